@@ -0,0 +1,95 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStore keeps blobs as files under root. The API serves them itself,
+// under baseURL.
+type LocalStore struct {
+	root    string
+	baseURL string
+}
+
+func NewLocalStore(root, baseURL string) (*LocalStore, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalStore{root: root, baseURL: baseURL}, nil
+}
+
+// Put writes to a temporary file first so readers never see half a blob.
+func (s *LocalStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+
+	if err := validKey(key); err != nil {
+		return err
+	}
+
+	target := filepath.Join(s.root, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+func (s *LocalStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+
+	if err := validKey(key); err != nil {
+		return nil, ErrNotFound
+	}
+
+	f, err := os.Open(filepath.Join(s.root, filepath.FromSlash(key)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// Delete removes the file and its directory once that is empty.
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+
+	if err := validKey(key); err != nil {
+		return err
+	}
+
+	target := filepath.Join(s.root, filepath.FromSlash(key))
+	if err := os.Remove(target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	os.Remove(filepath.Dir(target))
+	return nil
+}
+
+// DeletePrefix removes every blob under the directory prefix names.
+func (s *LocalStore) DeletePrefix(ctx context.Context, prefix string) error {
+
+	prefix = strings.TrimSuffix(prefix, "/")
+	if err := validKey(prefix); err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(s.root, filepath.FromSlash(prefix)))
+}
+
+func (s *LocalStore) URL(key string) string {
+	return s.baseURL + "/" + key
+}
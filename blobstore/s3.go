@@ -0,0 +1,114 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Config points at any S3-compatible service: AWS, MinIO, R2 and the like.
+// Endpoint is a host[:port] without scheme.
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+}
+
+// S3Store keeps blobs as objects in one bucket. URL points at baseURL, which
+// is either the bucket's public address or the API's own media route.
+type S3Store struct {
+	client  *minio.Client
+	bucket  string
+	baseURL string
+}
+
+func NewS3Store(cfg S3Config, baseURL string) (*S3Store, error) {
+
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, errors.New("s3 endpoint and bucket are required")
+	}
+
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3Store{
+		client:  client,
+		bucket:  cfg.Bucket,
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+
+	if err := validKey(key); err != nil {
+		return err
+	}
+
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{
+		ContentType:  contentType,
+		CacheControl: "public, max-age=31536000, immutable",
+	})
+	return err
+}
+
+// Open stats the object first: GetObject only reports a missing key on the
+// first read.
+func (s *S3Store) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+
+	if err := validKey(key); err != nil {
+		return nil, ErrNotFound
+	}
+
+	if _, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{}); err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+
+	if err := validKey(key); err != nil {
+		return err
+	}
+	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
+
+func (s *S3Store) DeletePrefix(ctx context.Context, prefix string) error {
+
+	prefix = strings.TrimSuffix(prefix, "/")
+	if err := validKey(prefix); err != nil {
+		return err
+	}
+	prefix += "/"
+
+	for object := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if object.Err != nil {
+			return object.Err
+		}
+		if err := s.client.RemoveObject(ctx, s.bucket, object.Key, minio.RemoveObjectOptions{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *S3Store) URL(key string) string {
+	return s.baseURL + "/" + key
+}
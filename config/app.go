@@ -3,14 +3,38 @@ package config
 import (
 	"log"
 	"os"
+	"strings"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Port        string `mapstructure:"PORT"`
-	Environment string `mapstructure:"ENVIRONMENT"`
-	DBConn      string `mapstructure:"DB_CONN"`
+	Port         string `mapstructure:"PORT"`
+	Environment  string `mapstructure:"ENVIRONMENT"`
+	DBConn       string `mapstructure:"DB_CONN"`
+	StorageDir   string `mapstructure:"STORAGE_DIR"`
+	JobWorkers   int    `mapstructure:"JOB_WORKERS"`
+	BaseCurrency string `mapstructure:"BASE_CURRENCY"`
+	// digits after the decimal point in stored base-currency prices; the
+	// default 0 keeps them in whole units, as they were always stored
+	BaseCurrencyExponent int `mapstructure:"BASE_CURRENCY_EXPONENT"`
+	// whether stored prices already contain tax (gross) or not (net)
+	PricesIncludeTax bool `mapstructure:"PRICES_INCLUDE_TAX"`
+	// scheme and host product URLs are built from, e.g. in QR codes
+	PublicURL string `mapstructure:"PUBLIC_URL"`
+	// where uploaded images are kept: "local" under STORAGE_DIR, or "s3"
+	BlobBackend   string `mapstructure:"BLOB_BACKEND"`
+	MaxImageBytes int64  `mapstructure:"MAX_IMAGE_BYTES"`
+	S3Endpoint    string `mapstructure:"S3_ENDPOINT"`
+	S3Region      string `mapstructure:"S3_REGION"`
+	S3Bucket      string `mapstructure:"S3_BUCKET"`
+	S3AccessKey   string `mapstructure:"S3_ACCESS_KEY"`
+	S3SecretKey   string `mapstructure:"S3_SECRET_KEY"`
+	S3UseSSL      bool   `mapstructure:"S3_USE_SSL"`
+	// public address of the bucket; without it images are served via /media/
+	S3PublicURL string `mapstructure:"S3_PUBLIC_URL"`
+	// sent as X-Admin-Key to see cost, margin and markup; empty disables that
+	AdminKey string `mapstructure:"ADMIN_KEY"`
 }
 
 func Load() Config {
@@ -19,6 +43,22 @@ func Load() Config {
 	viper.BindEnv("PORT")
 	viper.BindEnv("ENVIRONMENT")
 	viper.BindEnv("DB_CONN")
+	viper.BindEnv("STORAGE_DIR")
+	viper.BindEnv("JOB_WORKERS")
+	viper.BindEnv("BASE_CURRENCY")
+	viper.BindEnv("BASE_CURRENCY_EXPONENT")
+	viper.BindEnv("PRICES_INCLUDE_TAX")
+	viper.BindEnv("PUBLIC_URL")
+	viper.BindEnv("BLOB_BACKEND")
+	viper.BindEnv("MAX_IMAGE_BYTES")
+	viper.BindEnv("S3_ENDPOINT")
+	viper.BindEnv("S3_REGION")
+	viper.BindEnv("S3_BUCKET")
+	viper.BindEnv("S3_ACCESS_KEY")
+	viper.BindEnv("S3_SECRET_KEY")
+	viper.BindEnv("S3_USE_SSL")
+	viper.BindEnv("S3_PUBLIC_URL")
+	viper.BindEnv("ADMIN_KEY")
 
 	if _, err := os.Stat(".env"); err == nil {
 		viper.SetConfigFile(".env")
@@ -30,10 +70,62 @@ func Load() Config {
 		port = "8080"
 	}
 
+	storageDir := viper.GetString("STORAGE_DIR")
+	if storageDir == "" {
+		storageDir = "./storage"
+	}
+
+	jobWorkers := viper.GetInt("JOB_WORKERS")
+	if jobWorkers <= 0 {
+		jobWorkers = 2
+	}
+
+	// stored prices are minor units of this ISO 4217 currency
+	baseCurrency := strings.ToUpper(viper.GetString("BASE_CURRENCY"))
+	if baseCurrency == "" {
+		baseCurrency = "IDR"
+	}
+
+	baseCurrencyExponent := viper.GetInt("BASE_CURRENCY_EXPONENT")
+	if baseCurrencyExponent < 0 || baseCurrencyExponent > 4 {
+		log.Fatalf("BASE_CURRENCY_EXPONENT must be between 0 and 4, got %d", baseCurrencyExponent)
+	}
+
+	publicURL := strings.TrimRight(viper.GetString("PUBLIC_URL"), "/")
+	if publicURL == "" {
+		publicURL = "http://localhost:" + port
+	}
+
+	blobBackend := strings.ToLower(viper.GetString("BLOB_BACKEND"))
+	if blobBackend == "" {
+		blobBackend = "local"
+	}
+
+	maxImageBytes := viper.GetInt64("MAX_IMAGE_BYTES")
+	if maxImageBytes <= 0 {
+		maxImageBytes = 10 << 20
+	}
+
 	config := Config{
-		Port:        port,
-		Environment: viper.GetString("ENVIRONMENT"),
-		DBConn:      viper.GetString("DB_CONN"),
+		Port:                 port,
+		Environment:          viper.GetString("ENVIRONMENT"),
+		DBConn:               viper.GetString("DB_CONN"),
+		StorageDir:           storageDir,
+		JobWorkers:           jobWorkers,
+		BaseCurrency:         baseCurrency,
+		BaseCurrencyExponent: baseCurrencyExponent,
+		PricesIncludeTax:     viper.GetBool("PRICES_INCLUDE_TAX"),
+		PublicURL:            publicURL,
+		BlobBackend:          blobBackend,
+		MaxImageBytes:        maxImageBytes,
+		S3Endpoint:           viper.GetString("S3_ENDPOINT"),
+		S3Region:             viper.GetString("S3_REGION"),
+		S3Bucket:             viper.GetString("S3_BUCKET"),
+		S3AccessKey:          viper.GetString("S3_ACCESS_KEY"),
+		S3SecretKey:          viper.GetString("S3_SECRET_KEY"),
+		S3UseSSL:             viper.GetBool("S3_USE_SSL"),
+		S3PublicURL:          strings.TrimRight(viper.GetString("S3_PUBLIC_URL"), "/"),
+		AdminKey:             viper.GetString("ADMIN_KEY"),
 	}
 
 	if config.DBConn == "" {
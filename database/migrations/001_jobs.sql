@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS jobs (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    type          TEXT NOT NULL,
+    format        TEXT NOT NULL,
+    status        TEXT NOT NULL DEFAULT 'pending',
+    input_path    TEXT NOT NULL DEFAULT '',
+    artifact_path TEXT NOT NULL DEFAULT '',
+    total         INT NOT NULL DEFAULT 0,
+    processed     INT NOT NULL DEFAULT 0,
+    succeeded     INT NOT NULL DEFAULT 0,
+    failed        INT NOT NULL DEFAULT 0,
+    errors        JSONB NOT NULL DEFAULT '[]',
+    attempts      INT NOT NULL DEFAULT 0,
+    locked_at     TIMESTAMPTZ,
+    started_at    TIMESTAMPTZ,
+    finished_at   TIMESTAMPTZ,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_status_created_at ON jobs (status, created_at);
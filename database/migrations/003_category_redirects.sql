@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS category_redirects (
+    old_id     UUID PRIMARY KEY,
+    target_id  UUID NOT NULL REFERENCES categories (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_category_redirects_target_id ON category_redirects (target_id);
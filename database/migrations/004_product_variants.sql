@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS product_options (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    name       TEXT NOT NULL,
+    "values"   JSONB NOT NULL DEFAULT '[]',
+    position   INT NOT NULL DEFAULT 0,
+    UNIQUE (product_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS product_variants (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id     UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    sku            TEXT UNIQUE,
+    barcode        TEXT,
+    price_override BIGINT CHECK (price_override >= 0),
+    stock          INT NOT NULL DEFAULT 0 CHECK (stock >= 0),
+    options        JSONB NOT NULL DEFAULT '{}',
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_variants_product_id ON product_variants (product_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_variants_options ON product_variants (product_id, options);
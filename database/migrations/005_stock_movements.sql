@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id    UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    type          TEXT NOT NULL,
+    quantity      INT NOT NULL,
+    balance_after INT NOT NULL,
+    reason        TEXT NOT NULL DEFAULT '',
+    reference     TEXT NOT NULL DEFAULT '',
+    actor         TEXT NOT NULL DEFAULT '',
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_product_id_created_at ON stock_movements (product_id, created_at DESC);
+
+-- opening balance so existing stock is explained by the ledger
+INSERT INTO stock_movements (product_id, type, quantity, balance_after, reason)
+SELECT id, 'adjustment', stock, stock, 'opening balance'
+FROM products
+WHERE stock <> 0;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS reservations (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    status     TEXT NOT NULL DEFAULT 'active',
+    reference  TEXT NOT NULL DEFAULT '',
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_reservations_active_expires_at ON reservations (expires_at) WHERE status = 'active';
+
+CREATE TABLE IF NOT EXISTS reservation_lines (
+    reservation_id UUID NOT NULL REFERENCES reservations (id) ON DELETE CASCADE,
+    product_id     UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    quantity       INT NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (reservation_id, product_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_reservation_lines_product_id ON reservation_lines (product_id);
@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS warehouses (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code       TEXT NOT NULL UNIQUE,
+    name       TEXT NOT NULL,
+    address    TEXT NOT NULL DEFAULT '',
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_warehouses_single_default ON warehouses (is_default) WHERE is_default;
+
+CREATE TABLE IF NOT EXISTS stock_levels (
+    product_id   UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    warehouse_id UUID NOT NULL REFERENCES warehouses (id) ON DELETE RESTRICT,
+    quantity     INT NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (product_id, warehouse_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_levels_warehouse_id ON stock_levels (warehouse_id);
+
+ALTER TABLE stock_movements
+    ADD COLUMN IF NOT EXISTS warehouse_id UUID REFERENCES warehouses (id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS transfer_id UUID;
+
+-- all existing stock starts out in the default warehouse
+INSERT INTO warehouses (code, name, is_default)
+SELECT 'MAIN', 'Main warehouse', TRUE
+WHERE NOT EXISTS (SELECT 1 FROM warehouses WHERE is_default);
+
+INSERT INTO stock_levels (product_id, warehouse_id, quantity)
+SELECT p.id, w.id, p.stock
+FROM products p
+CROSS JOIN warehouses w
+WHERE w.is_default
+  AND p.stock > 0
+  AND NOT EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,6 @@
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS low_stock_threshold INT CHECK (low_stock_threshold >= 0),
+    ADD COLUMN IF NOT EXISTS reorder_qty INT NOT NULL DEFAULT 0 CHECK (reorder_qty >= 0);
+
+CREATE INDEX IF NOT EXISTS idx_products_low_stock_threshold ON products (low_stock_threshold)
+    WHERE low_stock_threshold IS NOT NULL;
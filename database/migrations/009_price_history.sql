@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS price_history (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id     UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    price          BIGINT NOT NULL,
+    previous_price BIGINT,
+    reason         TEXT NOT NULL DEFAULT '',
+    actor          TEXT NOT NULL DEFAULT '',
+    effective_at   TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_history_product_id_effective_at ON price_history (product_id, effective_at DESC);
+
+-- opening entry so lowest_price_30d has something to look back on
+INSERT INTO price_history (product_id, price, reason, effective_at)
+SELECT id, price, 'opening price', created_at
+FROM products;
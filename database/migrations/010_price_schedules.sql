@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS price_schedules (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    price      BIGINT NOT NULL CHECK (price >= 0),
+    starts_at  TIMESTAMPTZ NOT NULL,
+    ends_at    TIMESTAMPTZ,
+    priority   INT NOT NULL DEFAULT 0,
+    label      TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (ends_at IS NULL OR ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_schedules_product_id_starts_at ON price_schedules (product_id, starts_at);
+
+-- effective_price resolves the price a product sells at right now: the
+-- highest-priority schedule whose window is open, otherwise the list price.
+-- Overlapping windows of equal priority go to the one that started last.
+CREATE OR REPLACE FUNCTION effective_price(pid UUID, list_price BIGINT) RETURNS BIGINT AS $$
+    SELECT COALESCE((
+        SELECT ps.price
+        FROM price_schedules ps
+        WHERE ps.product_id = pid
+          AND ps.starts_at <= CURRENT_TIMESTAMP
+          AND (ps.ends_at IS NULL OR ps.ends_at > CURRENT_TIMESTAMP)
+        ORDER BY ps.priority DESC, ps.starts_at DESC, ps.created_at DESC
+        LIMIT 1
+    ), list_price)
+$$ LANGUAGE SQL STABLE;
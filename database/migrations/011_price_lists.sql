@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS price_lists (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code       TEXT NOT NULL UNIQUE,
+    name       TEXT NOT NULL,
+    currency   CHAR(3) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS price_list_items (
+    price_list_id UUID NOT NULL REFERENCES price_lists (id) ON DELETE CASCADE,
+    product_id    UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    price         BIGINT NOT NULL CHECK (price >= 0),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (price_list_id, product_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_list_items_product_id ON price_list_items (product_id);
+
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    base_currency  CHAR(3) NOT NULL,
+    quote_currency CHAR(3) NOT NULL,
+    rate           NUMERIC(24, 12) NOT NULL CHECK (rate > 0),
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (base_currency, quote_currency)
+);
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS tax_classes (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code       TEXT NOT NULL UNIQUE,
+    name       TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- rate_bp is in basis points, so 1100 is 11%
+CREATE TABLE IF NOT EXISTS tax_rates (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tax_class_id   UUID NOT NULL REFERENCES tax_classes (id) ON DELETE CASCADE,
+    rate_bp        INT NOT NULL CHECK (rate_bp >= 0 AND rate_bp <= 100000),
+    effective_from TIMESTAMPTZ NOT NULL,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (tax_class_id, effective_from)
+);
+
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS tax_class_id UUID REFERENCES tax_classes (id) ON DELETE SET NULL;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS tax_class_id UUID REFERENCES tax_classes (id) ON DELETE SET NULL;
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS customer_groups (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code       TEXT NOT NULL UNIQUE,
+    name       TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- a rule targets one product or one category (and its subcategories), for one
+-- customer group or, when customer_group_id is NULL, for everyone
+CREATE TABLE IF NOT EXISTS price_rules (
+    id                UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    customer_group_id UUID REFERENCES customer_groups (id) ON DELETE CASCADE,
+    product_id        UUID REFERENCES products (id) ON DELETE CASCADE,
+    category_id       UUID REFERENCES categories (id) ON DELETE CASCADE,
+    min_qty           INT NOT NULL DEFAULT 1 CHECK (min_qty >= 1),
+    discount_bp       INT CHECK (discount_bp > 0 AND discount_bp <= 10000),
+    fixed_price       BIGINT CHECK (fixed_price >= 0),
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((product_id IS NULL) <> (category_id IS NULL)),
+    CHECK ((discount_bp IS NULL) <> (fixed_price IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_rules_product_id ON price_rules (product_id) WHERE product_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_price_rules_category_id ON price_rules (category_id) WHERE category_id IS NOT NULL;
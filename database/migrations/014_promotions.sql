@@ -0,0 +1,35 @@
+-- a promotion without a code applies automatically; with a code it only
+-- applies when that coupon is presented
+CREATE TABLE IF NOT EXISTS promotions (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name         TEXT NOT NULL,
+    code         TEXT UNIQUE,
+    type         TEXT NOT NULL CHECK (type IN ('percent', 'fixed', 'buy_x_get_y', 'bundle')),
+    discount_bp  INT CHECK (discount_bp > 0 AND discount_bp <= 10000),
+    amount       BIGINT CHECK (amount > 0),
+    buy_qty      INT CHECK (buy_qty >= 1),
+    get_qty      INT CHECK (get_qty >= 1),
+    bundle_qty   INT CHECK (bundle_qty >= 2),
+    bundle_price BIGINT CHECK (bundle_price >= 0),
+    starts_at    TIMESTAMPTZ,
+    ends_at      TIMESTAMPTZ,
+    usage_limit  INT CHECK (usage_limit >= 1),
+    usage_count  INT NOT NULL DEFAULT 0,
+    priority     INT NOT NULL DEFAULT 0,
+    stackable    BOOLEAN NOT NULL DEFAULT TRUE,
+    active       BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (starts_at IS NULL OR ends_at IS NULL OR ends_at > starts_at)
+);
+
+-- a promotion without targets covers the whole catalog
+CREATE TABLE IF NOT EXISTS promotion_targets (
+    promotion_id UUID NOT NULL REFERENCES promotions (id) ON DELETE CASCADE,
+    product_id   UUID REFERENCES products (id) ON DELETE CASCADE,
+    category_id  UUID REFERENCES categories (id) ON DELETE CASCADE,
+    CHECK ((product_id IS NULL) <> (category_id IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_promotion_targets_product ON promotion_targets (promotion_id, product_id) WHERE product_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_promotion_targets_category ON promotion_targets (promotion_id, category_id) WHERE category_id IS NOT NULL;
@@ -0,0 +1,7 @@
+-- standard cost per unit, in the base currency's minor unit
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS cost_price BIGINT CHECK (cost_price >= 0);
+
+-- what each incoming unit actually cost, when known
+ALTER TABLE stock_movements
+    ADD COLUMN IF NOT EXISTS unit_cost BIGINT CHECK (unit_cost >= 0);
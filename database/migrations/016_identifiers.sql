@@ -0,0 +1,53 @@
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS sku TEXT UNIQUE,
+    ADD COLUMN IF NOT EXISTS barcode TEXT UNIQUE,
+    ADD COLUMN IF NOT EXISTS slug TEXT UNIQUE;
+
+ALTER TABLE categories
+    ADD COLUMN IF NOT EXISTS slug TEXT UNIQUE;
+
+-- derive slugs for existing rows from their names, suffixing part of the id
+-- where two names slugify alike
+UPDATE products p
+SET slug = s.slug
+FROM (
+    SELECT id,
+           base || CASE WHEN ROW_NUMBER() OVER (PARTITION BY base ORDER BY created_at, id) > 1
+                        THEN '-' || LEFT(id::TEXT, 8) ELSE '' END AS slug
+    FROM (
+        SELECT id, created_at,
+               COALESCE(NULLIF(TRIM(BOTH '-' FROM LOWER(REGEXP_REPLACE(name, '[^a-zA-Z0-9]+', '-', 'g'))), ''), 'product') AS base
+        FROM products
+    ) b
+) s
+WHERE p.id = s.id AND p.slug IS NULL;
+
+UPDATE categories c
+SET slug = s.slug
+FROM (
+    SELECT id,
+           base || CASE WHEN ROW_NUMBER() OVER (PARTITION BY base ORDER BY created_at, id) > 1
+                        THEN '-' || LEFT(id::TEXT, 8) ELSE '' END AS slug
+    FROM (
+        SELECT id, created_at,
+               COALESCE(NULLIF(TRIM(BOTH '-' FROM LOWER(REGEXP_REPLACE(name, '[^a-zA-Z0-9]+', '-', 'g'))), ''), 'category') AS base
+        FROM categories
+    ) b
+) s
+WHERE c.id = s.id AND c.slug IS NULL;
+
+ALTER TABLE products ALTER COLUMN slug SET NOT NULL;
+ALTER TABLE categories ALTER COLUMN slug SET NOT NULL;
+
+-- slugs a product or category used to have, so old URLs keep resolving
+CREATE TABLE IF NOT EXISTS product_slug_redirects (
+    old_slug   TEXT PRIMARY KEY,
+    product_id UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS category_slug_redirects (
+    old_slug    TEXT PRIMARY KEY,
+    category_id UUID NOT NULL REFERENCES categories (id) ON DELETE CASCADE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,28 @@
+-- files live in the blob store; storage_key is the original upload
+CREATE TABLE IF NOT EXISTS product_images (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id   UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    position     INT NOT NULL,
+    alt_text     TEXT NOT NULL DEFAULT '',
+    content_type TEXT NOT NULL,
+    width        INT NOT NULL,
+    height       INT NOT NULL,
+    size_bytes   BIGINT NOT NULL,
+    storage_key  TEXT NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_images_product ON product_images (product_id, position);
+
+-- resized copies of an image, one row per size and format
+CREATE TABLE IF NOT EXISTS product_image_variants (
+    image_id     UUID NOT NULL REFERENCES product_images (id) ON DELETE CASCADE,
+    name         TEXT NOT NULL,
+    format       TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    width        INT NOT NULL,
+    height       INT NOT NULL,
+    size_bytes   BIGINT NOT NULL,
+    storage_key  TEXT NOT NULL,
+    PRIMARY KEY (image_id, name, format)
+);
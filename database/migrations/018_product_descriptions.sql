@@ -0,0 +1,16 @@
+-- descriptions are markdown; HTML is rendered and sanitized on read
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS short_description TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
+
+-- name and identifiers weigh most, then the short and the long description;
+-- the 'simple' configuration does not stem, as names mix languages
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', name), 'A') ||
+        setweight(to_tsvector('simple', COALESCE(sku, '') || ' ' || COALESCE(barcode, '')), 'A') ||
+        setweight(to_tsvector('simple', short_description), 'B') ||
+        setweight(to_tsvector('simple', description), 'C')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING GIN (search_vector);
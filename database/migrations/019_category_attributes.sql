@@ -0,0 +1,14 @@
+-- the attribute schema products in a category follow; enum choices sit in "values"
+CREATE TABLE IF NOT EXISTS category_attributes (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    category_id UUID NOT NULL REFERENCES categories (id) ON DELETE CASCADE,
+    name        TEXT NOT NULL,
+    type        TEXT NOT NULL CHECK (type IN ('string', 'number', 'integer', 'boolean', 'enum')),
+    unit        TEXT NOT NULL DEFAULT '',
+    "values"    JSONB NOT NULL DEFAULT '[]',
+    required    BOOLEAN NOT NULL DEFAULT FALSE,
+    position    INT NOT NULL DEFAULT 0,
+    UNIQUE (category_id, name)
+);
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '{}';
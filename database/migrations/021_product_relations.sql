@@ -0,0 +1,13 @@
+-- product_id lists related_id under type, in position order; symmetric types
+-- (related, cross_sell) are stored once in each direction
+CREATE TABLE IF NOT EXISTS product_relations (
+    product_id UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    related_id UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    type       TEXT NOT NULL CHECK (type IN ('related', 'cross_sell', 'upsell', 'accessory', 'replacement')),
+    position   INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (product_id, type, related_id),
+    CHECK (product_id <> related_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_relations_related_id ON product_relations (related_id);
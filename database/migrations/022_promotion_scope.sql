@@ -0,0 +1,28 @@
+-- scope says outright whether a promotion covers the whole catalog or only
+-- its targets, instead of inferring it from a missing target list
+ALTER TABLE promotions ADD COLUMN IF NOT EXISTS scope TEXT NOT NULL DEFAULT 'catalog'
+    CHECK (scope IN ('catalog', 'targets'));
+
+UPDATE promotions p SET scope = 'targets'
+WHERE EXISTS (SELECT 1 FROM promotion_targets t WHERE t.promotion_id = p.id);
+
+-- targets go when their product or category is deleted; a targeted promotion
+-- that ends up with none is switched off rather than left to match nothing.
+-- Deferred to commit, so replacing the targets in one transaction is fine.
+CREATE OR REPLACE FUNCTION deactivate_untargeted_promotion() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE promotions p
+    SET active = FALSE, updated_at = CURRENT_TIMESTAMP
+    WHERE p.id = OLD.promotion_id
+      AND p.scope = 'targets'
+      AND p.active
+      AND NOT EXISTS (SELECT 1 FROM promotion_targets t WHERE t.promotion_id = p.id);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS promotion_targets_deactivate ON promotion_targets;
+CREATE CONSTRAINT TRIGGER promotion_targets_deactivate
+    AFTER DELETE ON promotion_targets
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION deactivate_untargeted_promotion();
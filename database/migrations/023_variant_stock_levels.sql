@@ -0,0 +1,12 @@
+-- 007 left products that already had variants out of stock_levels; their
+-- stock now moves through the default warehouse like any other, so whatever
+-- the warehouses do not hold yet starts out there
+INSERT INTO stock_levels (product_id, warehouse_id, quantity)
+SELECT p.id, w.id, p.stock - COALESCE((SELECT SUM(sl.quantity) FROM stock_levels sl WHERE sl.product_id = p.id), 0)
+FROM products p
+CROSS JOIN warehouses w
+WHERE w.is_default
+  AND EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id)
+  AND p.stock > COALESCE((SELECT SUM(sl.quantity) FROM stock_levels sl WHERE sl.product_id = p.id), 0)
+ON CONFLICT (product_id, warehouse_id)
+DO UPDATE SET quantity = stock_levels.quantity + EXCLUDED.quantity, updated_at = CURRENT_TIMESTAMP;
@@ -0,0 +1,55 @@
+-- variant barcodes become unique like product ones; where two variants
+-- already share one, the oldest keeps it
+UPDATE product_variants v
+SET barcode = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE v.barcode IS NOT NULL
+  AND EXISTS (
+      SELECT 1 FROM product_variants o
+      WHERE o.barcode = v.barcode
+        AND (o.created_at, o.id) < (v.created_at, v.id)
+  );
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_variants_barcode ON product_variants (barcode) WHERE barcode IS NOT NULL;
+
+-- a SKU or barcode names one thing across products and variants, or a
+-- lookup could not tell which was meant. Unique indexes cannot span two
+-- tables, so writes to either check the other, serialized per code by an
+-- advisory lock. Rows that clashed before this migration are left alone
+-- until their code changes.
+CREATE OR REPLACE FUNCTION check_product_code_clash() RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.sku IS NOT NULL AND (TG_OP = 'INSERT' OR NEW.sku IS DISTINCT FROM OLD.sku) THEN
+        PERFORM pg_advisory_xact_lock(hashtext('sku:' || NEW.sku));
+        IF TG_TABLE_NAME = 'products' THEN
+            IF EXISTS (SELECT 1 FROM product_variants WHERE sku = NEW.sku) THEN
+                RAISE EXCEPTION 'sku % clashes with a variant (variant_sku_clash)', NEW.sku USING ERRCODE = 'unique_violation';
+            END IF;
+        ELSIF EXISTS (SELECT 1 FROM products WHERE sku = NEW.sku) THEN
+            RAISE EXCEPTION 'sku % clashes with a product (product_sku_clash)', NEW.sku USING ERRCODE = 'unique_violation';
+        END IF;
+    END IF;
+
+    IF NEW.barcode IS NOT NULL AND (TG_OP = 'INSERT' OR NEW.barcode IS DISTINCT FROM OLD.barcode) THEN
+        PERFORM pg_advisory_xact_lock(hashtext('barcode:' || NEW.barcode));
+        IF TG_TABLE_NAME = 'products' THEN
+            IF EXISTS (SELECT 1 FROM product_variants WHERE barcode = NEW.barcode) THEN
+                RAISE EXCEPTION 'barcode % clashes with a variant (variant_barcode_clash)', NEW.barcode USING ERRCODE = 'unique_violation';
+            END IF;
+        ELSIF EXISTS (SELECT 1 FROM products WHERE barcode = NEW.barcode) THEN
+            RAISE EXCEPTION 'barcode % clashes with a product (product_barcode_clash)', NEW.barcode USING ERRCODE = 'unique_violation';
+        END IF;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS products_code_clash ON products;
+CREATE TRIGGER products_code_clash
+    BEFORE INSERT OR UPDATE OF sku, barcode ON products
+    FOR EACH ROW EXECUTE FUNCTION check_product_code_clash();
+
+DROP TRIGGER IF EXISTS product_variants_code_clash ON product_variants;
+CREATE TRIGGER product_variants_code_clash
+    BEFORE INSERT OR UPDATE OF sku, barcode ON product_variants
+    FOR EACH ROW EXECUTE FUNCTION check_product_code_clash();
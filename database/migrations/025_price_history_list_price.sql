@@ -0,0 +1,4 @@
+-- price_history.price is the effective price; list_price records the stored
+-- one too, so a list price change made while a sale schedule holds the
+-- effective price still leaves an entry. Older entries do not know it.
+ALTER TABLE price_history ADD COLUMN IF NOT EXISTS list_price BIGINT;
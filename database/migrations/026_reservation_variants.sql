@@ -0,0 +1,10 @@
+-- a product with variants is held per variant; its lines name the variant
+-- and a product can appear once per variant in the same reservation
+ALTER TABLE reservation_lines
+    ADD COLUMN IF NOT EXISTS variant_id UUID REFERENCES product_variants (id) ON DELETE CASCADE;
+
+ALTER TABLE reservation_lines DROP CONSTRAINT IF EXISTS reservation_lines_pkey;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reservation_lines_line
+    ON reservation_lines (reservation_id, product_id, COALESCE(variant_id, '00000000-0000-0000-0000-000000000000'::UUID));
+CREATE INDEX IF NOT EXISTS idx_reservation_lines_variant_id ON reservation_lines (variant_id) WHERE variant_id IS NOT NULL;
@@ -0,0 +1,64 @@
+package events
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	StockLow       = "stock.low"
+	StockRestored  = "stock.restored"
+	ProductDeleted = "product.deleted"
+)
+
+type Event struct {
+	Type       string      `json:"type"`
+	Payload    interface{} `json:"payload"`
+	OccurredAt time.Time   `json:"occurred_at"`
+}
+
+type Handler func(event Event)
+
+// Bus is a small in-process publish/subscribe hub. Handlers run on their own
+// goroutine so a slow webhook never blocks the request that raised the event.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[string][]Handler)}
+}
+
+// Subscribe registers handler for eventType, or for every event when
+// eventType is "*".
+func (b *Bus) Subscribe(eventType string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+func (b *Bus) Publish(eventType string, payload interface{}) {
+	event := Event{
+		Type:       eventType,
+		Payload:    payload,
+		OccurredAt: time.Now(),
+	}
+
+	b.mu.RLock()
+	handlers := append(append([]Handler{}, b.handlers[eventType]...), b.handlers["*"]...)
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		go func(handler Handler) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("Event handler for %s panicked: %v", event.Type, r)
+				}
+			}()
+			handler(event)
+		}(handler)
+	}
+}
@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+)
+
+// hasAdminKey reports whether r carries key in X-Admin-Key. Without a
+// configured key nobody is an admin.
+func hasAdminKey(r *http.Request, key string) bool {
+	given := r.Header.Get("X-Admin-Key")
+	return key != "" && subtle.ConstantTimeCompare([]byte(given), []byte(key)) == 1
+}
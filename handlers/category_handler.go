@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 
@@ -19,7 +20,13 @@ func NewCategoryHandler(service services.CategoryService) *CategoryHandler {
 
 func (h *CategoryHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 
-	categories, err := h.service.GetAll()
+	var categories []models.Category
+	var err error
+	if r.URL.Query().Get("with") == "stats" {
+		categories, err = h.service.GetAllWithStats()
+	} else {
+		categories, err = h.service.GetAll()
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -52,6 +59,23 @@ func (h *CategoryHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		status := http.StatusInternalServerError
 		if strings.Contains(err.Error(), "not found") {
+			// merged categories keep resolving to where they were merged into
+			if targetID, _ := h.service.FindRedirect(id); targetID != nil {
+				location := "/api/categories/" + targetID.String()
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Location", location)
+				w.WriteHeader(http.StatusMovedPermanently)
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"success":     false,
+					"message":     "category has been merged",
+					"redirect_to": location,
+					"data": map[string]string{
+						"id":        id,
+						"target_id": targetID.String(),
+					},
+				})
+				return
+			}
 			status = http.StatusNotFound
 		}
 		http.Error(w, err.Error(), status)
@@ -70,6 +94,45 @@ func (h *CategoryHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// GetBySlug answers an old slug with a 301 to the current one.
+func (h *CategoryHandler) GetBySlug(w http.ResponseWriter, r *http.Request) {
+
+	category, redirected, err := h.service.GetBySlug(strings.TrimPrefix(r.URL.Path, "/api/categories/by-slug/"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "required") {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	if redirected {
+		location := "/api/categories/by-slug/" + category.Slug
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", location)
+		w.WriteHeader(http.StatusMovedPermanently)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":     false,
+			"message":     "category slug has changed",
+			"redirect_to": location,
+			"data": map[string]string{
+				"id":   category.ID.String(),
+				"slug": category.Slug,
+			},
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    category,
+	})
+}
+
 func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 	var req models.CreateCategoryRequest
@@ -83,7 +146,9 @@ func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
 		status := http.StatusInternalServerError
 		if strings.Contains(err.Error(), "already exists") {
 			status = http.StatusConflict
-		} else if strings.Contains(err.Error(), "required") {
+		} else if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "cannot") || strings.Contains(err.Error(), "must") {
 			status = http.StatusBadRequest
 		}
 		http.Error(w, err.Error(), status)
@@ -120,7 +185,7 @@ func (h *CategoryHandler) Update(w http.ResponseWriter, r *http.Request) {
 			status = http.StatusNotFound
 		} else if strings.Contains(err.Error(), "already exists") {
 			status = http.StatusConflict
-		} else if strings.Contains(err.Error(), "required") {
+		} else if strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "cannot") || strings.Contains(err.Error(), "must") {
 			status = http.StatusBadRequest
 		}
 		http.Error(w, err.Error(), status)
@@ -142,11 +207,32 @@ func (h *CategoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.service.Delete(id)
+	query := r.URL.Query()
+	result, err := h.service.Delete(id, query.Get("on_products"), query.Get("target"))
+
+	// the blocking count goes out as data, so clients need not parse the message
+	var notEmpty *models.CategoryNotEmptyError
+	if errors.As(err, &notEmpty) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+			"data": map[string]interface{}{
+				"on_products": models.DeletePolicyRestrict,
+				"products":    notEmpty.Products,
+			},
+		})
+		return
+	}
+
 	if err != nil {
 		status := http.StatusInternalServerError
 		if strings.Contains(err.Error(), "not found") {
 			status = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "invalid") || strings.Contains(err.Error(), "required") ||
+			strings.Contains(err.Error(), "cannot") || strings.Contains(err.Error(), "must") {
+			status = http.StatusBadRequest
 		}
 		http.Error(w, err.Error(), status)
 		return
@@ -156,9 +242,7 @@ func (h *CategoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
 		"message": "category deleted successfully",
-		"data": map[string]string{
-			"id": id,
-		},
+		"data":    result,
 	})
 }
 
@@ -190,3 +274,202 @@ func (h *CategoryHandler) BulkCreate(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(response)
 }
+
+func (h *CategoryHandler) GetTree(w http.ResponseWriter, r *http.Request) {
+
+	tree, err := h.service.GetTree()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if tree == nil {
+		tree = []models.Category{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    tree,
+	})
+}
+
+func (h *CategoryHandler) GetAncestors(w http.ResponseWriter, r *http.Request) {
+	h.writeRelatives(w, r, "/ancestors", h.service.GetAncestors)
+}
+
+func (h *CategoryHandler) GetChildren(w http.ResponseWriter, r *http.Request) {
+	h.writeRelatives(w, r, "/children", h.service.GetChildren)
+}
+
+func (h *CategoryHandler) GetDescendants(w http.ResponseWriter, r *http.Request) {
+	h.writeRelatives(w, r, "/descendants", h.service.GetDescendants)
+}
+
+func (h *CategoryHandler) writeRelatives(w http.ResponseWriter, r *http.Request, suffix string, fetch func(id string) ([]models.Category, error)) {
+
+	id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/categories/"), suffix)
+	if id == "" {
+		http.Error(w, "category ID is required", http.StatusBadRequest)
+		return
+	}
+
+	categories, err := fetch(id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "invalid") {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	if categories == nil {
+		categories = []models.Category{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    categories,
+		"meta": map[string]interface{}{
+			"count": len(categories),
+		},
+	})
+}
+
+func (h *CategoryHandler) Merge(w http.ResponseWriter, r *http.Request) {
+
+	id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/categories/"), "/merge")
+	if id == "" {
+		http.Error(w, "category ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var req models.MergeCategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.service.Merge(id, &req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "conflicts") {
+			status = http.StatusConflict
+		} else if strings.Contains(err.Error(), "invalid") || strings.Contains(err.Error(), "required") ||
+			strings.Contains(err.Error(), "cannot") || strings.Contains(err.Error(), "must") {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "category merged successfully",
+		"data":    result,
+	})
+}
+
+func (h *CategoryHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+
+	id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/categories/"), "/stats")
+	if id == "" {
+		http.Error(w, "category ID is required", http.StatusBadRequest)
+		return
+	}
+
+	stats, err := h.service.GetStats(id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "invalid") {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    stats,
+	})
+}
+
+func (h *CategoryHandler) GetAttributes(w http.ResponseWriter, r *http.Request) {
+
+	id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/categories/"), "/attributes")
+	if id == "" {
+		http.Error(w, "category ID is required", http.StatusBadRequest)
+		return
+	}
+
+	attributes, err := h.service.GetAttributes(id)
+	if err != nil {
+		writeAttributeError(w, err)
+		return
+	}
+
+	if attributes == nil {
+		attributes = []models.CategoryAttribute{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    attributes,
+		"meta": map[string]interface{}{
+			"count": len(attributes),
+		},
+	})
+}
+
+func (h *CategoryHandler) SetAttributes(w http.ResponseWriter, r *http.Request) {
+
+	id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/categories/"), "/attributes")
+	if id == "" {
+		http.Error(w, "category ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var req models.SetAttributesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	attributes, err := h.service.SetAttributes(id, &req)
+	if err != nil {
+		writeAttributeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "attributes updated successfully",
+		"data":    attributes,
+		"meta": map[string]interface{}{
+			"count": len(attributes),
+		},
+	})
+}
+
+func writeAttributeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if strings.Contains(err.Error(), "not found") {
+		status = http.StatusNotFound
+	} else if strings.Contains(err.Error(), "invalid") || strings.Contains(err.Error(), "required") ||
+		strings.Contains(err.Error(), "cannot") || strings.Contains(err.Error(), "must") {
+		status = http.StatusBadRequest
+	}
+	http.Error(w, err.Error(), status)
+}
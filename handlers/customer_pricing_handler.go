@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/anggakrnwn/product-catalog-api/services"
+	"github.com/google/uuid"
+)
+
+type CustomerPricingHandler struct {
+	service services.CustomerPricingService
+}
+
+func NewCustomerPricingHandler(service services.CustomerPricingService) *CustomerPricingHandler {
+	return &CustomerPricingHandler{service: service}
+}
+
+func (h *CustomerPricingHandler) GetGroups(w http.ResponseWriter, r *http.Request) {
+
+	groups, err := h.service.GetGroups()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if groups == nil {
+		groups = []models.CustomerGroup{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    groups,
+		"meta": map[string]interface{}{
+			"count": len(groups),
+		},
+	})
+}
+
+func (h *CustomerPricingHandler) GetGroupByID(w http.ResponseWriter, r *http.Request) {
+
+	id, ok := pathUUID(pathSegments(r, "/api/customer-groups/"), 0)
+	if !ok {
+		http.Error(w, "Invalid customer group ID", http.StatusBadRequest)
+		return
+	}
+
+	group, err := h.service.GetGroupByID(id)
+	if err != nil {
+		writeCustomerPricingError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    group,
+	})
+}
+
+func (h *CustomerPricingHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+
+	var req models.CreateCustomerGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	group, err := h.service.CreateGroup(&req)
+	if err != nil {
+		writeCustomerPricingError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "customer group created successfully",
+		"data":    group,
+	})
+}
+
+func (h *CustomerPricingHandler) UpdateGroup(w http.ResponseWriter, r *http.Request) {
+
+	id, ok := pathUUID(pathSegments(r, "/api/customer-groups/"), 0)
+	if !ok {
+		http.Error(w, "Invalid customer group ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.UpdateCustomerGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	group, err := h.service.UpdateGroup(id, &req)
+	if err != nil {
+		writeCustomerPricingError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "customer group updated successfully",
+		"data":    group,
+	})
+}
+
+func (h *CustomerPricingHandler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+
+	id, ok := pathUUID(pathSegments(r, "/api/customer-groups/"), 0)
+	if !ok {
+		http.Error(w, "Invalid customer group ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteGroup(id); err != nil {
+		writeCustomerPricingError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "customer group deleted successfully",
+		"data": map[string]string{
+			"id": id.String(),
+		},
+	})
+}
+
+func (h *CustomerPricingHandler) GetRules(w http.ResponseWriter, r *http.Request) {
+
+	filter, err := priceRuleFilterFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rules, err := h.service.GetRules(filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if rules == nil {
+		rules = []models.PriceRule{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    rules,
+		"meta": map[string]interface{}{
+			"count": len(rules),
+		},
+	})
+}
+
+func (h *CustomerPricingHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
+
+	var req models.CreatePriceRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	rule, err := h.service.CreateRule(&req)
+	if err != nil {
+		writeCustomerPricingError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "price rule created successfully",
+		"data":    rule,
+	})
+}
+
+func (h *CustomerPricingHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+
+	id, ok := pathUUID(pathSegments(r, "/api/price-rules/"), 0)
+	if !ok {
+		http.Error(w, "Invalid price rule ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteRule(id); err != nil {
+		writeCustomerPricingError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "price rule deleted successfully",
+		"data": map[string]string{
+			"id": id.String(),
+		},
+	})
+}
+
+func (h *CustomerPricingHandler) Quote(w http.ResponseWriter, r *http.Request) {
+
+	var req models.QuoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	quote, err := h.service.Quote(&req)
+	if err != nil {
+		writeCustomerPricingError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    quote,
+	})
+}
+
+// priceRuleFilterFromQuery reads ?customer_group_id=, ?product_id= and ?category_id=.
+func priceRuleFilterFromQuery(r *http.Request) (models.PriceRuleFilter, error) {
+
+	var filter models.PriceRuleFilter
+	query := r.URL.Query()
+
+	parse := func(name string) (*uuid.UUID, error) {
+		v := query.Get(name)
+		if v == "" {
+			return nil, nil
+		}
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return nil, errors.New("invalid " + name)
+		}
+		return &id, nil
+	}
+
+	var err error
+	if filter.CustomerGroupID, err = parse("customer_group_id"); err != nil {
+		return filter, err
+	}
+	if filter.ProductID, err = parse("product_id"); err != nil {
+		return filter, err
+	}
+	if filter.CategoryID, err = parse("category_id"); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
+func writeCustomerPricingError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if strings.Contains(err.Error(), "not found") {
+		status = http.StatusNotFound
+	} else if strings.Contains(err.Error(), "already exists") {
+		status = http.StatusConflict
+	} else if strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "must") || strings.Contains(err.Error(), "cannot") {
+		status = http.StatusBadRequest
+	}
+	http.Error(w, err.Error(), status)
+}
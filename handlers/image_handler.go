@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/anggakrnwn/product-catalog-api/services"
+)
+
+type ImageHandler struct {
+	service  services.ImageService
+	maxBytes int64
+}
+
+// NewImageHandler accepts uploads of up to maxBytes per image.
+func NewImageHandler(service services.ImageService, maxBytes int64) *ImageHandler {
+	return &ImageHandler{
+		service:  service,
+		maxBytes: maxBytes,
+	}
+}
+
+func (h *ImageHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+
+	productID, ok := pathUUID(pathSegments(r, "/api/products/"), 0)
+	if !ok {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	images, err := h.service.GetAll(productID)
+	if err != nil {
+		writeImageError(w, err)
+		return
+	}
+
+	if images == nil {
+		images = []models.ProductImage{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    images,
+		"meta": map[string]interface{}{
+			"count": len(images),
+		},
+	})
+}
+
+// Upload takes a multipart form with the image in "file" and an optional
+// "alt_text". The file's type is sniffed from its content, not trusted from
+// the form.
+func (h *ImageHandler) Upload(w http.ResponseWriter, r *http.Request) {
+
+	productID, ok := pathUUID(pathSegments(r, "/api/products/"), 0)
+	if !ok {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	// leave room for the rest of the form around the file
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxBytes+1<<20)
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, fmt.Sprintf("image cannot be larger than %d bytes", h.maxBytes), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Invalid multipart form", http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "file is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, h.maxBytes+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if int64(len(data)) > h.maxBytes {
+		http.Error(w, fmt.Sprintf("image cannot be larger than %d bytes", h.maxBytes), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	image, err := h.service.Upload(productID, data, r.FormValue("alt_text"))
+	if err != nil {
+		writeImageError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "image uploaded successfully",
+		"data":    image,
+	})
+}
+
+func (h *ImageHandler) Update(w http.ResponseWriter, r *http.Request) {
+
+	segments := pathSegments(r, "/api/products/")
+	productID, ok := pathUUID(segments, 0)
+	if !ok {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+	imageID, ok := pathUUID(segments, 2)
+	if !ok {
+		http.Error(w, "Invalid image ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.UpdateProductImageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	image, err := h.service.Update(productID, imageID, &req)
+	if err != nil {
+		writeImageError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "image updated successfully",
+		"data":    image,
+	})
+}
+
+func (h *ImageHandler) Reorder(w http.ResponseWriter, r *http.Request) {
+
+	productID, ok := pathUUID(pathSegments(r, "/api/products/"), 0)
+	if !ok {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.ReorderProductImagesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	images, err := h.service.Reorder(productID, &req)
+	if err != nil {
+		writeImageError(w, err)
+		return
+	}
+
+	if images == nil {
+		images = []models.ProductImage{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "images reordered successfully",
+		"data":    images,
+		"meta": map[string]interface{}{
+			"count": len(images),
+		},
+	})
+}
+
+func (h *ImageHandler) Delete(w http.ResponseWriter, r *http.Request) {
+
+	segments := pathSegments(r, "/api/products/")
+	productID, ok := pathUUID(segments, 0)
+	if !ok {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+	imageID, ok := pathUUID(segments, 2)
+	if !ok {
+		http.Error(w, "Invalid image ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Delete(productID, imageID); err != nil {
+		writeImageError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "image deleted successfully",
+		"data": map[string]string{
+			"id": imageID.String(),
+		},
+	})
+}
+
+func writeImageError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if strings.Contains(err.Error(), "not found") {
+		status = http.StatusNotFound
+	} else if strings.Contains(err.Error(), "must") || strings.Contains(err.Error(), "cannot") ||
+		strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "invalid") {
+		status = http.StatusBadRequest
+	}
+	http.Error(w, err.Error(), status)
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/anggakrnwn/product-catalog-api/services"
+)
+
+type InventoryHandler struct {
+	service  services.InventoryService
+	adminKey string
+}
+
+func NewInventoryHandler(service services.InventoryService, adminKey string) *InventoryHandler {
+	return &InventoryHandler{service: service, adminKey: adminKey}
+}
+
+func (h *InventoryHandler) GetLowStock(w http.ResponseWriter, r *http.Request) {
+
+	groups, err := h.service.GetLowStock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if groups == nil {
+		groups = []models.LowStockGroup{}
+	}
+
+	count := 0
+	for _, g := range groups {
+		count += len(g.Products)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    groups,
+		"meta": map[string]interface{}{
+			"categories": len(groups),
+			"products":   count,
+		},
+	})
+}
+
+func (h *InventoryHandler) GetReplenishmentCSV(w http.ResponseWriter, r *http.Request) {
+
+	items, err := h.service.GetReplenishment()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// built in memory first, so a failure can still be reported as an error
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	err = writer.Write([]string{
+		"product_id", "name", "category", "stock", "reserved", "available",
+		"low_stock_threshold", "reorder_qty", "suggested_qty",
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for _, item := range items {
+		err := writer.Write([]string{
+			item.ID.String(),
+			item.Name,
+			item.CategoryName,
+			strconv.Itoa(item.Stock),
+			strconv.Itoa(item.Reserved),
+			strconv.Itoa(item.Available),
+			strconv.Itoa(*item.LowStockThreshold),
+			strconv.Itoa(item.ReorderQty),
+			strconv.Itoa(item.SuggestedQty),
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="replenishment.csv"`)
+	if _, err := buf.WriteTo(w); err != nil {
+		log.Printf("replenishment csv: %v", err)
+	}
+}
+
+// GetValuation reports stock at cost, so it needs the admin key.
+func (h *InventoryHandler) GetValuation(w http.ResponseWriter, r *http.Request) {
+	if !hasAdminKey(r, h.adminKey) {
+		http.Error(w, "inventory valuation requires a valid X-Admin-Key header", http.StatusForbidden)
+		return
+	}
+
+	valuation, err := h.service.GetValuation(r.URL.Query().Get("method"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "must") {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    valuation,
+		"meta": map[string]interface{}{
+			"categories": len(valuation.Categories),
+		},
+	})
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/anggakrnwn/product-catalog-api/services"
+)
+
+const maxImportSize = 100 << 20
+
+type JobHandler struct {
+	service services.JobService
+}
+
+func NewJobHandler(service services.JobService) *JobHandler {
+	return &JobHandler{service: service}
+}
+
+// Create accepts a JSON body for exports, or a multipart form with "type",
+// "format" and a "file" field for imports.
+func (h *JobHandler) Create(w http.ResponseWriter, r *http.Request) {
+
+	var req models.CreateJobRequest
+	var input io.Reader
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			http.Error(w, "Invalid multipart form", http.StatusBadRequest)
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+
+		req.Type = r.FormValue("type")
+		req.Format = r.FormValue("format")
+
+		file, header, err := r.FormFile("file")
+		if err == nil {
+			defer file.Close()
+			input = file
+			if req.Format == "" {
+				req.Format = strings.TrimPrefix(filepath.Ext(header.Filename), ".")
+			}
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.service.Create(&req, input)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "must be") {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/jobs/"+job.ID.String())
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "job queued successfully",
+		"data":    job,
+	})
+}
+
+func (h *JobHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+
+	id := strings.TrimPrefix(r.URL.Path, "/api/jobs/")
+	if id == "" {
+		http.Error(w, "job ID is required", http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.service.GetByID(id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "invalid") {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	if job.Status == models.JobStatusCompleted && job.Type == models.JobTypeExport {
+		job.DownloadURL = "/api/jobs/" + job.ID.String() + "/download"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    job,
+	})
+}
+
+func (h *JobHandler) Download(w http.ResponseWriter, r *http.Request) {
+
+	id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/jobs/"), "/download")
+	if id == "" {
+		http.Error(w, "job ID is required", http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.service.GetArtifact(id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "invalid") {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	contentType := "text/csv"
+	if job.Format == models.JobFormatJSON {
+		contentType = "application/json"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filepath.Base(job.ArtifactPath)+`"`)
+	http.ServeFile(w, r, job.ArtifactPath)
+}
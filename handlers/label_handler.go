@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/anggakrnwn/product-catalog-api/services"
+	"github.com/google/uuid"
+)
+
+type LabelHandler struct {
+	service services.LabelService
+}
+
+func NewLabelHandler(service services.LabelService) *LabelHandler {
+	return &LabelHandler{service: service}
+}
+
+// GetBarcodePNG serves /api/products/{id}/barcode.png (optional query:
+// module, the narrowest bar width in pixels, and height).
+func (h *LabelHandler) GetBarcodePNG(w http.ResponseWriter, r *http.Request) {
+	h.barcode(w, r, "image/png", h.service.BarcodePNG)
+}
+
+func (h *LabelHandler) GetBarcodeSVG(w http.ResponseWriter, r *http.Request) {
+	h.barcode(w, r, "image/svg+xml", h.service.BarcodeSVG)
+}
+
+func (h *LabelHandler) barcode(w http.ResponseWriter, r *http.Request, contentType string, render func(uuid.UUID, int, int) (*models.BarcodeImage, error)) {
+
+	productID, ok := pathUUID(pathSegments(r, "/api/products/"), 0)
+	if !ok {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	moduleWidth, ok := queryInt(r, "module", 3)
+	if !ok {
+		http.Error(w, "module must be an integer", http.StatusBadRequest)
+		return
+	}
+	height, ok := queryInt(r, "height", 80)
+	if !ok {
+		http.Error(w, "height must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	img, err := render(productID, moduleWidth, height)
+	if err != nil {
+		writeLabelError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Barcode-Symbology", img.Symbology)
+	w.Header().Set("X-Barcode-Content", img.Content)
+	w.Write(img.Data)
+}
+
+// GetQRCode serves /api/products/{id}/qr.png (optional query: size in pixels).
+func (h *LabelHandler) GetQRCode(w http.ResponseWriter, r *http.Request) {
+
+	productID, ok := pathUUID(pathSegments(r, "/api/products/"), 0)
+	if !ok {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	size, ok := queryInt(r, "size", 256)
+	if !ok {
+		http.Error(w, "size must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	data, err := h.service.QRCode(productID, size)
+	if err != nil {
+		writeLabelError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(data)
+}
+
+func (h *LabelHandler) CreateSheet(w http.ResponseWriter, r *http.Request) {
+
+	var req models.LabelSheetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	data, err := h.service.Sheet(&req)
+	if err != nil {
+		writeLabelError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", `inline; filename="labels.pdf"`)
+	w.Write(data)
+}
+
+// queryInt reads an integer query parameter, falling back to def when absent.
+func queryInt(r *http.Request, name string, def int) (int, bool) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+func writeLabelError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if strings.Contains(err.Error(), "not found") {
+		status = http.StatusNotFound
+	} else if strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "must") || strings.Contains(err.Error(), "cannot") || strings.Contains(err.Error(), "invalid") {
+		status = http.StatusBadRequest
+	}
+	http.Error(w, err.Error(), status)
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/anggakrnwn/product-catalog-api/blobstore"
+)
+
+// MediaHandler serves blobs under /media/ for stores without a public URL of
+// their own. Keys never change content, so responses cache for a year.
+type MediaHandler struct {
+	store blobstore.BlobStore
+}
+
+func NewMediaHandler(store blobstore.BlobStore) *MediaHandler {
+	return &MediaHandler{store: store}
+}
+
+func (h *MediaHandler) Serve(w http.ResponseWriter, r *http.Request) {
+
+	key := strings.TrimPrefix(r.URL.Path, "/media/")
+
+	blob, err := h.store.Open(r.Context(), key)
+	if err != nil {
+		if errors.Is(err, blobstore.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer blob.Close()
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	io.Copy(w, blob)
+}
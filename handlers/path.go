@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// pathSegments splits the part of the URL path after prefix, so
+// "/api/products/1/variants/2" with prefix "/api/products/" gives [1 variants 2].
+func pathSegments(r *http.Request, prefix string) []string {
+	trimmed := strings.Trim(strings.TrimPrefix(r.URL.Path, prefix), "/")
+	if trimmed == "" {
+		return nil
+	}
+	return strings.Split(trimmed, "/")
+}
+
+// pathUUID parses the segment at index i as a UUID.
+func pathUUID(segments []string, i int) (uuid.UUID, bool) {
+	if i >= len(segments) {
+		return uuid.Nil, false
+	}
+	id, err := uuid.Parse(segments[i])
+	if err != nil {
+		return uuid.Nil, false
+	}
+	return id, true
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/anggakrnwn/product-catalog-api/services"
+)
+
+type PriceHandler struct {
+	service services.PriceService
+}
+
+func NewPriceHandler(service services.PriceService) *PriceHandler {
+	return &PriceHandler{service: service}
+}
+
+func (h *PriceHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
+
+	productID, ok := pathUUID(pathSegments(r, "/api/products/"), 0)
+	if !ok {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	changes, total, err := h.service.GetHistory(productID, limit, offset)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	if changes == nil {
+		changes = []models.PriceChange{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    changes,
+		"meta": map[string]interface{}{
+			"count":  len(changes),
+			"total":  total,
+			"offset": offset,
+		},
+	})
+}
+
+func (h *PriceHandler) GetSchedules(w http.ResponseWriter, r *http.Request) {
+
+	productID, ok := pathUUID(pathSegments(r, "/api/products/"), 0)
+	if !ok {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	schedules, err := h.service.GetSchedules(productID)
+	if err != nil {
+		writePriceError(w, err)
+		return
+	}
+
+	if schedules == nil {
+		schedules = []models.PriceSchedule{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    schedules,
+		"meta": map[string]interface{}{
+			"count": len(schedules),
+		},
+	})
+}
+
+func (h *PriceHandler) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+
+	productID, ok := pathUUID(pathSegments(r, "/api/products/"), 0)
+	if !ok {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.CreatePriceScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	schedule, err := h.service.CreateSchedule(productID, &req)
+	if err != nil {
+		writePriceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "price schedule created successfully",
+		"data":    schedule,
+	})
+}
+
+func (h *PriceHandler) DeleteSchedule(w http.ResponseWriter, r *http.Request) {
+
+	segments := pathSegments(r, "/api/products/")
+	productID, ok := pathUUID(segments, 0)
+	if !ok {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	id, ok := pathUUID(segments, 2)
+	if !ok {
+		http.Error(w, "Invalid schedule ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteSchedule(productID, id); err != nil {
+		writePriceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "price schedule deleted successfully",
+	})
+}
+
+func writePriceError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if strings.Contains(err.Error(), "not found") {
+		status = http.StatusNotFound
+	} else if strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "must") {
+		status = http.StatusBadRequest
+	}
+	http.Error(w, err.Error(), status)
+}
@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/anggakrnwn/product-catalog-api/services"
+)
+
+type PriceListHandler struct {
+	service services.PriceListService
+}
+
+func NewPriceListHandler(service services.PriceListService) *PriceListHandler {
+	return &PriceListHandler{service: service}
+}
+
+func (h *PriceListHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+
+	lists, err := h.service.GetAll()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if lists == nil {
+		lists = []models.PriceList{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    lists,
+		"meta": map[string]interface{}{
+			"count": len(lists),
+		},
+	})
+}
+
+func (h *PriceListHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+
+	id, ok := pathUUID(pathSegments(r, "/api/price-lists/"), 0)
+	if !ok {
+		http.Error(w, "Invalid price list ID", http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.service.GetByID(id)
+	if err != nil {
+		writePriceListError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    list,
+	})
+}
+
+func (h *PriceListHandler) Create(w http.ResponseWriter, r *http.Request) {
+
+	var req models.CreatePriceListRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.service.Create(&req)
+	if err != nil {
+		writePriceListError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "price list created successfully",
+		"data":    list,
+	})
+}
+
+func (h *PriceListHandler) Update(w http.ResponseWriter, r *http.Request) {
+
+	id, ok := pathUUID(pathSegments(r, "/api/price-lists/"), 0)
+	if !ok {
+		http.Error(w, "Invalid price list ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.UpdatePriceListRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.service.Update(id, &req)
+	if err != nil {
+		writePriceListError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "price list updated successfully",
+		"data":    list,
+	})
+}
+
+func (h *PriceListHandler) Delete(w http.ResponseWriter, r *http.Request) {
+
+	id, ok := pathUUID(pathSegments(r, "/api/price-lists/"), 0)
+	if !ok {
+		http.Error(w, "Invalid price list ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Delete(id); err != nil {
+		writePriceListError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "price list deleted successfully",
+		"data": map[string]string{
+			"id": id.String(),
+		},
+	})
+}
+
+func (h *PriceListHandler) GetItems(w http.ResponseWriter, r *http.Request) {
+
+	id, ok := pathUUID(pathSegments(r, "/api/price-lists/"), 0)
+	if !ok {
+		http.Error(w, "Invalid price list ID", http.StatusBadRequest)
+		return
+	}
+
+	items, err := h.service.GetItems(id)
+	if err != nil {
+		writePriceListError(w, err)
+		return
+	}
+
+	if items == nil {
+		items = []models.PriceListItem{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    items,
+		"meta": map[string]interface{}{
+			"count": len(items),
+		},
+	})
+}
+
+func (h *PriceListHandler) SetItems(w http.ResponseWriter, r *http.Request) {
+
+	id, ok := pathUUID(pathSegments(r, "/api/price-lists/"), 0)
+	if !ok {
+		http.Error(w, "Invalid price list ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.SetPriceListItemsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	items, err := h.service.SetItems(id, &req)
+	if err != nil {
+		writePriceListError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "price list items saved successfully",
+		"data":    items,
+	})
+}
+
+func (h *PriceListHandler) DeleteItem(w http.ResponseWriter, r *http.Request) {
+
+	segments := pathSegments(r, "/api/price-lists/")
+	id, ok := pathUUID(segments, 0)
+	if !ok {
+		http.Error(w, "Invalid price list ID", http.StatusBadRequest)
+		return
+	}
+
+	productID, ok := pathUUID(segments, 2)
+	if !ok {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteItem(id, productID); err != nil {
+		writePriceListError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "price list item deleted successfully",
+	})
+}
+
+func (h *PriceListHandler) GetRates(w http.ResponseWriter, r *http.Request) {
+
+	rates, err := h.service.GetRates()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if rates == nil {
+		rates = []models.ExchangeRate{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    rates,
+		"meta": map[string]interface{}{
+			"count": len(rates),
+		},
+	})
+}
+
+func (h *PriceListHandler) SetRate(w http.ResponseWriter, r *http.Request) {
+
+	var req models.SetExchangeRateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	rate, err := h.service.SetRate(&req)
+	if err != nil {
+		writePriceListError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "exchange rate saved successfully",
+		"data":    rate,
+	})
+}
+
+func (h *PriceListHandler) DeleteRate(w http.ResponseWriter, r *http.Request) {
+
+	segments := pathSegments(r, "/api/exchange-rates/")
+	if len(segments) != 2 {
+		http.Error(w, "base and quote currency are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteRate(segments[0], segments[1]); err != nil {
+		writePriceListError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "exchange rate deleted successfully",
+	})
+}
+
+func writePriceListError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if strings.Contains(err.Error(), "not found") {
+		status = http.StatusNotFound
+	} else if strings.Contains(err.Error(), "already exists") {
+		status = http.StatusConflict
+	} else if strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "must") ||
+		strings.Contains(err.Error(), "cannot") || strings.Contains(err.Error(), "invalid") {
+		status = http.StatusBadRequest
+	}
+	http.Error(w, err.Error(), status)
+}
@@ -2,30 +2,102 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/anggakrnwn/product-catalog-api/markdown"
 	"github.com/anggakrnwn/product-catalog-api/models"
 	"github.com/anggakrnwn/product-catalog-api/services"
 	"github.com/google/uuid"
 )
 
 type ProductHandler struct {
-	service services.ProductService
+	service   services.ProductService
+	pricing   services.PricingService
+	images    services.ImageService
+	tags      services.TagService
+	relations services.RelationService
+	adminKey  string
 }
 
-func NewProductHandler(service services.ProductService) *ProductHandler {
-	return &ProductHandler{service: service}
+func NewProductHandler(service services.ProductService, pricing services.PricingService, images services.ImageService, tags services.TagService, relations services.RelationService, adminKey string) *ProductHandler {
+	return &ProductHandler{
+		service:   service,
+		pricing:   pricing,
+		images:    images,
+		tags:      tags,
+		relations: relations,
+		adminKey:  adminKey,
+	}
 }
 
 func (h *ProductHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 
-	products, err := h.service.GetAll()
+	filter, err := productFilterFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	priceCtx, err := priceContextFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	renderHTML, err := renderFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	admin, err := h.adminView(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	filter.IncludeCost = admin
+
+	products, err := h.service.List(filter)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	for i := range products {
+		showCost(&products[i], admin)
+	}
+
+	if err := h.pricing.Apply(products, priceCtx); err != nil {
+		writePricingError(w, err)
+		return
+	}
+
+	if err := h.images.Attach(products); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.tags.Attach(products); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if renderHTML {
+		for i := range products {
+			if err := renderDescriptions(&products[i]); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+
 	if products == nil {
 		products = []models.Product{}
 	}
@@ -57,21 +129,151 @@ func (h *ProductHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	product, err := h.service.GetWithCategory(id)
+	h.writeDetail(w, r, id, nil)
+}
+
+func (h *ProductHandler) GetBySKU(w http.ResponseWriter, r *http.Request) {
+	h.lookup(w, r, strings.TrimPrefix(r.URL.Path, "/api/products/by-sku/"), h.service.FindBySKU)
+}
+
+func (h *ProductHandler) GetByBarcode(w http.ResponseWriter, r *http.Request) {
+	h.lookup(w, r, strings.TrimPrefix(r.URL.Path, "/api/products/by-barcode/"), h.service.FindByBarcode)
+}
+
+// GetBySlug answers an old slug with a 301 to the current one.
+func (h *ProductHandler) GetBySlug(w http.ResponseWriter, r *http.Request) {
+	h.lookup(w, r, strings.TrimPrefix(r.URL.Path, "/api/products/by-slug/"), h.service.FindBySlug)
+}
+
+func (h *ProductHandler) lookup(w http.ResponseWriter, r *http.Request, value string, find func(string) (*models.ProductLookup, error)) {
+
+	found, err := find(value)
 	if err != nil {
 		status := http.StatusInternalServerError
 		if strings.Contains(err.Error(), "not found") {
 			status = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "required") {
+			status = http.StatusBadRequest
 		}
 		http.Error(w, err.Error(), status)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	if found.Redirected {
+		location := "/api/products/by-slug/" + found.Slug
+		if r.URL.RawQuery != "" {
+			location += "?" + r.URL.RawQuery
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", location)
+		w.WriteHeader(http.StatusMovedPermanently)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":     false,
+			"message":     "product slug has changed",
+			"redirect_to": location,
+			"data": map[string]string{
+				"id":   found.ProductID.String(),
+				"slug": found.Slug,
+			},
+		})
+		return
+	}
+
+	h.writeDetail(w, r, found.ProductID, found.VariantID)
+}
+
+// writeDetail renders one product the way GetByID does. variantID names the
+// variant a SKU or barcode lookup matched, if any.
+func (h *ProductHandler) writeDetail(w http.ResponseWriter, r *http.Request, id uuid.UUID, variantID *uuid.UUID) {
+
+	priceCtx, err := priceContextFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	renderHTML, err := renderFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	includeRelations := false
+	if v := r.URL.Query().Get("include"); v != "" {
+		for _, part := range strings.Split(v, ",") {
+			if strings.TrimSpace(part) != "relations" {
+				http.Error(w, "include must be relations", http.StatusBadRequest)
+				return
+			}
+			includeRelations = true
+		}
+	}
+
+	admin, err := h.adminView(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	product, err := h.service.GetWithCategory(id, admin)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	showCost(&product.Product, admin)
+
+	if err := h.pricing.ApplyDetail(product, priceCtx); err != nil {
+		writePricingError(w, err)
+		return
+	}
+
+	single := []models.Product{product.Product}
+	if err := h.images.Attach(single); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := h.tags.Attach(single); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	product.Images = single[0].Images
+	product.Tags = single[0].Tags
+
+	if includeRelations {
+		if product.Relations, err = h.relations.GetAll(id); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := h.pricing.ApplyRelated(product.Relations, priceCtx); err != nil {
+			writePricingError(w, err)
+			return
+		}
+	}
+
+	if renderHTML {
+		if err := renderDescriptions(&product.Product); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response := map[string]interface{}{
 		"success": true,
 		"data":    product,
-	})
+	}
+	if variantID != nil {
+		response["meta"] = map[string]interface{}{
+			"variant_id": variantID,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
 }
 
 func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -87,13 +289,18 @@ func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 		status := http.StatusInternalServerError
 		if strings.Contains(err.Error(), "not found") {
 			status = http.StatusNotFound
-		} else if strings.Contains(err.Error(), "required") {
+		} else if strings.Contains(err.Error(), "already exists") {
+			status = http.StatusConflict
+		} else if strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "cannot") || strings.Contains(err.Error(), "must") || strings.Contains(err.Error(), "invalid") {
 			status = http.StatusBadRequest
 		}
 		http.Error(w, err.Error(), status)
 		return
 	}
 
+	product.Currency = h.pricing.BaseCurrency()
+	showCost(product, h.isAdmin(r))
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -123,18 +330,27 @@ func (h *ProductHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.Actor == "" {
+		req.Actor = r.Header.Get("X-Actor")
+	}
+
 	product, err := h.service.Update(id, &req)
 	if err != nil {
 		status := http.StatusInternalServerError
 		if strings.Contains(err.Error(), "not found") {
 			status = http.StatusNotFound
-		} else if strings.Contains(err.Error(), "required") {
+		} else if strings.Contains(err.Error(), "already exists") || strings.Contains(err.Error(), "insufficient") {
+			status = http.StatusConflict
+		} else if strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "cannot") || strings.Contains(err.Error(), "must") || strings.Contains(err.Error(), "invalid") {
 			status = http.StatusBadRequest
 		}
 		http.Error(w, err.Error(), status)
 		return
 	}
 
+	product.Currency = h.pricing.BaseCurrency()
+	showCost(product, h.isAdmin(r))
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
@@ -185,18 +401,65 @@ func (h *ProductHandler) GetByCategory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	categoryID, err := uuid.Parse(categoryIDStr)
+	filter, err := productFilterFromQuery(r)
 	if err != nil {
-		http.Error(w, "Invalid category ID", http.StatusBadRequest)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	products, err := h.service.GetByCategoryID(categoryID)
+	priceCtx, err := priceContextFromQuery(r)
 	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	renderHTML, err := renderFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	admin, err := h.adminView(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	filter.IncludeCost = admin
+
+	products, err := h.service.List(filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for i := range products {
+		showCost(&products[i], admin)
+	}
+
+	if err := h.pricing.Apply(products, priceCtx); err != nil {
+		writePricingError(w, err)
+		return
+	}
+
+	if err := h.images.Attach(products); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	if err := h.tags.Attach(products); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if renderHTML {
+		for i := range products {
+			if err := renderDescriptions(&products[i]); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+
 	if products == nil {
 		products = []models.Product{}
 	}
@@ -210,3 +473,270 @@ func (h *ProductHandler) GetByCategory(w http.ResponseWriter, r *http.Request) {
 		},
 	})
 }
+
+// Facets takes the listing filters and an optional price_buckets list of
+// ascending bucket boundaries, e.g. price_buckets=1000,5000,10000.
+func (h *ProductHandler) Facets(w http.ResponseWriter, r *http.Request) {
+
+	filter, err := productFilterFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var bounds []int64
+	if v := r.URL.Query().Get("price_buckets"); v != "" {
+		for _, part := range strings.Split(v, ",") {
+			bound, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+			if err != nil || bound < 0 || (len(bounds) > 0 && bound <= bounds[len(bounds)-1]) {
+				http.Error(w, "price_buckets must be ascending non-negative integers", http.StatusBadRequest)
+				return
+			}
+			bounds = append(bounds, bound)
+		}
+		if len(bounds) > models.MaxPriceBuckets {
+			http.Error(w, fmt.Sprintf("price_buckets cannot have more than %d boundaries", models.MaxPriceBuckets), http.StatusBadRequest)
+			return
+		}
+	}
+
+	facets, err := h.service.Facets(filter, bounds)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    facets,
+		"meta": map[string]interface{}{
+			"currency": h.pricing.BaseCurrency(),
+		},
+	})
+}
+
+// productFilterFromQuery reads the listing filters shared by the product
+// listing endpoints.
+func productFilterFromQuery(r *http.Request) (models.ProductFilter, error) {
+
+	var filter models.ProductFilter
+	query := r.URL.Query()
+
+	if v := query.Get("category_id"); v != "" {
+		categoryID, err := uuid.Parse(v)
+		if err != nil {
+			return filter, errors.New("invalid category ID")
+		}
+		filter.CategoryID = &categoryID
+		filter.IncludeDescendants = query.Get("include_descendants") == "true"
+	}
+
+	if v := query.Get("warehouse_id"); v != "" {
+		warehouseID, err := uuid.Parse(v)
+		if err != nil {
+			return filter, errors.New("invalid warehouse ID")
+		}
+		filter.WarehouseID = &warehouseID
+	}
+
+	filter.Query = strings.TrimSpace(query.Get("q"))
+
+	var err error
+	if filter.MinPrice, err = priceFromQuery(query, "min_price"); err != nil {
+		return filter, err
+	}
+	if filter.MaxPrice, err = priceFromQuery(query, "max_price"); err != nil {
+		return filter, err
+	}
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return filter, errors.New("min_price cannot be greater than max_price")
+	}
+
+	switch status := query.Get("stock_status"); status {
+	case "", models.StockStatusInStock, models.StockStatusLowStock, models.StockStatusOutOfStock:
+		filter.StockStatus = status
+	default:
+		return filter, errors.New("stock_status must be in_stock, low_stock or out_of_stock")
+	}
+
+	if v := query.Get("tags"); v != "" {
+		seen := make(map[string]bool)
+		for _, tag := range strings.Split(v, ",") {
+			if tag = models.NormalizeTag(tag); tag != "" && !seen[tag] {
+				seen[tag] = true
+				filter.Tags = append(filter.Tags, tag)
+			}
+		}
+	}
+	switch mode := query.Get("tags_mode"); mode {
+	case "", models.TagsModeAny, models.TagsModeAll:
+		filter.TagsMode = mode
+	default:
+		return filter, errors.New("tags_mode must be any or all")
+	}
+
+	attributes, err := attributeFiltersFromQuery(query)
+	if err != nil {
+		return filter, err
+	}
+	filter.Attributes = attributes
+
+	return filter, nil
+}
+
+func priceFromQuery(query url.Values, name string) (*int64, error) {
+
+	v := query.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	price, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || price < 0 {
+		return nil, errors.New(name + " must be a non-negative integer")
+	}
+	return &price, nil
+}
+
+// attributeFiltersFromQuery reads attr.{name} parameters. The query parser
+// splits attr.ram_gb>=8 into the key "attr.ram_gb>" and the value "8", and
+// leaves attr.ram_gb>8 whole as a key, so the operator is recovered from
+// both shapes.
+func attributeFiltersFromQuery(query url.Values) ([]models.AttributeFilter, error) {
+
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		if strings.HasPrefix(key, "attr.") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var filters []models.AttributeFilter
+	for _, key := range keys {
+		spec := strings.TrimPrefix(key, "attr.")
+		for _, value := range query[key] {
+			var f models.AttributeFilter
+			switch {
+			case strings.HasSuffix(spec, ">") || strings.HasSuffix(spec, "<"):
+				f = models.AttributeFilter{Name: spec[:len(spec)-1], Op: spec[len(spec)-1:] + "=", Values: []string{value}}
+			case strings.ContainsAny(spec, "<>") && value == "":
+				i := strings.IndexAny(spec, "<>")
+				f = models.AttributeFilter{Name: spec[:i], Op: spec[i : i+1], Values: []string{spec[i+1:]}}
+			default:
+				f = models.AttributeFilter{Name: spec, Op: models.AttributeEqual}
+				for _, v := range strings.Split(value, ",") {
+					if v = strings.TrimSpace(v); v != "" {
+						f.Values = append(f.Values, v)
+					}
+				}
+				if len(f.Values) == 0 {
+					return nil, errors.New("attribute filter attr." + spec + " must have a value")
+				}
+			}
+
+			if err := models.ValidateAttributeName(f.Name); err != nil {
+				return nil, errors.New("invalid attribute filter attr." + spec)
+			}
+			if f.Op != models.AttributeEqual {
+				n, err := strconv.ParseFloat(f.Values[0], 64)
+				if err != nil || math.IsInf(n, 0) || math.IsNaN(n) {
+					return nil, errors.New("attribute filter attr." + f.Name + f.Op + " must compare with a number")
+				}
+				f.Values[0] = strconv.FormatFloat(n, 'f', -1, 64)
+			}
+			filters = append(filters, f)
+		}
+	}
+
+	return filters, nil
+}
+
+// renderFromQuery reads ?render=markdown|html. Descriptions always come back
+// as markdown; html adds their sanitized rendering next to it.
+func renderFromQuery(r *http.Request) (bool, error) {
+
+	switch r.URL.Query().Get("render") {
+	case "", "markdown":
+		return false, nil
+	case "html":
+		return true, nil
+	default:
+		return false, errors.New("render must be markdown or html")
+	}
+}
+
+func renderDescriptions(p *models.Product) error {
+
+	var err error
+	if p.ShortDescriptionHTML, err = markdown.ToHTML(p.ShortDescription); err != nil {
+		return err
+	}
+	p.DescriptionHTML, err = markdown.ToHTML(p.Description)
+	return err
+}
+
+// priceContextFromQuery reads ?currency=, ?price_list= (code or ID) and
+// ?tax=incl|excl.
+func priceContextFromQuery(r *http.Request) (models.PriceContext, error) {
+
+	var ctx models.PriceContext
+	query := r.URL.Query()
+
+	if v := query.Get("currency"); v != "" {
+		currency, err := models.NormalizeCurrency(v)
+		if err != nil {
+			return ctx, err
+		}
+		ctx.Currency = currency
+	}
+
+	ctx.PriceList = strings.ToUpper(strings.TrimSpace(query.Get("price_list")))
+
+	switch tax := query.Get("tax"); tax {
+	case "", models.TaxInclusive, models.TaxExclusive:
+		ctx.Tax = tax
+	default:
+		return ctx, errors.New("tax must be incl or excl")
+	}
+
+	return ctx, nil
+}
+
+// isAdmin reports whether r carries the configured admin key.
+func (h *ProductHandler) isAdmin(r *http.Request) bool {
+	return hasAdminKey(r, h.adminKey)
+}
+
+// adminView reports whether r asks for the admin view (?view=admin), which
+// needs the admin key.
+func (h *ProductHandler) adminView(r *http.Request) (bool, error) {
+	if r.URL.Query().Get("view") != "admin" {
+		return false, nil
+	}
+	if !h.isAdmin(r) {
+		return false, errors.New("view=admin requires a valid X-Admin-Key header")
+	}
+	return true, nil
+}
+
+// showCost works out margin and markup for admin reads (?view=admin) and
+// hides cost data from everyone else. It runs before any currency conversion,
+// since costs are kept in the base currency.
+func showCost(p *models.Product, admin bool) {
+	if admin {
+		p.SetCostMetrics()
+	} else {
+		p.HideCost()
+	}
+}
+
+func writePricingError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if strings.Contains(err.Error(), "not found") {
+		status = http.StatusNotFound
+	} else if strings.Contains(err.Error(), "cannot") || strings.Contains(err.Error(), "invalid") {
+		status = http.StatusBadRequest
+	}
+	http.Error(w, err.Error(), status)
+}
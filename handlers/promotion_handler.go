@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/anggakrnwn/product-catalog-api/services"
+)
+
+type PromotionHandler struct {
+	service services.PromotionService
+}
+
+func NewPromotionHandler(service services.PromotionService) *PromotionHandler {
+	return &PromotionHandler{service: service}
+}
+
+func (h *PromotionHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+
+	promotions, err := h.service.GetAll(r.URL.Query().Get("active") == "true")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if promotions == nil {
+		promotions = []models.Promotion{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    promotions,
+		"meta": map[string]interface{}{
+			"count": len(promotions),
+		},
+	})
+}
+
+func (h *PromotionHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+
+	id, ok := pathUUID(pathSegments(r, "/api/promotions/"), 0)
+	if !ok {
+		http.Error(w, "Invalid promotion ID", http.StatusBadRequest)
+		return
+	}
+
+	promotion, err := h.service.GetByID(id)
+	if err != nil {
+		writePromotionError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    promotion,
+	})
+}
+
+func (h *PromotionHandler) Create(w http.ResponseWriter, r *http.Request) {
+
+	var req models.CreatePromotionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	promotion, err := h.service.Create(&req)
+	if err != nil {
+		writePromotionError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "promotion created successfully",
+		"data":    promotion,
+	})
+}
+
+func (h *PromotionHandler) Update(w http.ResponseWriter, r *http.Request) {
+
+	id, ok := pathUUID(pathSegments(r, "/api/promotions/"), 0)
+	if !ok {
+		http.Error(w, "Invalid promotion ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.UpdatePromotionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	promotion, err := h.service.Update(id, &req)
+	if err != nil {
+		writePromotionError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "promotion updated successfully",
+		"data":    promotion,
+	})
+}
+
+func (h *PromotionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+
+	id, ok := pathUUID(pathSegments(r, "/api/promotions/"), 0)
+	if !ok {
+		http.Error(w, "Invalid promotion ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Delete(id); err != nil {
+		writePromotionError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "promotion deleted successfully",
+		"data": map[string]string{
+			"id": id.String(),
+		},
+	})
+}
+
+func (h *PromotionHandler) Evaluate(w http.ResponseWriter, r *http.Request) {
+
+	var req models.EvaluateCartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	evaluation, err := h.service.Evaluate(&req)
+	if err != nil {
+		writePromotionError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    evaluation,
+	})
+}
+
+func writePromotionError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if strings.Contains(err.Error(), "not found") {
+		status = http.StatusNotFound
+	} else if strings.Contains(err.Error(), "already exists") || strings.Contains(err.Error(), "limit reached") {
+		status = http.StatusConflict
+	} else if strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "must") || strings.Contains(err.Error(), "cannot") {
+		status = http.StatusBadRequest
+	}
+	http.Error(w, err.Error(), status)
+}
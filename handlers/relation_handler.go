@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/anggakrnwn/product-catalog-api/services"
+)
+
+type RelationHandler struct {
+	service services.RelationService
+	pricing services.PricingService
+}
+
+func NewRelationHandler(service services.RelationService, pricing services.PricingService) *RelationHandler {
+	return &RelationHandler{service: service, pricing: pricing}
+}
+
+func (h *RelationHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+
+	productID, ok := pathUUID(pathSegments(r, "/api/products/"), 0)
+	if !ok {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	priceCtx, err := priceContextFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	relations, err := h.service.GetAll(productID)
+	if err != nil {
+		writeRelationError(w, err)
+		return
+	}
+
+	if err := h.pricing.ApplyRelated(relations, priceCtx); err != nil {
+		writePricingError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    relations,
+	})
+}
+
+// Set replaces the relations of the type named in the path.
+func (h *RelationHandler) Set(w http.ResponseWriter, r *http.Request) {
+
+	segments := pathSegments(r, "/api/products/")
+	productID, ok := pathUUID(segments, 0)
+	if !ok {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.SetRelationsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	related, err := h.service.Set(productID, segments[2], &req)
+	if err != nil {
+		writeRelationError(w, err)
+		return
+	}
+
+	if related == nil {
+		related = []models.RelatedProduct{}
+	}
+
+	byType := map[string][]models.RelatedProduct{segments[2]: related}
+	if err := h.pricing.ApplyRelated(byType, models.PriceContext{}); err != nil {
+		writePricingError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "relations updated successfully",
+		"data":    related,
+		"meta": map[string]interface{}{
+			"count": len(related),
+			"type":  segments[2],
+		},
+	})
+}
+
+func (h *RelationHandler) Remove(w http.ResponseWriter, r *http.Request) {
+
+	segments := pathSegments(r, "/api/products/")
+	productID, ok := pathUUID(segments, 0)
+	if !ok {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+	relatedID, ok := pathUUID(segments, 3)
+	if !ok {
+		http.Error(w, "Invalid related product ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Remove(productID, segments[2], relatedID); err != nil {
+		writeRelationError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "relation removed successfully",
+		"data": map[string]string{
+			"product_id": productID.String(),
+			"type":       segments[2],
+			"related_id": relatedID.String(),
+		},
+	})
+}
+
+func writeRelationError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if strings.Contains(err.Error(), "not found") {
+		status = http.StatusNotFound
+	} else if strings.Contains(err.Error(), "must") || strings.Contains(err.Error(), "cannot") ||
+		strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "invalid") {
+		status = http.StatusBadRequest
+	}
+	http.Error(w, err.Error(), status)
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/anggakrnwn/product-catalog-api/services"
+)
+
+type ReservationHandler struct {
+	service services.ReservationService
+}
+
+func NewReservationHandler(service services.ReservationService) *ReservationHandler {
+	return &ReservationHandler{service: service}
+}
+
+func (h *ReservationHandler) Create(w http.ResponseWriter, r *http.Request) {
+
+	var req models.CreateReservationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	reservation, err := h.service.Create(&req)
+	if err != nil {
+		writeReservationError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "reservation created successfully",
+		"data":    reservation,
+	})
+}
+
+func (h *ReservationHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+
+	id, ok := pathUUID(pathSegments(r, "/api/reservations/"), 0)
+	if !ok {
+		http.Error(w, "Invalid reservation ID", http.StatusBadRequest)
+		return
+	}
+
+	reservation, err := h.service.GetByID(id)
+	if err != nil {
+		writeReservationError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    reservation,
+	})
+}
+
+func (h *ReservationHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+
+	id, ok := pathUUID(pathSegments(r, "/api/reservations/"), 0)
+	if !ok {
+		http.Error(w, "Invalid reservation ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.ConfirmReservationRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.Actor == "" {
+		req.Actor = r.Header.Get("X-Actor")
+	}
+
+	reservation, err := h.service.Confirm(id, &req)
+	if err != nil {
+		writeReservationError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "reservation confirmed successfully",
+		"data":    reservation,
+	})
+}
+
+func (h *ReservationHandler) Release(w http.ResponseWriter, r *http.Request) {
+
+	id, ok := pathUUID(pathSegments(r, "/api/reservations/"), 0)
+	if !ok {
+		http.Error(w, "Invalid reservation ID", http.StatusBadRequest)
+		return
+	}
+
+	reservation, err := h.service.Release(id)
+	if err != nil {
+		writeReservationError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "reservation released successfully",
+		"data":    reservation,
+	})
+}
+
+func writeReservationError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if strings.Contains(err.Error(), "not found") {
+		status = http.StatusNotFound
+	} else if strings.Contains(err.Error(), "insufficient") || strings.Contains(err.Error(), "cannot be") {
+		status = http.StatusConflict
+	} else if strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "must") {
+		status = http.StatusBadRequest
+	}
+	http.Error(w, err.Error(), status)
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/anggakrnwn/product-catalog-api/services"
+)
+
+type StockHandler struct {
+	service  services.StockService
+	adminKey string
+}
+
+func NewStockHandler(service services.StockService, adminKey string) *StockHandler {
+	return &StockHandler{service: service, adminKey: adminKey}
+}
+
+func (h *StockHandler) Adjust(w http.ResponseWriter, r *http.Request) {
+
+	productID, ok := pathUUID(pathSegments(r, "/api/products/"), 0)
+	if !ok {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.AdjustStockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Actor == "" {
+		req.Actor = r.Header.Get("X-Actor")
+	}
+
+	movement, err := h.service.Adjust(productID, &req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "insufficient") {
+			status = http.StatusConflict
+		} else if strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "must") || strings.Contains(err.Error(), "cannot") {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "stock adjusted successfully",
+		"data":    movement,
+	})
+}
+
+func (h *StockHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
+
+	productID, ok := pathUUID(pathSegments(r, "/api/products/"), 0)
+	if !ok {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	movements, total, err := h.service.GetHistory(productID, limit, offset)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	if movements == nil {
+		movements = []models.StockMovement{}
+	}
+
+	// unit costs are cost data, like the product's cost price
+	if !hasAdminKey(r, h.adminKey) {
+		for i := range movements {
+			movements[i].UnitCost = nil
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    movements,
+		"meta": map[string]interface{}{
+			"count":  len(movements),
+			"total":  total,
+			"offset": offset,
+		},
+	})
+}
+
+func (h *StockHandler) GetLevels(w http.ResponseWriter, r *http.Request) {
+
+	productID, ok := pathUUID(pathSegments(r, "/api/products/"), 0)
+	if !ok {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	levels, err := h.service.GetLevels(productID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	if levels == nil {
+		levels = []models.StockLevel{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    levels,
+	})
+}
@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/anggakrnwn/product-catalog-api/services"
+)
+
+type TagHandler struct {
+	service services.TagService
+}
+
+func NewTagHandler(service services.TagService) *TagHandler {
+	return &TagHandler{service: service}
+}
+
+func (h *TagHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+
+	tags, err := h.service.GetAll()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeTags(w, "", tags)
+}
+
+func (h *TagHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+
+	id, ok := pathUUID(pathSegments(r, "/api/tags/"), 0)
+	if !ok {
+		http.Error(w, "Invalid tag ID", http.StatusBadRequest)
+		return
+	}
+
+	tag, err := h.service.GetByID(id)
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    tag,
+	})
+}
+
+func (h *TagHandler) Create(w http.ResponseWriter, r *http.Request) {
+
+	var req models.CreateTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	tag, err := h.service.Create(&req)
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "tag created successfully",
+		"data":    tag,
+	})
+}
+
+// Update renames the tag.
+func (h *TagHandler) Update(w http.ResponseWriter, r *http.Request) {
+
+	id, ok := pathUUID(pathSegments(r, "/api/tags/"), 0)
+	if !ok {
+		http.Error(w, "Invalid tag ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.UpdateTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	tag, err := h.service.Update(id, &req)
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "tag renamed successfully",
+		"data":    tag,
+	})
+}
+
+func (h *TagHandler) Delete(w http.ResponseWriter, r *http.Request) {
+
+	id, ok := pathUUID(pathSegments(r, "/api/tags/"), 0)
+	if !ok {
+		http.Error(w, "Invalid tag ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Delete(id); err != nil {
+		writeTagError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "tag deleted successfully",
+		"data": map[string]string{
+			"id": id.String(),
+		},
+	})
+}
+
+func (h *TagHandler) Merge(w http.ResponseWriter, r *http.Request) {
+
+	id, ok := pathUUID(pathSegments(r, "/api/tags/"), 0)
+	if !ok {
+		http.Error(w, "Invalid tag ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.MergeTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.service.Merge(id, &req)
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "tag merged successfully",
+		"data":    result,
+	})
+}
+
+func (h *TagHandler) GetProductTags(w http.ResponseWriter, r *http.Request) {
+
+	productID, ok := pathUUID(pathSegments(r, "/api/products/"), 0)
+	if !ok {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	tags, err := h.service.GetProductTags(productID)
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+
+	writeTags(w, "", tags)
+}
+
+func (h *TagHandler) AddProductTags(w http.ResponseWriter, r *http.Request) {
+
+	productID, ok := pathUUID(pathSegments(r, "/api/products/"), 0)
+	if !ok {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.ProductTagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	tags, err := h.service.AddProductTags(productID, &req)
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+
+	writeTags(w, "tags added successfully", tags)
+}
+
+// RemoveProductTag takes the tag's ID or name as the last path segment.
+func (h *TagHandler) RemoveProductTag(w http.ResponseWriter, r *http.Request) {
+
+	segments := pathSegments(r, "/api/products/")
+	productID, ok := pathUUID(segments, 0)
+	if !ok {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+	if len(segments) != 3 {
+		http.Error(w, "tag is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.RemoveProductTag(productID, segments[2]); err != nil {
+		writeTagError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "tag removed successfully",
+		"data": map[string]string{
+			"product_id": productID.String(),
+			"tag":        segments[2],
+		},
+	})
+}
+
+func writeTags(w http.ResponseWriter, message string, tags []models.Tag) {
+
+	if tags == nil {
+		tags = []models.Tag{}
+	}
+
+	response := map[string]interface{}{
+		"success": true,
+		"data":    tags,
+		"meta": map[string]interface{}{
+			"count": len(tags),
+		},
+	}
+	if message != "" {
+		response["message"] = message
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func writeTagError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if strings.Contains(err.Error(), "not found") {
+		status = http.StatusNotFound
+	} else if strings.Contains(err.Error(), "already exists") {
+		status = http.StatusConflict
+	} else if strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "must") ||
+		strings.Contains(err.Error(), "cannot") || strings.Contains(err.Error(), "invalid") {
+		status = http.StatusBadRequest
+	}
+	http.Error(w, err.Error(), status)
+}
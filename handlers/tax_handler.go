@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/anggakrnwn/product-catalog-api/services"
+)
+
+type TaxHandler struct {
+	service services.TaxService
+}
+
+func NewTaxHandler(service services.TaxService) *TaxHandler {
+	return &TaxHandler{service: service}
+}
+
+func (h *TaxHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+
+	classes, err := h.service.GetAll()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if classes == nil {
+		classes = []models.TaxClass{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    classes,
+		"meta": map[string]interface{}{
+			"count": len(classes),
+		},
+	})
+}
+
+func (h *TaxHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+
+	id, ok := pathUUID(pathSegments(r, "/api/tax-classes/"), 0)
+	if !ok {
+		http.Error(w, "Invalid tax class ID", http.StatusBadRequest)
+		return
+	}
+
+	class, err := h.service.GetByID(id)
+	if err != nil {
+		writeTaxError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    class,
+	})
+}
+
+func (h *TaxHandler) Create(w http.ResponseWriter, r *http.Request) {
+
+	var req models.CreateTaxClassRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	class, err := h.service.Create(&req)
+	if err != nil {
+		writeTaxError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "tax class created successfully",
+		"data":    class,
+	})
+}
+
+func (h *TaxHandler) Update(w http.ResponseWriter, r *http.Request) {
+
+	id, ok := pathUUID(pathSegments(r, "/api/tax-classes/"), 0)
+	if !ok {
+		http.Error(w, "Invalid tax class ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.UpdateTaxClassRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	class, err := h.service.Update(id, &req)
+	if err != nil {
+		writeTaxError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "tax class updated successfully",
+		"data":    class,
+	})
+}
+
+func (h *TaxHandler) Delete(w http.ResponseWriter, r *http.Request) {
+
+	id, ok := pathUUID(pathSegments(r, "/api/tax-classes/"), 0)
+	if !ok {
+		http.Error(w, "Invalid tax class ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Delete(id); err != nil {
+		writeTaxError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "tax class deleted successfully",
+		"data": map[string]string{
+			"id": id.String(),
+		},
+	})
+}
+
+func (h *TaxHandler) CreateRate(w http.ResponseWriter, r *http.Request) {
+
+	id, ok := pathUUID(pathSegments(r, "/api/tax-classes/"), 0)
+	if !ok {
+		http.Error(w, "Invalid tax class ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.CreateTaxRateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	rate, err := h.service.CreateRate(id, &req)
+	if err != nil {
+		writeTaxError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "tax rate created successfully",
+		"data":    rate,
+	})
+}
+
+func writeTaxError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if strings.Contains(err.Error(), "not found") {
+		status = http.StatusNotFound
+	} else if strings.Contains(err.Error(), "already exists") {
+		status = http.StatusConflict
+	} else if strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "must") || strings.Contains(err.Error(), "cannot") {
+		status = http.StatusBadRequest
+	}
+	http.Error(w, err.Error(), status)
+}
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/anggakrnwn/product-catalog-api/services"
+)
+
+type VariantHandler struct {
+	service services.VariantService
+}
+
+func NewVariantHandler(service services.VariantService) *VariantHandler {
+	return &VariantHandler{service: service}
+}
+
+func (h *VariantHandler) GetOptions(w http.ResponseWriter, r *http.Request) {
+
+	productID, ok := pathUUID(pathSegments(r, "/api/products/"), 0)
+	if !ok {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	options, err := h.service.GetOptions(productID)
+	if err != nil {
+		writeVariantError(w, err)
+		return
+	}
+
+	if options == nil {
+		options = []models.ProductOption{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    options,
+	})
+}
+
+func (h *VariantHandler) SetOptions(w http.ResponseWriter, r *http.Request) {
+
+	productID, ok := pathUUID(pathSegments(r, "/api/products/"), 0)
+	if !ok {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.SetOptionsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	options, err := h.service.SetOptions(productID, &req)
+	if err != nil {
+		writeVariantError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "product options updated successfully",
+		"data":    options,
+	})
+}
+
+func (h *VariantHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+
+	productID, ok := pathUUID(pathSegments(r, "/api/products/"), 0)
+	if !ok {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	variants, err := h.service.GetByProductID(productID)
+	if err != nil {
+		writeVariantError(w, err)
+		return
+	}
+
+	if variants == nil {
+		variants = []models.ProductVariant{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    variants,
+		"meta": map[string]interface{}{
+			"count": len(variants),
+		},
+	})
+}
+
+func (h *VariantHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+
+	segments := pathSegments(r, "/api/products/")
+	productID, ok := pathUUID(segments, 0)
+	if !ok {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+	id, ok := pathUUID(segments, 2)
+	if !ok {
+		http.Error(w, "Invalid variant ID", http.StatusBadRequest)
+		return
+	}
+
+	variant, err := h.service.GetByID(productID, id)
+	if err != nil {
+		writeVariantError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    variant,
+	})
+}
+
+func (h *VariantHandler) Create(w http.ResponseWriter, r *http.Request) {
+
+	productID, ok := pathUUID(pathSegments(r, "/api/products/"), 0)
+	if !ok {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.CreateVariantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	variant, err := h.service.Create(productID, &req)
+	if err != nil {
+		writeVariantError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "variant created successfully",
+		"data":    variant,
+	})
+}
+
+func (h *VariantHandler) Update(w http.ResponseWriter, r *http.Request) {
+
+	segments := pathSegments(r, "/api/products/")
+	productID, ok := pathUUID(segments, 0)
+	if !ok {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+	id, ok := pathUUID(segments, 2)
+	if !ok {
+		http.Error(w, "Invalid variant ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.UpdateVariantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	variant, err := h.service.Update(productID, id, &req)
+	if err != nil {
+		writeVariantError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "variant updated successfully",
+		"data":    variant,
+	})
+}
+
+func (h *VariantHandler) Delete(w http.ResponseWriter, r *http.Request) {
+
+	segments := pathSegments(r, "/api/products/")
+	productID, ok := pathUUID(segments, 0)
+	if !ok {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+	id, ok := pathUUID(segments, 2)
+	if !ok {
+		http.Error(w, "Invalid variant ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Delete(productID, id); err != nil {
+		writeVariantError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "variant deleted successfully",
+		"data": map[string]string{
+			"id": id.String(),
+		},
+	})
+}
+
+func (h *VariantHandler) Generate(w http.ResponseWriter, r *http.Request) {
+
+	productID, ok := pathUUID(pathSegments(r, "/api/products/"), 0)
+	if !ok {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.GenerateVariantsRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	variants, err := h.service.Generate(productID, &req)
+	if err != nil {
+		writeVariantError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "variants generated successfully",
+		"data":    variants,
+		"meta": map[string]interface{}{
+			"created": len(variants),
+		},
+	})
+}
+
+func writeVariantError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if strings.Contains(err.Error(), "not found") {
+		status = http.StatusNotFound
+	} else if strings.Contains(err.Error(), "already exists") || strings.Contains(err.Error(), "insufficient") {
+		status = http.StatusConflict
+	} else if strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "must") ||
+		strings.Contains(err.Error(), "cannot") || strings.Contains(err.Error(), "invalid") ||
+		strings.Contains(err.Error(), "not a valid") || strings.Contains(err.Error(), "twice") ||
+		strings.Contains(err.Error(), "no options") {
+		status = http.StatusBadRequest
+	}
+	http.Error(w, err.Error(), status)
+}
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/anggakrnwn/product-catalog-api/services"
+)
+
+type WarehouseHandler struct {
+	service services.WarehouseService
+}
+
+func NewWarehouseHandler(service services.WarehouseService) *WarehouseHandler {
+	return &WarehouseHandler{service: service}
+}
+
+func (h *WarehouseHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+
+	warehouses, err := h.service.GetAll()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if warehouses == nil {
+		warehouses = []models.Warehouse{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    warehouses,
+		"meta": map[string]interface{}{
+			"count": len(warehouses),
+		},
+	})
+}
+
+func (h *WarehouseHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+
+	id, ok := pathUUID(pathSegments(r, "/api/warehouses/"), 0)
+	if !ok {
+		http.Error(w, "Invalid warehouse ID", http.StatusBadRequest)
+		return
+	}
+
+	warehouse, err := h.service.GetByID(id)
+	if err != nil {
+		writeWarehouseError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    warehouse,
+	})
+}
+
+func (h *WarehouseHandler) Create(w http.ResponseWriter, r *http.Request) {
+
+	var req models.CreateWarehouseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	warehouse, err := h.service.Create(&req)
+	if err != nil {
+		writeWarehouseError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "warehouse created successfully",
+		"data":    warehouse,
+	})
+}
+
+func (h *WarehouseHandler) Update(w http.ResponseWriter, r *http.Request) {
+
+	id, ok := pathUUID(pathSegments(r, "/api/warehouses/"), 0)
+	if !ok {
+		http.Error(w, "Invalid warehouse ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.UpdateWarehouseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	warehouse, err := h.service.Update(id, &req)
+	if err != nil {
+		writeWarehouseError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "warehouse updated successfully",
+		"data":    warehouse,
+	})
+}
+
+func (h *WarehouseHandler) Delete(w http.ResponseWriter, r *http.Request) {
+
+	id, ok := pathUUID(pathSegments(r, "/api/warehouses/"), 0)
+	if !ok {
+		http.Error(w, "Invalid warehouse ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Delete(id); err != nil {
+		writeWarehouseError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "warehouse deleted successfully",
+		"data": map[string]string{
+			"id": id.String(),
+		},
+	})
+}
+
+func (h *WarehouseHandler) GetStockLevels(w http.ResponseWriter, r *http.Request) {
+
+	id, ok := pathUUID(pathSegments(r, "/api/warehouses/"), 0)
+	if !ok {
+		http.Error(w, "Invalid warehouse ID", http.StatusBadRequest)
+		return
+	}
+
+	levels, err := h.service.GetStockLevels(id)
+	if err != nil {
+		writeWarehouseError(w, err)
+		return
+	}
+
+	if levels == nil {
+		levels = []models.StockLevel{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    levels,
+		"meta": map[string]interface{}{
+			"count": len(levels),
+		},
+	})
+}
+
+func (h *WarehouseHandler) Transfer(w http.ResponseWriter, r *http.Request) {
+
+	var req models.TransferStockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Actor == "" {
+		req.Actor = r.Header.Get("X-Actor")
+	}
+
+	transfer, err := h.service.Transfer(&req)
+	if err != nil {
+		writeWarehouseError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "stock transferred successfully",
+		"data":    transfer,
+	})
+}
+
+func writeWarehouseError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if strings.Contains(err.Error(), "not found") {
+		status = http.StatusNotFound
+	} else if strings.Contains(err.Error(), "already exists") || strings.Contains(err.Error(), "insufficient") ||
+		strings.Contains(err.Error(), "still holds") {
+		status = http.StatusConflict
+	} else if strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "must") || strings.Contains(err.Error(), "cannot") {
+		status = http.StatusBadRequest
+	}
+	http.Error(w, err.Error(), status)
+}
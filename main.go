@@ -1,14 +1,20 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"net/http"
+	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/anggakrnwn/product-catalog-api/blobstore"
 	"github.com/anggakrnwn/product-catalog-api/config"
 	"github.com/anggakrnwn/product-catalog-api/database"
+	"github.com/anggakrnwn/product-catalog-api/events"
 	"github.com/anggakrnwn/product-catalog-api/handlers"
+	"github.com/anggakrnwn/product-catalog-api/models"
 	"github.com/anggakrnwn/product-catalog-api/repositories"
 	"github.com/anggakrnwn/product-catalog-api/services"
 )
@@ -17,6 +23,9 @@ func main() {
 	// load config
 	cfg := config.Load()
 
+	// amounts in the base currency use the unit prices are stored in
+	models.SetCurrencyExponent(cfg.BaseCurrency, cfg.BaseCurrencyExponent)
+
 	// setup database
 	db, err := database.InitDB(cfg.DBConn)
 	if err != nil {
@@ -26,14 +35,93 @@ func main() {
 
 	log.Println("Database connected successfully")
 
+	if err := database.Migrate(db); err != nil {
+		log.Fatal("Failed to run migrations:", err)
+	}
+
+	// internal events; webhooks and notifications subscribe here
+	bus := events.NewBus()
+
+	// uploaded media
+	blobStore, err := newBlobStore(cfg)
+	if err != nil {
+		log.Fatal("Failed to initialize blob store:", err)
+	}
+
 	// dependency injection
 	categoryRepo := repositories.NewCategoryRepository(db)
-	categoryService := services.NewCategoryService(categoryRepo)
+	categoryService := services.NewCategoryService(categoryRepo, bus)
 	categoryHandler := handlers.NewCategoryHandler(categoryService)
 
 	productRepo := repositories.NewProductRepository(db)
-	productService := services.NewProductService(productRepo, categoryRepo)
-	productHandler := handlers.NewProductHandler(productService)
+	variantRepo := repositories.NewVariantRepository(db)
+	stockRepo := repositories.NewStockRepository(db)
+	productService := services.NewProductService(productRepo, categoryRepo, variantRepo, bus)
+	priceListRepo := repositories.NewPriceListRepository(db)
+	priceListService := services.NewPriceListService(priceListRepo)
+	priceListHandler := handlers.NewPriceListHandler(priceListService)
+	taxRepo := repositories.NewTaxRepository(db)
+	taxService := services.NewTaxService(taxRepo)
+	taxHandler := handlers.NewTaxHandler(taxService)
+	pricingService := services.NewPricingService(priceListRepo, taxRepo, cfg.BaseCurrency, cfg.PricesIncludeTax)
+	imageRepo := repositories.NewImageRepository(db)
+	imageService := services.NewImageService(imageRepo, productRepo, blobStore)
+	imageHandler := handlers.NewImageHandler(imageService, cfg.MaxImageBytes)
+	mediaHandler := handlers.NewMediaHandler(blobStore)
+	bus.Subscribe(events.ProductDeleted, imageService.HandleProductDeleted)
+	tagRepo := repositories.NewTagRepository(db)
+	tagService := services.NewTagService(tagRepo, productRepo)
+	tagHandler := handlers.NewTagHandler(tagService)
+
+	relationRepo := repositories.NewRelationRepository(db)
+	relationService := services.NewRelationService(relationRepo, productRepo)
+	relationHandler := handlers.NewRelationHandler(relationService, pricingService)
+
+	productHandler := handlers.NewProductHandler(productService, pricingService, imageService, tagService, relationService, cfg.AdminKey)
+	labelService := services.NewLabelService(productRepo, pricingService, cfg.PublicURL)
+	labelHandler := handlers.NewLabelHandler(labelService)
+
+	variantService := services.NewVariantService(variantRepo, productRepo)
+	variantHandler := handlers.NewVariantHandler(variantService)
+
+	stockService := services.NewStockService(stockRepo, productRepo, variantRepo)
+	stockHandler := handlers.NewStockHandler(stockService, cfg.AdminKey)
+
+	customerPricingRepo := repositories.NewCustomerPricingRepository(db)
+	customerPricingService := services.NewCustomerPricingService(customerPricingRepo, productRepo, cfg.BaseCurrency)
+	customerPricingHandler := handlers.NewCustomerPricingHandler(customerPricingService)
+
+	promotionRepo := repositories.NewPromotionRepository(db)
+	promotionService := services.NewPromotionService(promotionRepo, productRepo, cfg.BaseCurrency)
+	promotionHandler := handlers.NewPromotionHandler(promotionService)
+
+	priceRepo := repositories.NewPriceRepository(db)
+	priceService := services.NewPriceService(priceRepo, productRepo)
+	priceHandler := handlers.NewPriceHandler(priceService)
+
+	reservationRepo := repositories.NewReservationRepository(db)
+	reservationService := services.NewReservationService(reservationRepo)
+	reservationHandler := handlers.NewReservationHandler(reservationService)
+
+	warehouseRepo := repositories.NewWarehouseRepository(db)
+	warehouseService := services.NewWarehouseService(warehouseRepo, stockRepo, variantRepo)
+	warehouseHandler := handlers.NewWarehouseHandler(warehouseService)
+
+	inventoryRepo := repositories.NewInventoryRepository(db)
+	inventoryService := services.NewInventoryService(inventoryRepo, cfg.BaseCurrency)
+	inventoryHandler := handlers.NewInventoryHandler(inventoryService, cfg.AdminKey)
+
+	jobRepo := repositories.NewJobRepository(db)
+	jobService := services.NewJobService(jobRepo, productService, cfg.StorageDir)
+	jobHandler := handlers.NewJobHandler(jobService)
+
+	// background workers
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	jobService.Start(ctx, cfg.JobWorkers)
+	reservationService.Start(ctx)
+	priceService.Start(ctx)
 
 	// setup router
 	// categories
@@ -51,9 +139,32 @@ func main() {
 	http.HandleFunc("/api/categories/", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
-			categoryHandler.GetByID(w, r)
+			switch {
+			case strings.HasSuffix(r.URL.Path, "/ancestors"):
+				categoryHandler.GetAncestors(w, r)
+			case strings.HasSuffix(r.URL.Path, "/children"):
+				categoryHandler.GetChildren(w, r)
+			case strings.HasSuffix(r.URL.Path, "/descendants"):
+				categoryHandler.GetDescendants(w, r)
+			case strings.HasSuffix(r.URL.Path, "/stats"):
+				categoryHandler.GetStats(w, r)
+			case strings.HasSuffix(r.URL.Path, "/attributes"):
+				categoryHandler.GetAttributes(w, r)
+			default:
+				categoryHandler.GetByID(w, r)
+			}
+		case http.MethodPost:
+			if strings.HasSuffix(r.URL.Path, "/merge") {
+				categoryHandler.Merge(w, r)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
 		case http.MethodPut:
-			categoryHandler.Update(w, r)
+			if strings.HasSuffix(r.URL.Path, "/attributes") {
+				categoryHandler.SetAttributes(w, r)
+			} else {
+				categoryHandler.Update(w, r)
+			}
 		case http.MethodDelete:
 			categoryHandler.Delete(w, r)
 		default:
@@ -62,6 +173,21 @@ func main() {
 	})
 
 	http.HandleFunc("/api/categories/bulk", categoryHandler.BulkCreate)
+	http.HandleFunc("/api/categories/tree", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		categoryHandler.GetTree(w, r)
+	})
+
+	http.HandleFunc("/api/categories/by-slug/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		categoryHandler.GetBySlug(w, r)
+	})
 
 	// products
 	http.HandleFunc("/api/products", func(w http.ResponseWriter, r *http.Request) {
@@ -80,6 +206,13 @@ func main() {
 	})
 
 	http.HandleFunc("/api/products/", func(w http.ResponseWriter, r *http.Request) {
+		// sub-resources: /api/products/{id}/...
+		segments := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/products/"), "/"), "/")
+		if len(segments) > 1 {
+			productSubroutes(w, r, segments[1:], variantHandler, stockHandler, priceHandler, labelHandler, imageHandler, tagHandler, relationHandler)
+			return
+		}
+
 		switch r.Method {
 		case http.MethodGet:
 			productHandler.GetByID(w, r)
@@ -92,6 +225,381 @@ func main() {
 		}
 	})
 
+	http.HandleFunc("/api/products/facets", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		productHandler.Facets(w, r)
+	})
+
+	// product lookups by SKU, barcode and slug
+	http.HandleFunc("/api/products/by-sku/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		productHandler.GetBySKU(w, r)
+	})
+
+	http.HandleFunc("/api/products/by-barcode/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		productHandler.GetByBarcode(w, r)
+	})
+
+	http.HandleFunc("/api/products/by-slug/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		productHandler.GetBySlug(w, r)
+	})
+
+	// uploaded files, for blob stores without a public URL
+	http.HandleFunc("/media/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		mediaHandler.Serve(w, r)
+	})
+
+	// printable shelf labels
+	http.HandleFunc("/api/labels", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		labelHandler.CreateSheet(w, r)
+	})
+
+	// tags
+	http.HandleFunc("/api/tags", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			tagHandler.GetAll(w, r)
+		case http.MethodPost:
+			tagHandler.Create(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/api/tags/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			tagHandler.GetByID(w, r)
+		case http.MethodPost:
+			if strings.HasSuffix(r.URL.Path, "/merge") {
+				tagHandler.Merge(w, r)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		case http.MethodPut:
+			tagHandler.Update(w, r)
+		case http.MethodDelete:
+			tagHandler.Delete(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// warehouses
+	http.HandleFunc("/api/warehouses", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			warehouseHandler.GetAll(w, r)
+		case http.MethodPost:
+			warehouseHandler.Create(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/api/warehouses/transfers", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		warehouseHandler.Transfer(w, r)
+	})
+
+	http.HandleFunc("/api/warehouses/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			if strings.HasSuffix(r.URL.Path, "/stock") {
+				warehouseHandler.GetStockLevels(w, r)
+			} else {
+				warehouseHandler.GetByID(w, r)
+			}
+		case http.MethodPut:
+			warehouseHandler.Update(w, r)
+		case http.MethodDelete:
+			warehouseHandler.Delete(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// price lists and exchange rates
+	http.HandleFunc("/api/price-lists", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			priceListHandler.GetAll(w, r)
+		case http.MethodPost:
+			priceListHandler.Create(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/api/price-lists/", func(w http.ResponseWriter, r *http.Request) {
+		segments := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/price-lists/"), "/"), "/")
+
+		switch {
+		case len(segments) == 1:
+			switch r.Method {
+			case http.MethodGet:
+				priceListHandler.GetByID(w, r)
+			case http.MethodPut:
+				priceListHandler.Update(w, r)
+			case http.MethodDelete:
+				priceListHandler.Delete(w, r)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		case len(segments) == 2 && segments[1] == "items":
+			switch r.Method {
+			case http.MethodGet:
+				priceListHandler.GetItems(w, r)
+			case http.MethodPut:
+				priceListHandler.SetItems(w, r)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		case len(segments) == 3 && segments[1] == "items":
+			if r.Method != http.MethodDelete {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			priceListHandler.DeleteItem(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	http.HandleFunc("/api/exchange-rates", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			priceListHandler.GetRates(w, r)
+		case http.MethodPut:
+			priceListHandler.SetRate(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/api/exchange-rates/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		priceListHandler.DeleteRate(w, r)
+	})
+
+	// customer groups, tier pricing and quotes
+	http.HandleFunc("/api/customer-groups", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			customerPricingHandler.GetGroups(w, r)
+		case http.MethodPost:
+			customerPricingHandler.CreateGroup(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/api/customer-groups/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			customerPricingHandler.GetGroupByID(w, r)
+		case http.MethodPut:
+			customerPricingHandler.UpdateGroup(w, r)
+		case http.MethodDelete:
+			customerPricingHandler.DeleteGroup(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/api/price-rules", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			customerPricingHandler.GetRules(w, r)
+		case http.MethodPost:
+			customerPricingHandler.CreateRule(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/api/price-rules/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		customerPricingHandler.DeleteRule(w, r)
+	})
+
+	http.HandleFunc("/api/pricing/quote", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		customerPricingHandler.Quote(w, r)
+	})
+
+	// promotions and coupons
+	http.HandleFunc("/api/promotions", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			promotionHandler.GetAll(w, r)
+		case http.MethodPost:
+			promotionHandler.Create(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/api/promotions/evaluate", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		promotionHandler.Evaluate(w, r)
+	})
+
+	http.HandleFunc("/api/promotions/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			promotionHandler.GetByID(w, r)
+		case http.MethodPut:
+			promotionHandler.Update(w, r)
+		case http.MethodDelete:
+			promotionHandler.Delete(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// tax classes
+	http.HandleFunc("/api/tax-classes", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			taxHandler.GetAll(w, r)
+		case http.MethodPost:
+			taxHandler.Create(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/api/tax-classes/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(strings.TrimSuffix(r.URL.Path, "/"), "/rates") {
+			if r.Method != http.MethodPost {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			taxHandler.CreateRate(w, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			taxHandler.GetByID(w, r)
+		case http.MethodPut:
+			taxHandler.Update(w, r)
+		case http.MethodDelete:
+			taxHandler.Delete(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// inventory reports
+	http.HandleFunc("/api/inventory/low-stock", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		inventoryHandler.GetLowStock(w, r)
+	})
+
+	http.HandleFunc("/api/inventory/replenishment.csv", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		inventoryHandler.GetReplenishmentCSV(w, r)
+	})
+
+	http.HandleFunc("/api/reports/inventory-valuation", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		inventoryHandler.GetValuation(w, r)
+	})
+
+	// reservations
+	http.HandleFunc("/api/reservations", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			reservationHandler.Create(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/api/reservations/", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet:
+			reservationHandler.GetByID(w, r)
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/confirm"):
+			reservationHandler.Confirm(w, r)
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/release"):
+			reservationHandler.Release(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// jobs
+	http.HandleFunc("/api/jobs", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			jobHandler.Create(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/api/jobs/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if strings.HasSuffix(r.URL.Path, "/download") {
+			jobHandler.Download(w, r)
+		} else {
+			jobHandler.GetByID(w, r)
+		}
+	})
+
 	// home dan health
 	http.HandleFunc("/", homeHandler)
 	http.HandleFunc("/health", healthHandler)
@@ -107,6 +615,185 @@ func main() {
 	}
 }
 
+func productSubroutes(w http.ResponseWriter, r *http.Request, segments []string, variantHandler *handlers.VariantHandler, stockHandler *handlers.StockHandler, priceHandler *handlers.PriceHandler, labelHandler *handlers.LabelHandler, imageHandler *handlers.ImageHandler, tagHandler *handlers.TagHandler, relationHandler *handlers.RelationHandler) {
+	route := strings.Join(segments, "/")
+
+	switch {
+	case route == "options":
+		switch r.Method {
+		case http.MethodGet:
+			variantHandler.GetOptions(w, r)
+		case http.MethodPut:
+			variantHandler.SetOptions(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+
+	case route == "variants":
+		switch r.Method {
+		case http.MethodGet:
+			variantHandler.GetAll(w, r)
+		case http.MethodPost:
+			variantHandler.Create(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+
+	case route == "variants/generate":
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		variantHandler.Generate(w, r)
+
+	case segments[0] == "variants" && len(segments) == 2:
+		switch r.Method {
+		case http.MethodGet:
+			variantHandler.GetByID(w, r)
+		case http.MethodPut:
+			variantHandler.Update(w, r)
+		case http.MethodDelete:
+			variantHandler.Delete(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+
+	case route == "stock/adjust":
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		stockHandler.Adjust(w, r)
+
+	case route == "stock/history":
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		stockHandler.GetHistory(w, r)
+
+	case route == "stock/levels":
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		stockHandler.GetLevels(w, r)
+
+	case route == "price-history":
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		priceHandler.GetHistory(w, r)
+
+	case route == "price-schedules":
+		switch r.Method {
+		case http.MethodGet:
+			priceHandler.GetSchedules(w, r)
+		case http.MethodPost:
+			priceHandler.CreateSchedule(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+
+	case segments[0] == "price-schedules" && len(segments) == 2:
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		priceHandler.DeleteSchedule(w, r)
+
+	case route == "images":
+		switch r.Method {
+		case http.MethodGet:
+			imageHandler.GetAll(w, r)
+		case http.MethodPost:
+			imageHandler.Upload(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+
+	case route == "images/order":
+		if r.Method != http.MethodPut {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		imageHandler.Reorder(w, r)
+
+	case segments[0] == "images" && len(segments) == 2:
+		switch r.Method {
+		case http.MethodPut:
+			imageHandler.Update(w, r)
+		case http.MethodDelete:
+			imageHandler.Delete(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+
+	case route == "tags":
+		switch r.Method {
+		case http.MethodGet:
+			tagHandler.GetProductTags(w, r)
+		case http.MethodPost:
+			tagHandler.AddProductTags(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+
+	case segments[0] == "tags" && len(segments) == 2:
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		tagHandler.RemoveProductTag(w, r)
+
+	case route == "relations":
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		relationHandler.GetAll(w, r)
+
+	case segments[0] == "relations" && len(segments) == 2:
+		if r.Method != http.MethodPut {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		relationHandler.Set(w, r)
+
+	case segments[0] == "relations" && len(segments) == 3:
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		relationHandler.Remove(w, r)
+
+	case route == "barcode.png":
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		labelHandler.GetBarcodePNG(w, r)
+
+	case route == "barcode.svg":
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		labelHandler.GetBarcodeSVG(w, r)
+
+	case route == "qr.png":
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		labelHandler.GetQRCode(w, r)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
 func homeHandler(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path != "/" {
 		http.NotFound(w, r)
@@ -124,18 +811,126 @@ func homeHandler(w http.ResponseWriter, r *http.Request) {
 			"GET /api/products/{id} returns category name",
 		},
 		"endpoints": []map[string]string{
-			{"method": "GET", "path": "/api/categories", "description": "List all categories"},
+			{"method": "GET", "path": "/api/categories", "description": "List all categories (optional query: with=stats)"},
 			{"method": "POST", "path": "/api/categories", "description": "Create category"},
 			{"method": "GET", "path": "/api/categories/{id}", "description": "Get category by ID"},
 			{"method": "PUT", "path": "/api/categories/{id}", "description": "Update category"},
-			{"method": "DELETE", "path": "/api/categories/{id}", "description": "Delete category"},
+			{"method": "DELETE", "path": "/api/categories/{id}", "description": "Delete category (optional query: on_products=restrict|cascade|reassign, target=uuid)"},
 			{"method": "POST", "path": "/api/categories/bulk", "description": "Bulk create categories"},
+			{"method": "GET", "path": "/api/categories/tree", "description": "Get nested category tree"},
+			{"method": "GET", "path": "/api/categories/by-slug/{slug}", "description": "Get category by slug, old slugs redirect (301)"},
+			{"method": "GET", "path": "/api/categories/{id}/ancestors", "description": "Get parent chain of a category, root first"},
+			{"method": "GET", "path": "/api/categories/{id}/children", "description": "Get direct subcategories"},
+			{"method": "GET", "path": "/api/categories/{id}/descendants", "description": "Get all subcategories"},
+			{"method": "GET", "path": "/api/categories/{id}/stats", "description": "Get product count, stock totals and price range"},
+			{"method": "GET", "path": "/api/categories/{id}/attributes", "description": "Get the attribute schema products in the category follow"},
+			{"method": "PUT", "path": "/api/categories/{id}/attributes", "description": "Replace the attribute schema (name, type string|number|integer|boolean|enum, unit, values, required)"},
+			{"method": "POST", "path": "/api/categories/{id}/merge", "description": "Merge category into target_id, old ID redirects (301)"},
 
-			{"method": "GET", "path": "/api/products", "description": "List all products (optional query: category_id=uuid)"},
-			{"method": "POST", "path": "/api/products", "description": "Create product with category_id"},
-			{"method": "GET", "path": "/api/products/{id}", "description": "Get product detail with category name (JOIN)"},
+			{"method": "GET", "path": "/api/products", "description": "List all products (optional query: q=full-text search, attr.{name}=a,b or attr.{name}>=n (also >, <, <=), min_price, max_price, stock_status=in_stock|low_stock|out_of_stock, tags=a,b, tags_mode=any|all, category_id=uuid, include_descendants=true, warehouse_id=uuid, currency=USD, price_list=code, tax=incl|excl, view=admin with X-Admin-Key for cost, margin and markup, render=html for description HTML)"},
+			{"method": "GET", "path": "/api/products/facets", "description": "Count products per category, price bucket, stock status and attribute value for the listing filters, each facet ignoring its own filter (optional query: price_buckets=1000,5000)"},
+			{"method": "POST", "path": "/api/products", "description": "Create product with category_id (optional sku, barcode as EAN-8/UPC-A/EAN-13, slug, short_description and description as markdown, attributes per the category schema)"},
+			{"method": "GET", "path": "/api/products/{id}", "description": "Get product detail with category name (JOIN), options and variants (optional query: currency, price_list, tax=incl|excl, view=admin with X-Admin-Key, render=html, include=relations)"},
 			{"method": "PUT", "path": "/api/products/{id}", "description": "Update product"},
 			{"method": "DELETE", "path": "/api/products/{id}", "description": "Delete product"},
+			{"method": "GET", "path": "/api/products/by-sku/{sku}", "description": "Get product by product or variant SKU"},
+			{"method": "GET", "path": "/api/products/by-barcode/{code}", "description": "Get product by product or variant barcode"},
+			{"method": "GET", "path": "/api/products/by-slug/{slug}", "description": "Get product by slug, old slugs redirect (301)"},
+			{"method": "GET", "path": "/api/products/{id}/options", "description": "Get product option definitions"},
+			{"method": "PUT", "path": "/api/products/{id}/options", "description": "Replace product option definitions (e.g. Size: S/M/L)"},
+			{"method": "GET", "path": "/api/products/{id}/variants", "description": "List product variants"},
+			{"method": "POST", "path": "/api/products/{id}/variants", "description": "Create a single variant"},
+			{"method": "POST", "path": "/api/products/{id}/variants/generate", "description": "Generate variants for every missing option combination"},
+			{"method": "GET", "path": "/api/products/{id}/variants/{variant_id}", "description": "Get variant"},
+			{"method": "PUT", "path": "/api/products/{id}/variants/{variant_id}", "description": "Update variant SKU, barcode, price override or stock"},
+			{"method": "DELETE", "path": "/api/products/{id}/variants/{variant_id}", "description": "Delete variant"},
+			{"method": "POST", "path": "/api/products/{id}/stock/adjust", "description": "Apply a stock movement (receipt, sale, adjustment, return, transfer), optional warehouse_id and unit_cost on incoming stock"},
+			{"method": "GET", "path": "/api/products/{id}/stock/history", "description": "List stock movements; unit_cost needs X-Admin-Key (optional query: limit, offset)"},
+			{"method": "GET", "path": "/api/products/{id}/stock/levels", "description": "Get stock per warehouse"},
+			{"method": "GET", "path": "/api/products/{id}/price-history", "description": "List price changes with actor (optional query: limit, offset)"},
+			{"method": "GET", "path": "/api/products/{id}/price-schedules", "description": "List scheduled and sale prices"},
+			{"method": "POST", "path": "/api/products/{id}/price-schedules", "description": "Schedule a price (price, starts_at, ends_at, priority)"},
+			{"method": "DELETE", "path": "/api/products/{id}/price-schedules/{schedule_id}", "description": "Delete a price schedule"},
+			{"method": "GET", "path": "/api/products/{id}/relations", "description": "List related, cross_sell, upsell, accessory and replacement products in display order (optional query: currency, price_list, tax=incl|excl)"},
+			{"method": "PUT", "path": "/api/products/{id}/relations/{type}", "description": "Replace relations of one type with product_ids in order; related and cross_sell apply both ways"},
+			{"method": "DELETE", "path": "/api/products/{id}/relations/{type}/{related_id}", "description": "Remove one relation"},
+			{"method": "GET", "path": "/api/products/{id}/tags", "description": "List product tags"},
+			{"method": "POST", "path": "/api/products/{id}/tags", "description": "Add tags to a product, creating unknown ones"},
+			{"method": "DELETE", "path": "/api/products/{id}/tags/{tag}", "description": "Remove a tag, by ID or name, from a product"},
+			{"method": "GET", "path": "/api/products/{id}/images", "description": "List product images in display order"},
+			{"method": "POST", "path": "/api/products/{id}/images", "description": "Upload an image (multipart: file, alt_text); JPEG, PNG, GIF or WebP, thumb and medium sizes are generated"},
+			{"method": "PUT", "path": "/api/products/{id}/images/order", "description": "Reorder images (image_ids)"},
+			{"method": "PUT", "path": "/api/products/{id}/images/{image_id}", "description": "Update image alt text"},
+			{"method": "DELETE", "path": "/api/products/{id}/images/{image_id}", "description": "Delete an image and its files"},
+			{"method": "GET", "path": "/media/{key}", "description": "Serve an uploaded file"},
+			{"method": "GET", "path": "/api/products/{id}/barcode.png", "description": "Barcode image, EAN-13/EAN-8 or Code128 of the SKU (optional query: module, height)"},
+			{"method": "GET", "path": "/api/products/{id}/barcode.svg", "description": "Barcode as SVG (optional query: module, height)"},
+			{"method": "GET", "path": "/api/products/{id}/qr.png", "description": "QR code of the product URL (optional query: size)"},
+			{"method": "POST", "path": "/api/labels", "description": "PDF sheet of shelf labels with name, price and barcode (product_ids, copies, currency, price_list, tax)"},
+
+			{"method": "GET", "path": "/api/tags", "description": "List tags with product counts"},
+			{"method": "POST", "path": "/api/tags", "description": "Create tag (names are trimmed and case-insensitive)"},
+			{"method": "GET", "path": "/api/tags/{id}", "description": "Get tag"},
+			{"method": "PUT", "path": "/api/tags/{id}", "description": "Rename tag, keeping its products"},
+			{"method": "DELETE", "path": "/api/tags/{id}", "description": "Delete tag from every product"},
+			{"method": "POST", "path": "/api/tags/{id}/merge", "description": "Move every product of the tag to target_id and delete it"},
+
+			{"method": "GET", "path": "/api/warehouses", "description": "List warehouses"},
+			{"method": "POST", "path": "/api/warehouses", "description": "Create warehouse"},
+			{"method": "GET", "path": "/api/warehouses/{id}", "description": "Get warehouse"},
+			{"method": "PUT", "path": "/api/warehouses/{id}", "description": "Update warehouse"},
+			{"method": "DELETE", "path": "/api/warehouses/{id}", "description": "Delete empty warehouse"},
+			{"method": "GET", "path": "/api/warehouses/{id}/stock", "description": "Get stock levels held in a warehouse"},
+			{"method": "POST", "path": "/api/warehouses/transfers", "description": "Transfer stock between warehouses"},
+
+			{"method": "GET", "path": "/api/price-lists", "description": "List price lists"},
+			{"method": "POST", "path": "/api/price-lists", "description": "Create price list (code, name, currency)"},
+			{"method": "GET", "path": "/api/price-lists/{id}", "description": "Get price list"},
+			{"method": "PUT", "path": "/api/price-lists/{id}", "description": "Update price list"},
+			{"method": "DELETE", "path": "/api/price-lists/{id}", "description": "Delete price list"},
+			{"method": "GET", "path": "/api/price-lists/{id}/items", "description": "List per-product prices"},
+			{"method": "PUT", "path": "/api/price-lists/{id}/items", "description": "Upsert per-product prices in the list currency"},
+			{"method": "DELETE", "path": "/api/price-lists/{id}/items/{product_id}", "description": "Remove a product from the list"},
+			{"method": "GET", "path": "/api/exchange-rates", "description": "List exchange rates"},
+			{"method": "PUT", "path": "/api/exchange-rates", "description": "Set exchange rate (base, quote, rate)"},
+			{"method": "DELETE", "path": "/api/exchange-rates/{base}/{quote}", "description": "Delete exchange rate"},
+
+			{"method": "GET", "path": "/api/customer-groups", "description": "List customer groups"},
+			{"method": "POST", "path": "/api/customer-groups", "description": "Create customer group (code, name)"},
+			{"method": "GET", "path": "/api/customer-groups/{id}", "description": "Get customer group"},
+			{"method": "PUT", "path": "/api/customer-groups/{id}", "description": "Update customer group"},
+			{"method": "DELETE", "path": "/api/customer-groups/{id}", "description": "Delete customer group and its price rules"},
+			{"method": "GET", "path": "/api/price-rules", "description": "List quantity-tier price rules (optional query: customer_group_id, product_id, category_id)"},
+			{"method": "POST", "path": "/api/price-rules", "description": "Create price rule on a product or category (min_qty, discount_bp or fixed_price)"},
+			{"method": "DELETE", "path": "/api/price-rules/{id}", "description": "Delete price rule"},
+			{"method": "POST", "path": "/api/pricing/quote", "description": "Quote line items for a customer group with tier pricing"},
+
+			{"method": "GET", "path": "/api/promotions", "description": "List promotions (optional query: active=true for running ones)"},
+			{"method": "POST", "path": "/api/promotions", "description": "Create promotion (percent, fixed, buy_x_get_y or bundle; optional coupon code; scope catalog or targets with product_ids/category_ids)"},
+			{"method": "GET", "path": "/api/promotions/{id}", "description": "Get promotion"},
+			{"method": "PUT", "path": "/api/promotions/{id}", "description": "Update promotion"},
+			{"method": "DELETE", "path": "/api/promotions/{id}", "description": "Delete promotion"},
+			{"method": "POST", "path": "/api/promotions/evaluate", "description": "Apply promotions and coupons to a cart line by line (redeem=true counts usage)"},
+
+			{"method": "GET", "path": "/api/tax-classes", "description": "List tax classes with their current rate"},
+			{"method": "POST", "path": "/api/tax-classes", "description": "Create tax class (code, name, optional rate_bp)"},
+			{"method": "GET", "path": "/api/tax-classes/{id}", "description": "Get tax class with rate history"},
+			{"method": "PUT", "path": "/api/tax-classes/{id}", "description": "Update tax class"},
+			{"method": "DELETE", "path": "/api/tax-classes/{id}", "description": "Delete tax class"},
+			{"method": "POST", "path": "/api/tax-classes/{id}/rates", "description": "Add a rate in basis points (rate_bp, effective_from)"},
+
+			{"method": "GET", "path": "/api/inventory/low-stock", "description": "Products at or under their low_stock_threshold, grouped by category"},
+			{"method": "GET", "path": "/api/inventory/replenishment.csv", "description": "CSV replenishment report with suggested order quantities"},
+			{"method": "GET", "path": "/api/reports/inventory-valuation", "description": "Stock value at cost by category, needs X-Admin-Key (optional query: method=weighted_average|fifo)"},
+
+			{"method": "POST", "path": "/api/reservations", "description": "Hold stock for several products or variants, all or nothing (lines[].variant_id, ttl_seconds)"},
+			{"method": "GET", "path": "/api/reservations/{id}", "description": "Get reservation"},
+			{"method": "POST", "path": "/api/reservations/{id}/confirm", "description": "Confirm reservation and decrement stock"},
+			{"method": "POST", "path": "/api/reservations/{id}/release", "description": "Release held stock"},
+
+			{"method": "POST", "path": "/api/jobs", "description": "Queue a product import (multipart file) or export job"},
+			{"method": "GET", "path": "/api/jobs/{id}", "description": "Get job status, progress and errors"},
+			{"method": "GET", "path": "/api/jobs/{id}/download", "description": "Download a finished export"},
 
 			{"method": "GET", "path": "/health", "description": "Health check"},
 		},
@@ -153,9 +948,40 @@ func healthHandler(w http.ResponseWriter, r *http.Request) {
 		"service":   "product-catalog-api",
 		"timestamp": time.Now().Format(time.RFC3339),
 		"database":  "connected",
-		"tables":    []string{"categories", "products"},
+		"tables": []string{
+			"categories", "category_redirects", "category_slug_redirects", "category_attributes",
+			"products", "product_slug_redirects", "tags", "product_tags", "product_relations", "product_images", "product_image_variants", "product_options", "product_variants", "stock_movements", "price_history", "price_schedules",
+			"price_lists", "price_list_items", "exchange_rates", "tax_classes", "tax_rates",
+			"customer_groups", "price_rules",
+			"promotions", "promotion_targets",
+			"warehouses", "stock_levels", "reservations", "reservation_lines",
+			"jobs",
+		},
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
+
+// newBlobStore picks where uploads go. Local files, and S3 objects without a
+// public bucket URL, are served back through /media/.
+func newBlobStore(cfg config.Config) (blobstore.BlobStore, error) {
+	mediaURL := cfg.PublicURL + "/media"
+
+	if cfg.BlobBackend == "s3" {
+		baseURL := cfg.S3PublicURL
+		if baseURL == "" {
+			baseURL = mediaURL
+		}
+		return blobstore.NewS3Store(blobstore.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			UseSSL:    cfg.S3UseSSL,
+		}, baseURL)
+	}
+
+	return blobstore.NewLocalStore(filepath.Join(cfg.StorageDir, "media"), mediaURL)
+}
@@ -0,0 +1,41 @@
+// Package markdown renders user-written markdown to HTML that is safe to
+// embed in a storefront page.
+package markdown
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+var (
+	renderer = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+	// bluemonday policies are safe for concurrent use once built
+	policyOnce sync.Once
+	policy     *bluemonday.Policy
+)
+
+// ToHTML renders src as GitHub-flavoured markdown and sanitizes the result.
+// goldmark already drops raw HTML; the sanitizer also strips unsafe link
+// schemes and attributes, and adds rel="nofollow" to links.
+func ToHTML(src string) (string, error) {
+
+	if src == "" {
+		return "", nil
+	}
+
+	var buf bytes.Buffer
+	if err := renderer.Convert([]byte(src), &buf); err != nil {
+		return "", err
+	}
+
+	policyOnce.Do(func() {
+		policy = bluemonday.UGCPolicy()
+		policy.AllowAttrs("checked", "disabled", "type").OnElements("input")
+	})
+	return policy.Sanitize(buf.String()), nil
+}
@@ -0,0 +1,158 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// MaxPixels bounds the decoded size of an upload, so a small file cannot
+// expand into gigabytes of pixels.
+const MaxPixels = 40_000_000
+
+// extensions maps the image types we accept to the extension stored.
+var extensions = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+	"image/webp": "webp",
+}
+
+// Variant is a resized copy that fits within MaxSize x MaxSize.
+type Variant struct {
+	Name    string
+	MaxSize int
+}
+
+var Variants = []Variant{
+	{Name: "thumb", MaxSize: 200},
+	{Name: "medium", MaxSize: 800},
+}
+
+// Rendition is one encoded file of a variant.
+type Rendition struct {
+	Variant     string
+	Format      string
+	ContentType string
+	Width       int
+	Height      int
+	Data        []byte
+}
+
+// Processed is a checked upload with its renditions.
+type Processed struct {
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+	Renditions  []Rendition
+}
+
+// Process sniffs and decodes an uploaded image, whatever its declared type,
+// and renders every variant twice: as JPEG, or PNG when the image has
+// transparency, and as WebP. Variants are never upscaled. The WebP files are
+// lossless, so for photos they come out larger than the JPEGs; they pay off
+// on flat artwork and transparent images.
+func Process(data []byte) (*Processed, error) {
+
+	contentType := http.DetectContentType(data)
+	ext, ok := extensions[contentType]
+	if !ok {
+		return nil, fmt.Errorf("file must be a JPEG, PNG, GIF or WebP image, got %s", contentType)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid image: %w", err)
+	}
+	if cfg.Width < 1 || cfg.Height < 1 {
+		return nil, errors.New("invalid image: no pixels")
+	}
+	if cfg.Width*cfg.Height > MaxPixels {
+		return nil, fmt.Errorf("image cannot exceed %d megapixels", MaxPixels/1_000_000)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid image: %w", err)
+	}
+
+	processed := &Processed{
+		ContentType: contentType,
+		Extension:   ext,
+		Width:       cfg.Width,
+		Height:      cfg.Height,
+	}
+
+	opaque := isOpaque(img)
+	for _, v := range Variants {
+		resized := fit(img, v.MaxSize)
+		size := resized.Bounds().Size()
+
+		var buf bytes.Buffer
+		format, formatType := "jpg", "image/jpeg"
+		if opaque {
+			err = jpeg.Encode(&buf, resized, &jpeg.Options{Quality: 85})
+		} else {
+			format, formatType = "png", "image/png"
+			err = png.Encode(&buf, resized)
+		}
+		if err != nil {
+			return nil, err
+		}
+		processed.Renditions = append(processed.Renditions, Rendition{
+			Variant: v.Name, Format: format, ContentType: formatType,
+			Width: size.X, Height: size.Y, Data: buf.Bytes(),
+		})
+
+		webp, err := EncodeWebPBytes(resized)
+		if err != nil {
+			return nil, err
+		}
+		processed.Renditions = append(processed.Renditions, Rendition{
+			Variant: v.Name, Format: "webp", ContentType: "image/webp",
+			Width: size.X, Height: size.Y, Data: webp,
+		})
+	}
+
+	return processed, nil
+}
+
+// fit scales img down to fit within maxSize x maxSize, keeping its aspect ratio.
+func fit(img image.Image, maxSize int) image.Image {
+
+	b := img.Bounds()
+	width, height := b.Dx(), b.Dy()
+	if width <= maxSize && height <= maxSize {
+		dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+		return dst
+	}
+
+	if width >= height {
+		height = max(1, height*maxSize/width)
+		width = maxSize
+	} else {
+		width = max(1, width*maxSize/height)
+		height = maxSize
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
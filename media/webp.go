@@ -0,0 +1,568 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"io"
+	"sort"
+)
+
+// EncodeWebP writes img as a lossless WebP (VP8L). The encoder keeps to a
+// small part of the format: the subtract-green and predictor transforms,
+// prefix-coded literals and run-length backward references to the pixel on
+// the left or above, without a color cache. That compresses thumbnails well
+// enough and round-trips exactly.
+func EncodeWebP(w io.Writer, img image.Image) error {
+
+	b := img.Bounds()
+	width, height := b.Dx(), b.Dy()
+	if width < 1 || height < 1 || width > 1<<14 || height > 1<<14 {
+		return errors.New("webp: image must be between 1x1 and 16384x16384 pixels")
+	}
+
+	nrgba := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(nrgba, nrgba.Bounds(), img, b.Min, draw.Src)
+
+	argb := make([]uint32, width*height)
+	hasAlpha := false
+	for i := range argb {
+		p := nrgba.Pix[i*4 : i*4+4]
+		argb[i] = uint32(p[3])<<24 | uint32(p[0])<<16 | uint32(p[1])<<8 | uint32(p[2])
+		if p[3] != 0xff {
+			hasAlpha = true
+		}
+	}
+
+	var bw bitWriter
+	bw.write(0x2f, 8)
+	bw.write(uint32(width-1), 14)
+	bw.write(uint32(height-1), 14)
+	if hasAlpha {
+		bw.write(1, 1)
+	} else {
+		bw.write(0, 1)
+	}
+	bw.write(0, 3)
+
+	// transforms are undone in reverse, so subtract green goes first
+	subtractGreen(argb)
+	bw.write(1, 1)
+	bw.write(2, 2)
+
+	modes, bits := predict(argb, width, height)
+	bw.write(1, 1)
+	bw.write(0, 2)
+	bw.write(uint32(bits-2), 3)
+	writeEntropyImage(&bw, modes, (width+1<<bits-1)>>bits, false)
+
+	bw.write(0, 1)
+	writeEntropyImage(&bw, argb, width, true)
+
+	data := bw.bytes()
+	chunk := len(data)
+	padded := chunk + chunk&1
+
+	var header [20]byte
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], uint32(12+padded))
+	copy(header[8:16], "WEBPVP8L")
+	binary.LittleEndian.PutUint32(header[16:20], uint32(chunk))
+
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	if chunk&1 == 1 {
+		data = append(data, 0)
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// EncodeWebPBytes is EncodeWebP into a byte slice.
+func EncodeWebPBytes(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := EncodeWebP(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type bitWriter struct {
+	buf   []byte
+	acc   uint64
+	nbits uint
+}
+
+// write appends the n low bits of v, least significant first.
+func (bw *bitWriter) write(v uint32, n uint) {
+	bw.acc |= uint64(v) << bw.nbits
+	bw.nbits += n
+	for bw.nbits >= 8 {
+		bw.buf = append(bw.buf, byte(bw.acc))
+		bw.acc >>= 8
+		bw.nbits -= 8
+	}
+}
+
+func (bw *bitWriter) bytes() []byte {
+	if bw.nbits > 0 {
+		bw.buf = append(bw.buf, byte(bw.acc))
+		bw.acc, bw.nbits = 0, 0
+	}
+	return bw.buf
+}
+
+func subtractGreen(argb []uint32) {
+	for i, p := range argb {
+		g := (p >> 8) & 0xff
+		r := ((p >> 16) - g) & 0xff
+		b := (p - g) & 0xff
+		argb[i] = p&0xff00ff00 | r<<16 | b
+	}
+}
+
+// predictorModes are the predictors tried for each tile: left, top and select.
+var predictorModes = []int{1, 2, 11}
+
+// predict replaces argb with its residuals, picking per tile the predictor
+// with the smallest residuals, and returns the tile modes image.
+func predict(argb []uint32, width, height int) ([]uint32, int) {
+
+	const bits = 4
+	tiles := (width + 1<<bits - 1) >> bits
+	rows := (height + 1<<bits - 1) >> bits
+	modes := make([]uint32, tiles*rows)
+
+	// predictions read the untransformed neighbours, so work from a copy
+	src := make([]uint32, len(argb))
+	copy(src, argb)
+
+	for ty := 0; ty < rows; ty++ {
+		for tx := 0; tx < tiles; tx++ {
+			x0, y0 := tx<<bits, ty<<bits
+			x1, y1 := min(x0+1<<bits, width), min(y0+1<<bits, height)
+
+			best, bestCost := predictorModes[0], -1
+			for _, mode := range predictorModes {
+				cost := 0
+				for y := y0; y < y1; y++ {
+					for x := x0; x < x1; x++ {
+						cost += residualCost(sub(src[y*width+x], predictPixel(src, width, x, y, mode)))
+					}
+				}
+				if bestCost < 0 || cost < bestCost {
+					best, bestCost = mode, cost
+				}
+			}
+
+			modes[ty*tiles+tx] = 0xff000000 | uint32(best)<<8
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					argb[y*width+x] = sub(src[y*width+x], predictPixel(src, width, x, y, best))
+				}
+			}
+		}
+	}
+
+	return modes, bits
+}
+
+// predictPixel follows the decoder: the first pixel is predicted as opaque
+// black, the rest of the first row from the left and the first column from
+// the top, whatever the tile's mode.
+func predictPixel(src []uint32, width, x, y, mode int) uint32 {
+	switch {
+	case x == 0 && y == 0:
+		return 0xff000000
+	case y == 0:
+		return src[x-1]
+	case x == 0:
+		return src[(y-1)*width]
+	}
+
+	left := src[y*width+x-1]
+	top := src[(y-1)*width+x]
+	switch mode {
+	case 1:
+		return left
+	case 2:
+		return top
+	default:
+		return selectPredictor(left, top, src[(y-1)*width+x-1])
+	}
+}
+
+func selectPredictor(left, top, topLeft uint32) uint32 {
+	// distance of the estimate left+top-topLeft to left, and to top
+	toLeft, toTop := 0, 0
+	for shift := 0; shift < 32; shift += 8 {
+		l := int(left >> shift & 0xff)
+		t := int(top >> shift & 0xff)
+		tl := int(topLeft >> shift & 0xff)
+		toLeft += abs(t - tl)
+		toTop += abs(l - tl)
+	}
+	if toLeft < toTop {
+		return left
+	}
+	return top
+}
+
+// sub subtracts per channel, modulo 256.
+func sub(a, b uint32) uint32 {
+	ag := (a | 0x00ff00ff) - (b & 0xff00ff00)
+	rb := (a | 0xff00ff00) - (b & 0x00ff00ff)
+	return ag&0xff00ff00 | rb&0x00ff00ff
+}
+
+func residualCost(p uint32) int {
+	cost := 0
+	for shift := 0; shift < 32; shift += 8 {
+		c := int(p >> shift & 0xff)
+		if c > 127 {
+			c = 256 - c
+		}
+		cost += c
+	}
+	return cost
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// backward references copy the pixel on the left or the one above; in the
+// distance code's plane mapping those are codes 2 and 1
+const (
+	distanceLeft  = 2
+	distanceAbove = 1
+	minRun        = 4
+	maxRun        = 4096
+)
+
+// token is a literal pixel or, with length set, a backward reference.
+type token struct {
+	pixel    uint32
+	length   int
+	distance int
+}
+
+// tokenize finds runs that repeat the pixel on the left or the row above.
+func tokenize(pixels []uint32, width int) []token {
+
+	var tokens []token
+	for i := 0; i < len(pixels); {
+		left, above := 0, 0
+		if i >= 1 {
+			for left < maxRun && i+left < len(pixels) && pixels[i+left] == pixels[i-1] {
+				left++
+			}
+		}
+		if i >= width {
+			for above < maxRun && i+above < len(pixels) && pixels[i+above] == pixels[i+above-width] {
+				above++
+			}
+		}
+
+		switch {
+		case left >= minRun && left >= above:
+			tokens = append(tokens, token{length: left, distance: distanceLeft})
+			i += left
+		case above >= minRun:
+			tokens = append(tokens, token{length: above, distance: distanceAbove})
+			i += above
+		default:
+			tokens = append(tokens, token{pixel: pixels[i]})
+			i++
+		}
+	}
+	return tokens
+}
+
+// prefixEncode splits a length or distance code into its prefix symbol and
+// extra bits.
+func prefixEncode(value int) (symbol, extraBits, extra int) {
+	v := value - 1
+	if v < 4 {
+		return v, 0, 0
+	}
+	high := 0
+	for v>>(high+1) != 0 {
+		high++
+	}
+	second := v >> (high - 1) & 1
+	extraBits = high - 1
+	return 2*high + second, extraBits, v & (1<<extraBits - 1)
+}
+
+// writeEntropyImage writes pixels with one group of five prefix codes:
+// green (with run lengths), red, blue, alpha and distance. The main image
+// also says it has no meta prefix codes, which sub-images may not.
+func writeEntropyImage(bw *bitWriter, pixels []uint32, width int, main bool) {
+
+	// no color cache
+	bw.write(0, 1)
+	if main {
+		bw.write(0, 1)
+	}
+
+	tokens := tokenize(pixels, width)
+
+	green := make([]int, 256+24)
+	red := make([]int, 256)
+	blue := make([]int, 256)
+	alpha := make([]int, 256)
+	distance := make([]int, 40)
+	for _, t := range tokens {
+		if t.length > 0 {
+			lengthSymbol, _, _ := prefixEncode(t.length)
+			distanceSymbol, _, _ := prefixEncode(t.distance)
+			green[256+lengthSymbol]++
+			distance[distanceSymbol]++
+			continue
+		}
+		p := t.pixel
+		green[p>>8&0xff]++
+		red[p>>16&0xff]++
+		blue[p&0xff]++
+		alpha[p>>24]++
+	}
+
+	codes := make([]prefixCode, 5)
+	for i, freq := range [][]int{green, red, blue, alpha, distance} {
+		codes[i] = writePrefixCode(bw, freq)
+	}
+
+	for _, t := range tokens {
+		if t.length > 0 {
+			symbol, extraBits, extra := prefixEncode(t.length)
+			codes[0].put(bw, 256+symbol)
+			bw.write(uint32(extra), uint(extraBits))
+			symbol, extraBits, extra = prefixEncode(t.distance)
+			codes[4].put(bw, symbol)
+			bw.write(uint32(extra), uint(extraBits))
+			continue
+		}
+		p := t.pixel
+		codes[0].put(bw, int(p>>8&0xff))
+		codes[1].put(bw, int(p>>16&0xff))
+		codes[2].put(bw, int(p&0xff))
+		codes[3].put(bw, int(p>>24))
+	}
+}
+
+type prefixCode struct {
+	codes   []uint32
+	lengths []uint8
+}
+
+func (c prefixCode) put(bw *bitWriter, symbol int) {
+	if n := c.lengths[symbol]; n > 0 {
+		bw.write(c.codes[symbol], uint(n))
+	}
+}
+
+// codeLengthOrder is the order the code length code's lengths are sent in.
+var codeLengthOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// writePrefixCode builds a prefix code for freq, writes it and returns it.
+// A single literal symbol gets a simple code, which takes no bits per symbol.
+func writePrefixCode(bw *bitWriter, freq []int) prefixCode {
+
+	var used []int
+	for s, f := range freq {
+		if f > 0 {
+			used = append(used, s)
+		}
+	}
+
+	if len(used) == 0 || len(used) == 1 && used[0] < 256 {
+		symbol := 0
+		if len(used) == 1 {
+			symbol = used[0]
+		}
+		bw.write(1, 1)
+		bw.write(0, 1)
+		if symbol < 2 {
+			bw.write(0, 1)
+			bw.write(uint32(symbol), 1)
+		} else {
+			bw.write(1, 1)
+			bw.write(uint32(symbol), 8)
+		}
+		return prefixCode{codes: make([]uint32, len(freq)), lengths: make([]uint8, len(freq))}
+	}
+
+	lengths := huffmanLengths(freq, 15)
+
+	// code lengths, with runs of zeros folded into codes 17 and 18
+	type lengthToken struct{ symbol, extra, extraBits int }
+	var tokens []lengthToken
+	for i := 0; i < len(lengths); {
+		if lengths[i] != 0 {
+			tokens = append(tokens, lengthToken{int(lengths[i]), 0, 0})
+			i++
+			continue
+		}
+		run := 1
+		for i+run < len(lengths) && lengths[i+run] == 0 && run < 138 {
+			run++
+		}
+		switch {
+		case run >= 11:
+			tokens = append(tokens, lengthToken{18, run - 11, 7})
+		case run >= 3:
+			tokens = append(tokens, lengthToken{17, run - 3, 3})
+		default:
+			run = 1
+			tokens = append(tokens, lengthToken{0, 0, 0})
+		}
+		i += run
+	}
+
+	lengthFreq := make([]int, 19)
+	for _, t := range tokens {
+		lengthFreq[t.symbol]++
+	}
+	lengthCode := canonicalCode(huffmanLengths(lengthFreq, 7))
+
+	count := 19
+	for count > 4 && lengthCode.lengths[codeLengthOrder[count-1]] == 0 {
+		count--
+	}
+
+	bw.write(0, 1)
+	bw.write(uint32(count-4), 4)
+	for _, s := range codeLengthOrder[:count] {
+		bw.write(uint32(lengthCode.lengths[s]), 3)
+	}
+	// lengths follow for every symbol of the alphabet
+	bw.write(0, 1)
+	for _, t := range tokens {
+		lengthCode.put(bw, t.symbol)
+		if t.extraBits > 0 {
+			bw.write(uint32(t.extra), uint(t.extraBits))
+		}
+	}
+
+	return canonicalCode(lengths)
+}
+
+// huffmanLengths returns code lengths of at most limit bits for freq. When
+// the tree gets too deep the frequencies are flattened and it is rebuilt.
+// At least two symbols always get a code, so the code is complete.
+func huffmanLengths(freq []int, limit int) []uint8 {
+
+	f := make([]int, len(freq))
+	copy(f, freq)
+
+	nonzero := 0
+	for _, v := range f {
+		if v > 0 {
+			nonzero++
+		}
+	}
+	for s := 0; nonzero < 2 && s < len(f); s++ {
+		if f[s] == 0 {
+			f[s] = 1
+			nonzero++
+		}
+	}
+
+	for {
+		lengths := buildHuffman(f)
+		longest := uint8(0)
+		for _, l := range lengths {
+			longest = max(longest, l)
+		}
+		if int(longest) <= limit {
+			return lengths
+		}
+		for i, v := range f {
+			if v > 0 {
+				f[i] = (v + 1) / 2
+			}
+		}
+	}
+}
+
+func buildHuffman(freq []int) []uint8 {
+
+	type node struct {
+		weight      int
+		symbol      int
+		left, right int
+	}
+
+	var nodes []node
+	var queue []int
+	for s, f := range freq {
+		if f > 0 {
+			nodes = append(nodes, node{weight: f, symbol: s, left: -1, right: -1})
+			queue = append(queue, len(nodes)-1)
+		}
+	}
+
+	for len(queue) > 1 {
+		sort.SliceStable(queue, func(i, j int) bool {
+			return nodes[queue[i]].weight < nodes[queue[j]].weight
+		})
+		a, b := queue[0], queue[1]
+		nodes = append(nodes, node{weight: nodes[a].weight + nodes[b].weight, symbol: -1, left: a, right: b})
+		queue = append(queue[2:], len(nodes)-1)
+	}
+
+	lengths := make([]uint8, len(freq))
+	var walk func(n int, depth uint8)
+	walk = func(n int, depth uint8) {
+		if nodes[n].symbol >= 0 {
+			lengths[nodes[n].symbol] = depth
+			return
+		}
+		walk(nodes[n].left, depth+1)
+		walk(nodes[n].right, depth+1)
+	}
+	walk(queue[0], 0)
+	return lengths
+}
+
+// canonicalCode assigns canonical codes to lengths. Codes are read from the
+// stream one bit at a time, first bit first, so they are stored reversed.
+func canonicalCode(lengths []uint8) prefixCode {
+
+	var count [16]uint32
+	for _, l := range lengths {
+		if l > 0 {
+			count[l]++
+		}
+	}
+
+	var next [16]uint32
+	code := uint32(0)
+	for l := 1; l < 16; l++ {
+		code = (code + count[l-1]) << 1
+		next[l] = code
+	}
+
+	codes := make([]uint32, len(lengths))
+	for s, l := range lengths {
+		if l == 0 {
+			continue
+		}
+		c := next[l]
+		next[l]++
+		var reversed uint32
+		for i := uint8(0); i < l; i++ {
+			reversed = reversed<<1 | c>>i&1
+		}
+		codes[s] = reversed
+	}
+
+	return prefixCode{codes: codes, lengths: lengths}
+}
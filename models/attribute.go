@@ -0,0 +1,79 @@
+package models
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/google/uuid"
+)
+
+// attribute value types
+const (
+	AttributeString  = "string"
+	AttributeNumber  = "number"
+	AttributeInteger = "integer"
+	AttributeBoolean = "boolean"
+	AttributeEnum    = "enum"
+)
+
+const (
+	MaxCategoryAttributes    = 50
+	MaxAttributeStringLength = 500
+	MaxAttributeUnitLength   = 20
+)
+
+// attribute filter operators
+const (
+	AttributeEqual        = "="
+	AttributeGreater      = ">"
+	AttributeGreaterEqual = ">="
+	AttributeLess         = "<"
+	AttributeLessEqual    = "<="
+)
+
+// CategoryAttribute is one custom attribute of a category's products, e.g.
+// storage_gb on phones. Values lists the choices of an enum attribute; Unit
+// is for display only.
+type CategoryAttribute struct {
+	ID         uuid.UUID `json:"id"`
+	CategoryID uuid.UUID `json:"category_id"`
+	Name       string    `json:"name"`
+	Type       string    `json:"type"`
+	Unit       string    `json:"unit"`
+	Values     []string  `json:"values,omitempty"`
+	Required   bool      `json:"required"`
+	Position   int       `json:"position"`
+}
+
+type SetAttributesRequest struct {
+	Attributes []AttributeInput `json:"attributes"`
+}
+
+type AttributeInput struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Unit     string   `json:"unit"`
+	Values   []string `json:"values"`
+	Required bool     `json:"required"`
+}
+
+// AttributeFilter narrows listings by a custom attribute, from query
+// parameters such as attr.storage_gb>=128 or attr.color=red,blue. Equality
+// matches any of Values, ignoring case; the range operators take a single
+// number.
+type AttributeFilter struct {
+	Name   string
+	Op     string
+	Values []string
+}
+
+var attributeNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,62}$`)
+
+// ValidateAttributeName requires lower snake case, so names can appear in
+// filter parameters unquoted.
+func ValidateAttributeName(name string) error {
+	if !attributeNamePattern.MatchString(name) {
+		return errors.New("attribute name must be lower case letters, digits and underscores, starting with a letter")
+	}
+	return nil
+}
@@ -1,29 +1,97 @@
 package models
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// MaxCategoryDepth is the deepest level a category may sit at, counting the
+// root as level 1.
+const MaxCategoryDepth = 5
+
+// what happens to a category's products when it is deleted
+const (
+	DeletePolicyRestrict = "restrict"
+	DeletePolicyCascade  = "cascade"
+	DeletePolicyReassign = "reassign"
+)
+
 type Category struct {
-	ID          uuid.UUID `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uuid.UUID      `json:"id"`
+	Name        string         `json:"name"`
+	Slug        string         `json:"slug"`
+	Description string         `json:"description"`
+	ParentID    *uuid.UUID     `json:"parent_id"`
+	TaxClassID  *uuid.UUID     `json:"tax_class_id"`
+	Depth       int            `json:"depth,omitempty"`
+	Children    []Category     `json:"children,omitempty"`
+	Stats       *CategoryStats `json:"stats,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
 }
 
+// CreateCategoryRequest derives the slug from the name when none is given.
 type CreateCategoryRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name        string     `json:"name"`
+	Slug        string     `json:"slug"`
+	Description string     `json:"description"`
+	ParentID    *uuid.UUID `json:"parent_id,omitempty"`
+	TaxClassID  *uuid.UUID `json:"tax_class_id,omitempty"`
 }
 
+// UpdateCategoryRequest keeps the current parent when parent_id is omitted;
+// send the nil UUID to move the category to the root. tax_class_id works the
+// same way, the nil UUID clears it. A changed slug leaves the old one behind
+// as a redirect.
 type UpdateCategoryRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name        string     `json:"name"`
+	Slug        *string    `json:"slug,omitempty"`
+	Description string     `json:"description"`
+	ParentID    *uuid.UUID `json:"parent_id,omitempty"`
+	TaxClassID  *uuid.UUID `json:"tax_class_id,omitempty"`
 }
 
 type BulkCreateRequest struct {
 	Categories []CreateCategoryRequest `json:"categories"`
 }
+
+type DeleteCategoryResult struct {
+	ID               uuid.UUID  `json:"id"`
+	OnProducts       string     `json:"on_products"`
+	TargetID         *uuid.UUID `json:"target_id,omitempty"`
+	ProductsAffected int64      `json:"products_affected"`
+}
+
+// CategoryNotEmptyError is returned when a restrict delete finds products
+// still in the category.
+type CategoryNotEmptyError struct {
+	Products int64
+}
+
+func (e *CategoryNotEmptyError) Error() string {
+	return fmt.Sprintf("category still has %d products", e.Products)
+}
+
+type MergeCategoryRequest struct {
+	TargetID uuid.UUID `json:"target_id"`
+}
+
+type MergeCategoryResult struct {
+	SourceID      uuid.UUID `json:"source_id"`
+	TargetID      uuid.UUID `json:"target_id"`
+	ProductsMoved int64     `json:"products_moved"`
+	ChildrenMoved int64     `json:"children_moved"`
+}
+
+// CategoryStats aggregates the products directly assigned to a category.
+// Price fields are null when the category has no products.
+type CategoryStats struct {
+	ProductCount int64  `json:"product_count"`
+	InStockCount int64  `json:"in_stock_count"`
+	TotalStock   int64  `json:"total_stock"`
+	MinPrice     *int64 `json:"min_price"`
+	MaxPrice     *int64 `json:"max_price"`
+	AvgPrice     *int64 `json:"avg_price"`
+}
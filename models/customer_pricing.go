@@ -0,0 +1,103 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CustomerGroup struct {
+	ID        uuid.UUID `json:"id"`
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CreateCustomerGroupRequest struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+type UpdateCustomerGroupRequest struct {
+	Name *string `json:"name,omitempty"`
+}
+
+// PriceRule is a quantity break on a product or a category subtree, e.g.
+// "10+ units at 5% off". Exactly one of DiscountBP (basis points off the base
+// price) and FixedPrice (unit price) is set. A nil CustomerGroupID applies
+// to every customer.
+type PriceRule struct {
+	ID              uuid.UUID  `json:"id"`
+	CustomerGroupID *uuid.UUID `json:"customer_group_id"`
+	ProductID       *uuid.UUID `json:"product_id"`
+	CategoryID      *uuid.UUID `json:"category_id"`
+	MinQty          int        `json:"min_qty"`
+	DiscountBP      *int       `json:"discount_bp"`
+	FixedPrice      *int64     `json:"fixed_price"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// UnitPrice applies the rule to a base unit price.
+func (r PriceRule) UnitPrice(base int64) int64 {
+	if r.FixedPrice != nil {
+		return *r.FixedPrice
+	}
+	return base - BasisPointsOf(base, *r.DiscountBP)
+}
+
+type CreatePriceRuleRequest struct {
+	CustomerGroupID *uuid.UUID `json:"customer_group_id,omitempty"`
+	ProductID       *uuid.UUID `json:"product_id,omitempty"`
+	CategoryID      *uuid.UUID `json:"category_id,omitempty"`
+	MinQty          int        `json:"min_qty"`
+	DiscountBP      *int       `json:"discount_bp,omitempty"`
+	FixedPrice      *int64     `json:"fixed_price,omitempty"`
+}
+
+// PriceRuleFilter narrows rule listings. Zero values mean no filter.
+type PriceRuleFilter struct {
+	CustomerGroupID *uuid.UUID
+	ProductID       *uuid.UUID
+	CategoryID      *uuid.UUID
+}
+
+// ApplicableRule is a rule that may price ProductID. Depth is 0 for rules on
+// the product itself and counts category levels up from it otherwise.
+type ApplicableRule struct {
+	ProductID uuid.UUID
+	Depth     int
+	Rule      PriceRule
+}
+
+// QuoteRequest prices a set of lines for a customer group, given by code or
+// ID. Without a group only rules for every customer apply.
+type QuoteRequest struct {
+	CustomerGroup string             `json:"customer_group"`
+	Items         []QuoteItemRequest `json:"items"`
+}
+
+type QuoteItemRequest struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Quantity  int       `json:"quantity"`
+}
+
+type QuoteLine struct {
+	ProductID     uuid.UUID  `json:"product_id"`
+	Name          string     `json:"name"`
+	Quantity      int        `json:"quantity"`
+	BaseUnitPrice int64      `json:"base_unit_price"`
+	UnitPrice     int64      `json:"unit_price"`
+	AppliedRule   *PriceRule `json:"applied_rule"`
+	LineDiscount  int64      `json:"line_discount"`
+	LineTotal     int64      `json:"line_total"`
+}
+
+type Quote struct {
+	CustomerGroup *CustomerGroup `json:"customer_group"`
+	Currency      string         `json:"currency"`
+	Lines         []QuoteLine    `json:"lines"`
+	Subtotal      int64          `json:"subtotal"`
+	DiscountTotal int64          `json:"discount_total"`
+	Total         int64          `json:"total"`
+}
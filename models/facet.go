@@ -0,0 +1,87 @@
+package models
+
+import (
+	"math"
+
+	"github.com/google/uuid"
+)
+
+// stock statuses, from available stock against the low-stock threshold
+const (
+	StockStatusInStock    = "in_stock"
+	StockStatusLowStock   = "low_stock"
+	StockStatusOutOfStock = "out_of_stock"
+)
+
+const (
+	DefaultPriceBuckets = 5
+	MaxPriceBuckets     = 20
+	// MaxFacetValues caps the values listed per attribute, most common first.
+	MaxFacetValues = 50
+)
+
+// ProductFacets counts the products of a filtered listing along each facet.
+// Every facet ignores its own filter, so its counts show what choosing a
+// different value would return; Total applies all of them.
+type ProductFacets struct {
+	Total       int64            `json:"total"`
+	Categories  []CategoryFacet  `json:"categories"`
+	Prices      []PriceBucket    `json:"prices"`
+	StockStatus []FacetValue     `json:"stock_status"`
+	Attributes  []AttributeFacet `json:"attributes"`
+}
+
+type FacetValue struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+type CategoryFacet struct {
+	ID    uuid.UUID `json:"id"`
+	Name  string    `json:"name"`
+	Count int64     `json:"count"`
+}
+
+// PriceBucket covers effective prices from Min to Max inclusive, so both can
+// be passed back as min_price and max_price. The last bucket has no Max.
+type PriceBucket struct {
+	Min   int64  `json:"min"`
+	Max   *int64 `json:"max"`
+	Count int64  `json:"count"`
+}
+
+type AttributeFacet struct {
+	Name   string       `json:"name"`
+	Values []FacetValue `json:"values"`
+}
+
+// PriceBucketBounds returns the lower bounds of about n buckets spanning min
+// to max, on a 1-2-5 step so the edges are round numbers.
+func PriceBucketBounds(min, max int64, n int) []int64 {
+
+	if n < 1 {
+		n = 1
+	}
+	if max <= min {
+		return []int64{min}
+	}
+
+	raw := float64(max-min) / float64(n)
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	step := int64(10 * magnitude)
+	for _, m := range []float64{1, 2, 5} {
+		if m*magnitude >= raw {
+			step = int64(m * magnitude)
+			break
+		}
+	}
+	if step < 1 {
+		step = 1
+	}
+
+	var bounds []int64
+	for b := min / step * step; b <= max; b += step {
+		bounds = append(bounds, b)
+	}
+	return bounds
+}
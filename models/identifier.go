@@ -0,0 +1,95 @@
+package models
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxSlugLength bounds product and category slugs.
+const MaxSlugLength = 120
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
+// Slugify turns a name into a URL slug of lowercase ASCII letters and digits
+// separated by single hyphens. Accents are dropped ("Café" becomes "cafe");
+// anything else separates words. The result may be empty.
+func Slugify(name string) string {
+
+	var b strings.Builder
+	pendingHyphen := false
+	for _, r := range norm.NFD.String(strings.ToLower(name)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			if pendingHyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			pendingHyphen = false
+			b.WriteRune(r)
+		default:
+			pendingHyphen = true
+		}
+	}
+
+	slug := b.String()
+	if len(slug) > MaxSlugLength {
+		slug = strings.TrimRight(slug[:MaxSlugLength], "-")
+	}
+	return slug
+}
+
+// ValidateSlug checks a slug supplied by a client.
+func ValidateSlug(slug string) error {
+	if slug == "" {
+		return errors.New("slug cannot be empty")
+	}
+	if len(slug) > MaxSlugLength {
+		return errors.New("slug must not exceed 120 characters")
+	}
+	if !slugPattern.MatchString(slug) {
+		return errors.New("slug must contain only lowercase letters, digits and single hyphens")
+	}
+	return nil
+}
+
+// ValidateBarcode accepts EAN-8, UPC-A (12 digits) and EAN-13 codes whose
+// last digit is the GS1 check digit of the rest.
+func ValidateBarcode(code string) error {
+
+	switch len(code) {
+	case 8, 12, 13:
+	default:
+		return errors.New("barcode must be an EAN-8, UPC-A or EAN-13 code")
+	}
+
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return errors.New("barcode must contain only digits")
+		}
+	}
+
+	if GTINCheckDigit(code[:len(code)-1]) != int(code[len(code)-1]-'0') {
+		return errors.New("barcode check digit is invalid")
+	}
+	return nil
+}
+
+// GTINCheckDigit computes the GS1 mod-10 check digit for digits, which holds
+// every digit of the code except the check digit itself.
+func GTINCheckDigit(digits string) int {
+
+	sum := 0
+	for i := 0; i < len(digits); i++ {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 0 {
+			d *= 3
+		}
+		sum += d
+	}
+	return (10 - sum%10) % 10
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MaxImagesPerProduct = 20
+	MaxAltTextLength    = 250
+)
+
+// ProductImage is an uploaded image, ordered by Position within its product.
+// URL is the original upload; Variants are the resized copies.
+type ProductImage struct {
+	ID          uuid.UUID      `json:"id"`
+	ProductID   uuid.UUID      `json:"product_id"`
+	Position    int            `json:"position"`
+	AltText     string         `json:"alt_text"`
+	ContentType string         `json:"content_type"`
+	Width       int            `json:"width"`
+	Height      int            `json:"height"`
+	SizeBytes   int64          `json:"size_bytes"`
+	StorageKey  string         `json:"-"`
+	URL         string         `json:"url"`
+	Variants    []ImageVariant `json:"variants"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+// ImageVariant is one size of an image in one format, e.g. thumb as webp.
+type ImageVariant struct {
+	Name        string `json:"name"`
+	Format      string `json:"format"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	SizeBytes   int64  `json:"size_bytes"`
+	StorageKey  string `json:"-"`
+	URL         string `json:"url"`
+}
+
+type UpdateProductImageRequest struct {
+	AltText *string `json:"alt_text,omitempty"`
+}
+
+// ReorderProductImagesRequest lists every image of the product in its new order.
+type ReorderProductImagesRequest struct {
+	ImageIDs []uuid.UUID `json:"image_ids"`
+}
+
+// ProductDeletedEvent is published once a product and its rows are gone.
+type ProductDeletedEvent struct {
+	ProductID uuid.UUID `json:"product_id"`
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LowStockItem is a product whose available stock is at or under its
+// low-stock threshold, with the quantity we suggest reordering.
+type LowStockItem struct {
+	Product
+	CategoryName string `json:"category_name"`
+	SuggestedQty int    `json:"suggested_qty"`
+}
+
+type LowStockGroup struct {
+	CategoryID   uuid.UUID      `json:"category_id"`
+	CategoryName string         `json:"category_name"`
+	Products     []LowStockItem `json:"products"`
+}
+
+const (
+	ValuationWeightedAverage = "weighted_average"
+	ValuationFIFO            = "fifo"
+)
+
+// ProductCostHistory is what the valuation report needs for one product in
+// stock: its stock-changing movements, oldest first, without the paired
+// entries of warehouse transfers.
+type ProductCostHistory struct {
+	ProductID    uuid.UUID
+	Name         string
+	CategoryID   uuid.UUID
+	CategoryName string
+	Stock        int
+	CostPrice    *int64
+	Movements    []CostedMovement
+}
+
+type CostedMovement struct {
+	Quantity int
+	UnitCost *int64
+}
+
+// ProductValuation values a product's stock. Uncosted products have neither a
+// cost price nor a costed receipt and are valued at zero.
+type ProductValuation struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Name      string    `json:"name"`
+	Units     int       `json:"units"`
+	UnitCost  int64     `json:"unit_cost"`
+	Value     int64     `json:"value"`
+	Uncosted  bool      `json:"uncosted"`
+}
+
+type CategoryValuation struct {
+	CategoryID    uuid.UUID          `json:"category_id"`
+	CategoryName  string             `json:"category_name"`
+	Units         int                `json:"units"`
+	Value         int64              `json:"value"`
+	UncostedUnits int                `json:"uncosted_units"`
+	Products      []ProductValuation `json:"products"`
+}
+
+type InventoryValuation struct {
+	Method        string              `json:"method"`
+	Currency      string              `json:"currency"`
+	Categories    []CategoryValuation `json:"categories"`
+	Units         int                 `json:"units"`
+	Value         int64               `json:"value"`
+	UncostedUnits int                 `json:"uncosted_units"`
+	GeneratedAt   time.Time           `json:"generated_at"`
+}
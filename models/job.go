@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	JobTypeImport = "import"
+	JobTypeExport = "export"
+
+	JobFormatCSV  = "csv"
+	JobFormatJSON = "json"
+
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusFailed    = "failed"
+)
+
+type Job struct {
+	ID           uuid.UUID  `json:"id"`
+	Type         string     `json:"type"`
+	Format       string     `json:"format"`
+	Status       string     `json:"status"`
+	InputPath    string     `json:"-"`
+	ArtifactPath string     `json:"-"`
+	Total        int        `json:"total"`
+	Processed    int        `json:"processed"`
+	Succeeded    int        `json:"succeeded"`
+	Failed       int        `json:"failed"`
+	Errors       []JobError `json:"errors"`
+	Attempts     int        `json:"attempts"`
+	DownloadURL  string     `json:"download_url,omitempty"`
+	StartedAt    *time.Time `json:"started_at,omitempty"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+type JobError struct {
+	Row     int    `json:"row,omitempty"`
+	Message string `json:"message"`
+}
+
+type CreateJobRequest struct {
+	Type   string `json:"type"`
+	Format string `json:"format"`
+}
+
+// ProductRecord is the flat shape used by product import and export files.
+// SKU, Barcode and Slug are optional on import.
+type ProductRecord struct {
+	ID         string `json:"id,omitempty"`
+	Name       string `json:"name"`
+	Price      int64  `json:"price"`
+	Stock      int    `json:"stock"`
+	CategoryID string `json:"category_id"`
+	SKU        string `json:"sku,omitempty"`
+	Barcode    string `json:"barcode,omitempty"`
+	Slug       string `json:"slug,omitempty"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "github.com/google/uuid"
+
+const (
+	MaxLabelCopies  = 100
+	MaxLabelsPerJob = 500
+	// A4 sheet of 63.5 x 38.1 mm labels
+	LabelColumns = 3
+	LabelRows    = 7
+)
+
+// LabelSheetRequest asks for a printable PDF of shelf labels, one per product
+// id in the given order, each repeated Copies times. Currency, PriceList and
+// Tax pick the price printed, as on product reads.
+type LabelSheetRequest struct {
+	ProductIDs []uuid.UUID `json:"product_ids"`
+	Copies     int         `json:"copies"`
+	Currency   string      `json:"currency"`
+	PriceList  string      `json:"price_list"`
+	Tax        string      `json:"tax"`
+}
+
+// BarcodeImage is a rendered product barcode. Symbology is "ean13", "ean8"
+// or "code128"; Content is what the bars encode.
+type BarcodeImage struct {
+	Symbology string
+	Content   string
+	Data      []byte
+}
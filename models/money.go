@@ -0,0 +1,125 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Money is an amount in the minor unit of its currency, e.g. cents for USD.
+// How many minor units make up one major unit follows ISO 4217, except for
+// the base currency, whose stored unit is configured at startup.
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// currencyExponents maps ISO 4217 codes to their number of minor-unit digits.
+var currencyExponents = map[string]int{
+	"AUD": 2, "BHD": 3, "CAD": 2, "CHF": 2, "CLP": 0, "CNY": 2, "EUR": 2,
+	"GBP": 2, "HKD": 2, "IDR": 2, "INR": 2, "ISK": 0, "JOD": 3, "JPY": 0,
+	"KRW": 0, "KWD": 3, "MYR": 2, "NZD": 2, "OMR": 3, "PHP": 2, "SAR": 2,
+	"SGD": 2, "THB": 2, "TND": 3, "TWD": 2, "USD": 2, "VND": 0,
+}
+
+// NormalizeCurrency upper-cases code and checks it is a supported ISO 4217 code.
+func NormalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if _, ok := currencyExponents[code]; !ok {
+		return "", fmt.Errorf("invalid currency code %q", code)
+	}
+	return code, nil
+}
+
+// SetCurrencyExponent overrides the number of minor-unit digits for currency.
+// It is meant to be called once at startup, before any amount is handled.
+func SetCurrencyExponent(currency string, exponent int) {
+	currencyExponents[currency] = exponent
+}
+
+// CurrencyExponent returns the number of minor-unit digits for currency.
+func CurrencyExponent(currency string) int {
+	return currencyExponents[currency]
+}
+
+// String formats m in major units, e.g. "USD 12.50" or "JPY 1200".
+func (m Money) String() string {
+	exp := CurrencyExponent(m.Currency)
+	if exp == 0 {
+		return fmt.Sprintf("%s %d", m.Currency, m.Amount)
+	}
+
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	scale := int64(1)
+	for i := 0; i < exp; i++ {
+		scale *= 10
+	}
+	return fmt.Sprintf("%s %s%d.%0*d", m.Currency, sign, amount/scale, exp, amount%scale)
+}
+
+// Convert converts m into currency to, where rate is the price of one major
+// unit of m.Currency in major units of to. The result is rounded half away
+// from zero to the target's minor unit.
+func (m Money) Convert(to string, rate *big.Rat) (Money, error) {
+
+	if m.Currency == to {
+		return m, nil
+	}
+
+	if rate == nil || rate.Sign() <= 0 {
+		return Money{}, errors.New("exchange rate must be positive")
+	}
+
+	value := new(big.Rat).SetInt64(m.Amount)
+	value.Mul(value, rate)
+
+	shift := CurrencyExponent(to) - CurrencyExponent(m.Currency)
+	pow := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(shift))), nil))
+	if shift >= 0 {
+		value.Mul(value, pow)
+	} else {
+		value.Quo(value, pow)
+	}
+
+	amount, err := roundHalfAwayFromZero(value)
+	if err != nil {
+		return Money{}, err
+	}
+
+	return Money{Amount: amount, Currency: to}, nil
+}
+
+// roundHalfAwayFromZero rounds r to the nearest integer, ties away from zero.
+func roundHalfAwayFromZero(r *big.Rat) (int64, error) {
+
+	num := new(big.Int).Abs(r.Num())
+	den := r.Denom()
+
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if new(big.Int).Mul(rem, big.NewInt(2)).Cmp(den) >= 0 {
+		quo.Add(quo, big.NewInt(1))
+	}
+
+	if r.Sign() < 0 {
+		quo.Neg(quo)
+	}
+
+	if !quo.IsInt64() {
+		return 0, errors.New("amount is out of range")
+	}
+	return quo.Int64(), nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PriceChange is one entry in a product's price history. Price is the
+// effective price and ListPrice the stored one, nil on entries older than
+// the column. PreviousPrice is nil for the first price a product was created
+// with.
+type PriceChange struct {
+	ID            uuid.UUID `json:"id"`
+	ProductID     uuid.UUID `json:"product_id"`
+	Price         int64     `json:"price"`
+	PreviousPrice *int64    `json:"previous_price"`
+	ListPrice     *int64    `json:"list_price"`
+	Reason        string    `json:"reason"`
+	Actor         string    `json:"actor"`
+	EffectiveAt   time.Time `json:"effective_at"`
+}
+
+// PriceSchedule overrides a product's list price between StartsAt and EndsAt.
+// An open-ended schedule (no EndsAt) acts as a price change taking effect at
+// StartsAt. When windows overlap the highest Priority wins.
+type PriceSchedule struct {
+	ID        uuid.UUID  `json:"id"`
+	ProductID uuid.UUID  `json:"product_id"`
+	Price     int64      `json:"price"`
+	StartsAt  time.Time  `json:"starts_at"`
+	EndsAt    *time.Time `json:"ends_at"`
+	Priority  int        `json:"priority"`
+	Label     string     `json:"label"`
+	Active    bool       `json:"active"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type CreatePriceScheduleRequest struct {
+	Price    int64      `json:"price"`
+	StartsAt time.Time  `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at,omitempty"`
+	Priority int        `json:"priority"`
+	Label    string     `json:"label"`
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PriceList holds explicit per-product prices in its own currency, e.g. an
+// IDR retail list or a USD export list.
+type PriceList struct {
+	ID        uuid.UUID `json:"id"`
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	Currency  string    `json:"currency"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CreatePriceListRequest struct {
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	Currency string `json:"currency"`
+}
+
+type UpdatePriceListRequest struct {
+	Name *string `json:"name,omitempty"`
+}
+
+type PriceListItem struct {
+	PriceListID uuid.UUID `json:"price_list_id"`
+	ProductID   uuid.UUID `json:"product_id"`
+	Price       Money     `json:"price"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// SetPriceListItemsRequest upserts item prices, given in the list's minor unit.
+type SetPriceListItemsRequest struct {
+	Items []PriceListItemInput `json:"items"`
+}
+
+type PriceListItemInput struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Price     int64     `json:"price"`
+}
+
+// ExchangeRate is the price of one major unit of Base in major units of Quote.
+type ExchangeRate struct {
+	Base      string    `json:"base"`
+	Quote     string    `json:"quote"`
+	Rate      string    `json:"rate"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type SetExchangeRateRequest struct {
+	Base  string      `json:"base"`
+	Quote string      `json:"quote"`
+	Rate  json.Number `json:"rate"`
+}
+
+// PriceContext selects how prices are presented on product reads. Empty
+// fields keep the stored prices in the base currency. Tax is TaxInclusive or
+// TaxExclusive.
+type PriceContext struct {
+	Currency  string
+	PriceList string
+	Tax       string
+}
@@ -6,32 +6,175 @@ import (
 	"github.com/google/uuid"
 )
 
+// Product carries the effective Price, resolved from any open price schedule,
+// next to the stored ListPrice. CompareAtPrice is set while a schedule sells
+// below the list price. LowestPrice30d is nil until the product has had an
+// earlier price. Amounts are in the minor unit of Currency, which is
+// the base currency unless a read asked for another one.
+//
+// CostPrice, MarginBP and MarkupBP are only filled on admin reads. The cost
+// stays in the base currency; margin is profit over price and markup profit
+// over cost, both in basis points of the base-currency price.
+//
+// ShortDescription and Description hold markdown as written. The HTML fields
+// carry the sanitized rendering and are only filled on ?render=html reads.
+// Attributes holds values for the custom attributes of the category.
 type Product struct {
-	ID         uuid.UUID `json:"id"`
-	Name       string    `json:"name"`
-	Price      int64     `json:"price"`
-	Stock      int       `json:"stock"`
-	CategoryID uuid.UUID `json:"category_id"`
-	Category   *Category `json:"category,omitempty"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID                   uuid.UUID      `json:"id"`
+	Name                 string         `json:"name"`
+	SKU                  *string        `json:"sku"`
+	Barcode              *string        `json:"barcode"`
+	Slug                 string         `json:"slug"`
+	ShortDescription     string         `json:"short_description"`
+	Description          string         `json:"description"`
+	ShortDescriptionHTML string         `json:"short_description_html,omitempty"`
+	DescriptionHTML      string         `json:"description_html,omitempty"`
+	Price                int64          `json:"price"`
+	CompareAtPrice       *int64         `json:"compare_at_price"`
+	ListPrice            int64          `json:"list_price"`
+	LowestPrice30d       *int64         `json:"lowest_price_30d"`
+	Currency             string         `json:"currency"`
+	Stock                int            `json:"stock"`
+	Reserved             int            `json:"reserved"`
+	Available            int            `json:"available"`
+	WarehouseStock       *int           `json:"warehouse_stock,omitempty"`
+	LowStockThreshold    *int           `json:"low_stock_threshold"`
+	ReorderQty           int            `json:"reorder_qty"`
+	TaxClassID           *uuid.UUID     `json:"tax_class_id"`
+	Tax                  *TaxBreakdown  `json:"tax,omitempty"`
+	CostPrice            *int64         `json:"cost_price,omitempty"`
+	MarginBP             *int           `json:"margin_bp,omitempty"`
+	MarkupBP             *int           `json:"markup_bp,omitempty"`
+	CategoryID           uuid.UUID      `json:"category_id"`
+	Category             *Category      `json:"category,omitempty"`
+	Attributes           map[string]any `json:"attributes"`
+	Tags                 []string       `json:"tags,omitempty"`
+	Images               []ProductImage `json:"images,omitempty"`
+	CreatedAt            time.Time      `json:"created_at"`
+	UpdatedAt            time.Time      `json:"updated_at"`
 }
 
+// CreateProductRequest derives the slug from the name when none is given.
 type CreateProductRequest struct {
-	Name       string    `json:"name" binding:"required"`
-	Price      int64     `json:"price" binding:"required,min=0"`
-	Stock      int       `json:"stock" binding:"min=0"`
-	CategoryID uuid.UUID `json:"category_id" binding:"required"`
+	Name              string     `json:"name" binding:"required"`
+	SKU               *string    `json:"sku,omitempty"`
+	Barcode           *string    `json:"barcode,omitempty"`
+	Slug              string     `json:"slug"`
+	ShortDescription  string     `json:"short_description"`
+	Description       string     `json:"description"`
+	Price             int64      `json:"price" binding:"required,min=0"`
+	Stock             int        `json:"stock" binding:"min=0"`
+	CategoryID        uuid.UUID  `json:"category_id" binding:"required"`
+	LowStockThreshold *int       `json:"low_stock_threshold,omitempty"`
+	ReorderQty        int        `json:"reorder_qty"`
+	TaxClassID        *uuid.UUID `json:"tax_class_id,omitempty"`
+	CostPrice         *int64     `json:"cost_price,omitempty"`
+	// values for the category's attribute schema
+	Attributes map[string]any `json:"attributes,omitempty"`
 }
 
 type UpdateProductRequest struct {
-	Name       *string    `json:"name,omitempty"`
-	Price      *int64     `json:"price,omitempty" binding:"min=0"`
-	Stock      *int       `json:"stock,omitempty" binding:"min=0"`
-	CategoryID *uuid.UUID `json:"category_id,omitempty"`
+	Name *string `json:"name,omitempty"`
+	// an empty sku or barcode clears it
+	SKU     *string `json:"sku,omitempty"`
+	Barcode *string `json:"barcode,omitempty"`
+	// the previous slug keeps resolving as a redirect
+	Slug             *string    `json:"slug,omitempty"`
+	ShortDescription *string    `json:"short_description,omitempty"`
+	Description      *string    `json:"description,omitempty"`
+	Price            *int64     `json:"price,omitempty" binding:"min=0"`
+	Stock            *int       `json:"stock,omitempty" binding:"min=0"`
+	CategoryID       *uuid.UUID `json:"category_id,omitempty"`
+	// a negative threshold clears it
+	LowStockThreshold *int `json:"low_stock_threshold,omitempty"`
+	ReorderQty        *int `json:"reorder_qty,omitempty"`
+	// send the nil UUID to inherit the tax class from the category again
+	TaxClassID *uuid.UUID `json:"tax_class_id,omitempty"`
+	// a negative cost price clears it
+	CostPrice *int64 `json:"cost_price,omitempty"`
+	// replaces all attribute values; a null value removes one
+	Attributes map[string]any `json:"attributes,omitempty"`
+	Actor      string         `json:"actor,omitempty"`
+}
+
+// SetCostMetrics fills MarginBP and MarkupBP from Price and CostPrice. Either
+// stays nil when its divisor is zero.
+func (p *Product) SetCostMetrics() {
+
+	p.MarginBP, p.MarkupBP = nil, nil
+	if p.CostPrice == nil {
+		return
+	}
+
+	profit := p.Price - *p.CostPrice
+	if p.Price > 0 {
+		margin := int(divRound(profit*10000, p.Price))
+		p.MarginBP = &margin
+	}
+	if *p.CostPrice > 0 {
+		markup := int(divRound(profit*10000, *p.CostPrice))
+		p.MarkupBP = &markup
+	}
+}
+
+// Description limits, in characters of markdown source.
+const (
+	MaxShortDescriptionLength = 500
+	MaxDescriptionLength      = 20000
+)
+
+// HideCost clears the admin-only cost fields.
+func (p *Product) HideCost() {
+	p.CostPrice, p.MarginBP, p.MarkupBP = nil, nil, nil
 }
 
 type ProductWithCategory struct {
 	Product
-	CategoryName string `json:"category_name"`
+	CategoryName string           `json:"category_name"`
+	Options      []ProductOption  `json:"options,omitempty"`
+	Variants     []ProductVariant `json:"variants,omitempty"`
+	// keyed by relation type, on ?include=relations reads
+	Relations map[string][]RelatedProduct `json:"relations,omitempty"`
+}
+
+// ProductLookup is the result of resolving a SKU, barcode or slug. VariantID
+// is set when a variant's SKU or barcode matched; Redirected when an old slug
+// did, in which case Slug is the product's current one.
+type ProductLookup struct {
+	ProductID  uuid.UUID
+	VariantID  *uuid.UUID
+	Slug       string
+	Redirected bool
+}
+
+// ProductFilter narrows product listings. Zero values mean no filter. A
+// Query searches names, identifiers and descriptions and orders the matches
+// by relevance. The price bounds are inclusive and apply to the effective
+// price in the base currency. Tags match any of the names, or all of them
+// with TagsMode all.
+type ProductFilter struct {
+	IDs                []uuid.UUID
+	Query              string
+	Attributes         []AttributeFilter
+	CategoryID         *uuid.UUID
+	IncludeDescendants bool
+	WarehouseID        *uuid.UUID
+	MinPrice           *int64
+	MaxPrice           *int64
+	StockStatus        string
+	Tags               []string
+	TagsMode           string
+	// selects cost_price, which only admin reads may see
+	IncludeCost bool
+}
+
+// StockThresholdEvent is published when a product's available stock crosses
+// its low-stock threshold in either direction.
+type StockThresholdEvent struct {
+	ProductID         uuid.UUID `json:"product_id"`
+	Name              string    `json:"name"`
+	PreviousAvailable int       `json:"previous_available"`
+	Available         int       `json:"available"`
+	LowStockThreshold int       `json:"low_stock_threshold"`
+	ReorderQty        int       `json:"reorder_qty"`
 }
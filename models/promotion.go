@@ -0,0 +1,171 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	PromotionPercent  = "percent"
+	PromotionFixed    = "fixed"
+	PromotionBuyXGetY = "buy_x_get_y"
+	PromotionBundle   = "bundle"
+)
+
+// what a promotion covers
+const (
+	PromotionScopeCatalog = "catalog"
+	PromotionScopeTargets = "targets"
+)
+
+// Promotion is a discount on the whole catalog, or with the targets Scope on
+// the products or categories it targets. A targeted promotion that loses its
+// last target, because the product or category was deleted, is deactivated.
+// Which amount fields are set depends on Type:
+//
+//   - percent: DiscountBP off every eligible unit
+//   - fixed: Amount off every eligible unit
+//   - buy_x_get_y: for every BuyQty units, GetQty more at DiscountBP off
+//     (10000 makes them free); the cheapest units are discounted
+//   - bundle: every BundleQty eligible units cost BundlePrice together
+//
+// A promotion with a Code is a coupon and only applies when it is presented.
+// Promotions apply in descending Priority, each to what is left of the line
+// after earlier ones. A promotion that is not Stackable skips lines already
+// discounted and keeps later promotions off the lines it discounts.
+type Promotion struct {
+	ID          uuid.UUID   `json:"id"`
+	Name        string      `json:"name"`
+	Code        *string     `json:"code"`
+	Type        string      `json:"type"`
+	Scope       string      `json:"scope"`
+	DiscountBP  *int        `json:"discount_bp"`
+	Amount      *int64      `json:"amount"`
+	BuyQty      *int        `json:"buy_qty"`
+	GetQty      *int        `json:"get_qty"`
+	BundleQty   *int        `json:"bundle_qty"`
+	BundlePrice *int64      `json:"bundle_price"`
+	ProductIDs  []uuid.UUID `json:"product_ids"`
+	CategoryIDs []uuid.UUID `json:"category_ids"`
+	StartsAt    *time.Time  `json:"starts_at"`
+	EndsAt      *time.Time  `json:"ends_at"`
+	UsageLimit  *int        `json:"usage_limit"`
+	UsageCount  int         `json:"usage_count"`
+	Priority    int         `json:"priority"`
+	Stackable   bool        `json:"stackable"`
+	Active      bool        `json:"active"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+// RunningAt reports whether the promotion's date window covers t.
+func (p Promotion) RunningAt(t time.Time) bool {
+	if p.StartsAt != nil && t.Before(*p.StartsAt) {
+		return false
+	}
+	if p.EndsAt != nil && !t.Before(*p.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// Exhausted reports whether the promotion has reached its usage limit.
+func (p Promotion) Exhausted() bool {
+	return p.UsageLimit != nil && p.UsageCount >= *p.UsageLimit
+}
+
+type CreatePromotionRequest struct {
+	Name        string      `json:"name"`
+	Code        string      `json:"code"`
+	Type        string      `json:"type"`
+	Scope       string      `json:"scope"`
+	DiscountBP  *int        `json:"discount_bp,omitempty"`
+	Amount      *int64      `json:"amount,omitempty"`
+	BuyQty      *int        `json:"buy_qty,omitempty"`
+	GetQty      *int        `json:"get_qty,omitempty"`
+	BundleQty   *int        `json:"bundle_qty,omitempty"`
+	BundlePrice *int64      `json:"bundle_price,omitempty"`
+	ProductIDs  []uuid.UUID `json:"product_ids"`
+	CategoryIDs []uuid.UUID `json:"category_ids"`
+	StartsAt    *time.Time  `json:"starts_at,omitempty"`
+	EndsAt      *time.Time  `json:"ends_at,omitempty"`
+	UsageLimit  *int        `json:"usage_limit,omitempty"`
+	Priority    int         `json:"priority"`
+	Stackable   *bool       `json:"stackable,omitempty"`
+	Active      *bool       `json:"active,omitempty"`
+}
+
+// UpdatePromotionRequest changes only the fields that are set. An empty Code
+// turns a coupon into an automatic promotion and a UsageLimit of 0 removes
+// the limit. The type cannot change.
+type UpdatePromotionRequest struct {
+	Name        *string      `json:"name,omitempty"`
+	Code        *string      `json:"code,omitempty"`
+	Scope       *string      `json:"scope,omitempty"`
+	DiscountBP  *int         `json:"discount_bp,omitempty"`
+	Amount      *int64       `json:"amount,omitempty"`
+	BuyQty      *int         `json:"buy_qty,omitempty"`
+	GetQty      *int         `json:"get_qty,omitempty"`
+	BundleQty   *int         `json:"bundle_qty,omitempty"`
+	BundlePrice *int64       `json:"bundle_price,omitempty"`
+	ProductIDs  *[]uuid.UUID `json:"product_ids,omitempty"`
+	CategoryIDs *[]uuid.UUID `json:"category_ids,omitempty"`
+	StartsAt    *time.Time   `json:"starts_at,omitempty"`
+	EndsAt      *time.Time   `json:"ends_at,omitempty"`
+	UsageLimit  *int         `json:"usage_limit,omitempty"`
+	Priority    *int         `json:"priority,omitempty"`
+	Stackable   *bool        `json:"stackable,omitempty"`
+	Active      *bool        `json:"active,omitempty"`
+}
+
+// EvaluateCartRequest prices a cart against the running promotions plus any
+// presented coupons. With Redeem set, the usage of every applied promotion is
+// counted against its limit.
+type EvaluateCartRequest struct {
+	Items       []CartItem `json:"items"`
+	CouponCodes []string   `json:"coupon_codes"`
+	Redeem      bool       `json:"redeem"`
+}
+
+type CartItem struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Quantity  int       `json:"quantity"`
+}
+
+// AppliedDiscount is what one promotion took off a line, or off the whole
+// cart in CartEvaluation.Applied.
+type AppliedDiscount struct {
+	PromotionID uuid.UUID `json:"promotion_id"`
+	Name        string    `json:"name"`
+	Code        *string   `json:"code"`
+	Type        string    `json:"type"`
+	Amount      int64     `json:"amount"`
+}
+
+type CartLine struct {
+	ProductID     uuid.UUID         `json:"product_id"`
+	Name          string            `json:"name"`
+	Quantity      int               `json:"quantity"`
+	UnitPrice     int64             `json:"unit_price"`
+	Subtotal      int64             `json:"subtotal"`
+	Discounts     []AppliedDiscount `json:"discounts"`
+	DiscountTotal int64             `json:"discount_total"`
+	Total         int64             `json:"total"`
+}
+
+type RejectedCoupon struct {
+	Code   string `json:"code"`
+	Reason string `json:"reason"`
+}
+
+type CartEvaluation struct {
+	Currency        string            `json:"currency"`
+	Lines           []CartLine        `json:"lines"`
+	Applied         []AppliedDiscount `json:"applied"`
+	RejectedCoupons []RejectedCoupon  `json:"rejected_coupons"`
+	Subtotal        int64             `json:"subtotal"`
+	DiscountTotal   int64             `json:"discount_total"`
+	Total           int64             `json:"total"`
+	Redeemed        bool              `json:"redeemed"`
+}
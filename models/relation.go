@@ -0,0 +1,51 @@
+package models
+
+import "github.com/google/uuid"
+
+// relation types; related and cross_sell hold both ways, the others point
+// from the product to the one it suggests
+const (
+	RelationRelated     = "related"
+	RelationCrossSell   = "cross_sell"
+	RelationUpsell      = "upsell"
+	RelationAccessory   = "accessory"
+	RelationReplacement = "replacement"
+)
+
+// RelationTypes lists the relation types in display order.
+var RelationTypes = []string{RelationRelated, RelationCrossSell, RelationUpsell, RelationAccessory, RelationReplacement}
+
+// MaxRelationsPerType caps how many products one product lists per type.
+const MaxRelationsPerType = 50
+
+func ValidRelationType(t string) bool {
+	for _, known := range RelationTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// SymmetricRelation reports whether relating A to B also relates B to A.
+func SymmetricRelation(t string) bool {
+	return t == RelationRelated || t == RelationCrossSell
+}
+
+// RelatedProduct is a product as it appears in another product's relations.
+// Price is the effective price, in the minor unit of Currency.
+type RelatedProduct struct {
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	Slug     string    `json:"slug"`
+	SKU      *string   `json:"sku"`
+	Price    int64     `json:"price"`
+	Currency string    `json:"currency"`
+	Position int       `json:"position"`
+}
+
+// SetRelationsRequest replaces a product's relations of one type; the order
+// of ProductIDs is the display order.
+type SetRelationsRequest struct {
+	ProductIDs []uuid.UUID `json:"product_ids"`
+}
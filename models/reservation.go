@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ReservationActive    = "active"
+	ReservationConfirmed = "confirmed"
+	ReservationReleased  = "released"
+	ReservationExpired   = "expired"
+
+	DefaultReservationTTL = 15 * time.Minute
+	MaxReservationTTL     = 24 * time.Hour
+)
+
+type Reservation struct {
+	ID        uuid.UUID         `json:"id"`
+	Status    string            `json:"status"`
+	Reference string            `json:"reference"`
+	Lines     []ReservationLine `json:"lines"`
+	ExpiresAt time.Time         `json:"expires_at"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// ReservationLine holds Quantity units of a product, or of one of its
+// variants; a product with variants can only be held per variant.
+type ReservationLine struct {
+	ProductID uuid.UUID  `json:"product_id"`
+	VariantID *uuid.UUID `json:"variant_id,omitempty"`
+	Quantity  int        `json:"quantity"`
+}
+
+type CreateReservationRequest struct {
+	Lines      []ReservationLine `json:"lines"`
+	TTLSeconds int               `json:"ttl_seconds"`
+	Reference  string            `json:"reference"`
+}
+
+type ConfirmReservationRequest struct {
+	Actor string `json:"actor"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MovementReceipt    = "receipt"
+	MovementSale       = "sale"
+	MovementAdjustment = "adjustment"
+	MovementReturn     = "return"
+	MovementTransfer   = "transfer"
+)
+
+// StockMovement is one ledger entry. Quantity is the signed change and
+// BalanceAfter the product's total stock, across warehouses, once it was applied.
+// UnitCost is what each incoming unit cost, when it was recorded.
+type StockMovement struct {
+	ID           uuid.UUID  `json:"id"`
+	ProductID    uuid.UUID  `json:"product_id"`
+	WarehouseID  *uuid.UUID `json:"warehouse_id"`
+	TransferID   *uuid.UUID `json:"transfer_id,omitempty"`
+	Type         string     `json:"type"`
+	Quantity     int        `json:"quantity"`
+	BalanceAfter int        `json:"balance_after"`
+	UnitCost     *int64     `json:"unit_cost"`
+	Reason       string     `json:"reason"`
+	Reference    string     `json:"reference"`
+	Actor        string     `json:"actor"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// AdjustStockRequest applies to the default warehouse unless WarehouseID is set.
+// UnitCost may only be given on incoming stock.
+type AdjustStockRequest struct {
+	WarehouseID *uuid.UUID `json:"warehouse_id,omitempty"`
+	Type        string     `json:"type"`
+	Quantity    int        `json:"quantity"`
+	UnitCost    *int64     `json:"unit_cost,omitempty"`
+	Reason      string     `json:"reason"`
+	Reference   string     `json:"reference"`
+	Actor       string     `json:"actor"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MaxTagLength      = 50
+	MaxTagsPerProduct = 50
+)
+
+// how a listing's tags filter combines its tags
+const (
+	TagsModeAny = "any"
+	TagsModeAll = "all"
+)
+
+type Tag struct {
+	ID           uuid.UUID `json:"id"`
+	Name         string    `json:"name"`
+	ProductCount int64     `json:"product_count"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type CreateTagRequest struct {
+	Name string `json:"name"`
+}
+
+// UpdateTagRequest renames a tag. Renaming onto another tag's name is
+// refused; merge the two instead.
+type UpdateTagRequest struct {
+	Name string `json:"name"`
+}
+
+type MergeTagRequest struct {
+	TargetID uuid.UUID `json:"target_id"`
+}
+
+type MergeTagResult struct {
+	SourceID      uuid.UUID `json:"source_id"`
+	TargetID      uuid.UUID `json:"target_id"`
+	ProductsMoved int64     `json:"products_moved"`
+}
+
+// ProductTagsRequest names tags to add to a product; unknown ones are
+// created.
+type ProductTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// NormalizeTag trims the name, lower-cases it and collapses inner runs of
+// whitespace, so "Summer  Sale" and "summer sale" are the same tag.
+func NormalizeTag(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// how prices are presented on product reads
+const (
+	TaxInclusive = "incl"
+	TaxExclusive = "excl"
+)
+
+type TaxClass struct {
+	ID          uuid.UUID `json:"id"`
+	Code        string    `json:"code"`
+	Name        string    `json:"name"`
+	CurrentRate *TaxRate  `json:"current_rate"`
+	Rates       []TaxRate `json:"rates,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TaxRate applies from EffectiveFrom until the next rate of the same class.
+// RateBP is in basis points, so 1100 is 11%.
+type TaxRate struct {
+	ID            uuid.UUID `json:"id"`
+	TaxClassID    uuid.UUID `json:"tax_class_id"`
+	RateBP        int       `json:"rate_bp"`
+	EffectiveFrom time.Time `json:"effective_from"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type CreateTaxClassRequest struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+	// optional opening rate, effective immediately
+	RateBP *int `json:"rate_bp,omitempty"`
+}
+
+type UpdateTaxClassRequest struct {
+	Name *string `json:"name,omitempty"`
+}
+
+type CreateTaxRateRequest struct {
+	RateBP        int        `json:"rate_bp"`
+	EffectiveFrom *time.Time `json:"effective_from,omitempty"`
+}
+
+// ProductTax is the tax class and rate in effect for a product, either its
+// own or inherited from the nearest category that has one.
+type ProductTax struct {
+	TaxClassCode string
+	RateBP       int
+}
+
+// TaxBreakdown splits a product's price into net, tax and gross amounts in
+// the minor unit of the product's currency.
+type TaxBreakdown struct {
+	Mode     string `json:"mode"`
+	TaxClass string `json:"tax_class"`
+	RateBP   int    `json:"rate_bp"`
+	Net      int64  `json:"net"`
+	Tax      int64  `json:"tax"`
+	Gross    int64  `json:"gross"`
+}
+
+// SplitTax works out net, tax and gross for amount at rateBP. When inclusive
+// is true amount is gross, otherwise it is net. Tax is rounded half away from
+// zero on the minor unit, and net + tax always equals gross.
+func SplitTax(amount int64, rateBP int, inclusive bool) (net, tax, gross int64) {
+
+	bp := int64(rateBP)
+	if inclusive {
+		gross = amount
+		net = divRound(gross*10000, 10000+bp)
+		tax = gross - net
+		return net, tax, gross
+	}
+
+	net = amount
+	tax = divRound(net*bp, 10000)
+	gross = net + tax
+	return net, tax, gross
+}
+
+// BasisPointsOf returns bp basis points of amount, rounded half away from zero.
+func BasisPointsOf(amount int64, bp int) int64 {
+	return divRound(amount*int64(bp), 10000)
+}
+
+// divRound divides a by a positive b, rounding half away from zero.
+func divRound(a, b int64) int64 {
+	if a < 0 {
+		return -divRound(-a, b)
+	}
+	return (a + b/2) / b
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxVariantCombinations caps how many variants a single option matrix may produce.
+const MaxVariantCombinations = 500
+
+type ProductOption struct {
+	ID        uuid.UUID `json:"id"`
+	ProductID uuid.UUID `json:"product_id"`
+	Name      string    `json:"name"`
+	Values    []string  `json:"values"`
+	Position  int       `json:"position"`
+}
+
+type ProductVariant struct {
+	ID            uuid.UUID         `json:"id"`
+	ProductID     uuid.UUID         `json:"product_id"`
+	SKU           *string           `json:"sku"`
+	Barcode       *string           `json:"barcode"`
+	PriceOverride *int64            `json:"price_override"`
+	Price         int64             `json:"price"`
+	Stock         int               `json:"stock"`
+	Options       map[string]string `json:"options"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+// OptionKey returns a stable key for an option combination, e.g. "Color=Red|Size=S".
+func OptionKey(options map[string]string) string {
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, name+"="+options[name])
+	}
+	return strings.Join(parts, "|")
+}
+
+type SetOptionsRequest struct {
+	Options []OptionInput `json:"options"`
+}
+
+type OptionInput struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+type CreateVariantRequest struct {
+	SKU           *string           `json:"sku,omitempty"`
+	Barcode       *string           `json:"barcode,omitempty"`
+	PriceOverride *int64            `json:"price_override,omitempty"`
+	Stock         int               `json:"stock"`
+	Options       map[string]string `json:"options"`
+}
+
+type UpdateVariantRequest struct {
+	SKU           *string `json:"sku,omitempty"`
+	Barcode       *string `json:"barcode,omitempty"`
+	PriceOverride *int64  `json:"price_override,omitempty"`
+	ClearPrice    bool    `json:"clear_price_override,omitempty"`
+	Stock         *int    `json:"stock,omitempty"`
+}
+
+// GenerateVariantsRequest fills in every missing option combination. SKUs are
+// built as SKUPrefix-VALUE-VALUE when a prefix is given.
+type GenerateVariantsRequest struct {
+	SKUPrefix     string `json:"sku_prefix"`
+	PriceOverride *int64 `json:"price_override,omitempty"`
+	Stock         int    `json:"stock"`
+}
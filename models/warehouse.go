@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Warehouse struct {
+	ID        uuid.UUID `json:"id"`
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	Address   string    `json:"address"`
+	IsDefault bool      `json:"is_default"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CreateWarehouseRequest struct {
+	Code      string `json:"code"`
+	Name      string `json:"name"`
+	Address   string `json:"address"`
+	IsDefault bool   `json:"is_default"`
+}
+
+type UpdateWarehouseRequest struct {
+	Name      *string `json:"name,omitempty"`
+	Address   *string `json:"address,omitempty"`
+	IsDefault *bool   `json:"is_default,omitempty"`
+}
+
+// StockLevel is the quantity of one product held in one warehouse.
+type StockLevel struct {
+	ProductID     uuid.UUID `json:"product_id"`
+	ProductName   string    `json:"product_name,omitempty"`
+	WarehouseID   uuid.UUID `json:"warehouse_id"`
+	WarehouseCode string    `json:"warehouse_code,omitempty"`
+	Quantity      int       `json:"quantity"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type TransferStockRequest struct {
+	ProductID       uuid.UUID `json:"product_id"`
+	FromWarehouseID uuid.UUID `json:"from_warehouse_id"`
+	ToWarehouseID   uuid.UUID `json:"to_warehouse_id"`
+	Quantity        int       `json:"quantity"`
+	Reason          string    `json:"reason"`
+	Reference       string    `json:"reference"`
+	Actor           string    `json:"actor"`
+}
+
+// Transfer groups the two ledger entries written for one transfer.
+type Transfer struct {
+	ID       uuid.UUID     `json:"id"`
+	Outgoing StockMovement `json:"outgoing"`
+	Incoming StockMovement `json:"incoming"`
+	Levels   []StockLevel  `json:"levels"`
+}
@@ -2,7 +2,10 @@ package repositories
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/anggakrnwn/product-catalog-api/models"
@@ -14,8 +17,19 @@ type CategoryRepository interface {
 	GetByID(id uuid.UUID) (*models.Category, error)
 	Create(category *models.Category) error
 	Update(id uuid.UUID, category *models.Category) error
-	Delete(id uuid.UUID) error
+	Delete(id uuid.UUID, policy string, targetID *uuid.UUID) (int64, []uuid.UUID, error)
 	FindByName(name string) (*models.Category, error)
+	GetChildren(id uuid.UUID) ([]models.Category, error)
+	GetAncestors(id uuid.UUID) ([]models.Category, error)
+	GetDescendants(id uuid.UUID) ([]models.Category, error)
+	Merge(sourceID, targetID uuid.UUID) (*models.MergeCategoryResult, error)
+	FindRedirect(id uuid.UUID) (*uuid.UUID, error)
+	FindBySlug(slug string) (*models.Category, bool, error)
+	SlugTaken(slug string) (bool, error)
+	GetAllWithStats() ([]models.Category, error)
+	GetStats(id uuid.UUID) (*models.CategoryStats, error)
+	GetAttributes(categoryID uuid.UUID) ([]models.CategoryAttribute, error)
+	SetAttributes(categoryID uuid.UUID, attributes []models.CategoryAttribute) error
 }
 
 type categoryRepository struct {
@@ -28,36 +42,16 @@ func NewCategoryRepository(db *sql.DB) CategoryRepository {
 
 func (r *categoryRepository) GetAll() ([]models.Category, error) {
 
-	query := "SELECT id, name, description, created_at, updated_at FROM categories ORDER BY name"
-	rows, err := r.db.Query(query)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var categories []models.Category
-	for rows.Next() {
-		var c models.Category
-		err := rows.Scan(&c.ID, &c.Name, &c.Description, &c.CreatedAt, &c.UpdatedAt)
-		if err != nil {
-			return nil, err
-		}
-		categories = append(categories, c)
-	}
-
-	if err = rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return categories, nil
+	query := "SELECT " + categoryColumns + " FROM categories c ORDER BY c.name"
+	return r.queryCategories(query)
 }
 
 func (r *categoryRepository) GetByID(id uuid.UUID) (*models.Category, error) {
 
-	query := "SELECT id, name, description, created_at, updated_at FROM categories WHERE id = $1"
+	query := "SELECT " + categoryColumns + " FROM categories c WHERE c.id = $1"
 
 	var c models.Category
-	err := r.db.QueryRow(query, id).Scan(&c.ID, &c.Name, &c.Description, &c.CreatedAt, &c.UpdatedAt)
+	err := scanCategory(r.db.QueryRow(query, id), &c)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, errors.New("category not found")
@@ -68,93 +62,609 @@ func (r *categoryRepository) GetByID(id uuid.UUID) (*models.Category, error) {
 	return &c, nil
 }
 
+// Create inserts the category, checking its parent in the same transaction.
 func (r *categoryRepository) Create(category *models.Category) error {
 
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if category.ParentID != nil {
+		if err := checkParent(tx, uuid.Nil, *category.ParentID); err != nil {
+			return err
+		}
+	}
+
 	query := `
-    INSERT INTO categories (id, name, description) 
-    VALUES ($1, $2, $3)
+    INSERT INTO categories (id, name, slug, description, parent_id, tax_class_id) 
+    VALUES ($1, $2, $3, $4, $5, $6)
     RETURNING created_at, updated_at
     `
 
-	err := r.db.QueryRow(query,
+	err = tx.QueryRow(query,
 		category.ID,
 		strings.TrimSpace(category.Name),
+		category.Slug,
 		strings.TrimSpace(category.Description),
+		category.ParentID,
+		category.TaxClassID,
 	).Scan(&category.CreatedAt, &category.UpdatedAt)
 
 	if err != nil {
+		if strings.Contains(err.Error(), "tax_class_id") {
+			return errors.New("tax class not found")
+		}
+		if strings.Contains(err.Error(), "categories_slug_key") {
+			return errors.New("category with this slug already exists")
+		}
 		if strings.Contains(err.Error(), "duplicate key") {
 			return errors.New("category with this name already exists")
 		}
 		return err
 	}
 
-	return nil
+	return tx.Commit()
 }
 
+// Update rewrites the category and, when its slug changes, keeps the old
+// slug as a redirect in the same transaction. A new parent is checked there
+// too.
 func (r *categoryRepository) Update(id uuid.UUID, category *models.Category) error {
 
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var previousSlug string
+	var previousParentID *uuid.UUID
+	err = tx.QueryRow("SELECT slug, parent_id FROM categories WHERE id = $1 FOR UPDATE", id).Scan(&previousSlug, &previousParentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("category not found")
+		}
+		return err
+	}
+
+	if category.ParentID != nil && (previousParentID == nil || *previousParentID != *category.ParentID) {
+		if err := checkParent(tx, id, *category.ParentID); err != nil {
+			return err
+		}
+	}
+
 	query := `
     UPDATE categories 
-    SET name = $1, description = $2, updated_at = CURRENT_TIMESTAMP 
-    WHERE id = $3 
+    SET name = $1, slug = $2, description = $3, parent_id = $4, tax_class_id = $5, updated_at = CURRENT_TIMESTAMP 
+    WHERE id = $6 
     RETURNING updated_at
     `
 
-	result, err := r.db.Exec(query,
+	err = tx.QueryRow(query,
 		strings.TrimSpace(category.Name),
+		category.Slug,
 		strings.TrimSpace(category.Description),
+		category.ParentID,
+		category.TaxClassID,
 		id,
-	)
+	).Scan(&category.UpdatedAt)
 	if err != nil {
+		if strings.Contains(err.Error(), "tax_class_id") {
+			return errors.New("tax class not found")
+		}
+		if strings.Contains(err.Error(), "categories_slug_key") {
+			return errors.New("category with this slug already exists")
+		}
 		if strings.Contains(err.Error(), "duplicate key") {
 			return errors.New("category with this name already exists")
 		}
 		return err
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	if category.Slug != previousSlug {
+		if err := insertCategorySlugRedirect(tx, previousSlug, id); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM category_slug_redirects WHERE old_slug = $1", category.Slug); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Delete removes a category and deals with its products according to policy,
+// all in one transaction. Subcategories move up to the deleted category's
+// parent. It returns how many products were deleted or reassigned, and the
+// IDs of the deleted ones.
+func (r *categoryRepository) Delete(id uuid.UUID, policy string, targetID *uuid.UUID) (int64, []uuid.UUID, error) {
+
+	tx, err := r.db.Begin()
 	if err != nil {
-		return err
+		return 0, nil, err
 	}
+	defer tx.Rollback()
 
-	if rowsAffected == 0 {
-		return errors.New("category not found")
+	var parentID *uuid.UUID
+	err = tx.QueryRow("SELECT parent_id FROM categories WHERE id = $1 FOR UPDATE", id).Scan(&parentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil, errors.New("category not found")
+		}
+		return 0, nil, err
 	}
 
-	return r.db.QueryRow(
-		"SELECT updated_at FROM categories WHERE id = $1",
-		id,
-	).Scan(&category.UpdatedAt)
+	var affected int64
+	var deleted []uuid.UUID
+	switch policy {
+	case models.DeletePolicyCascade:
+		rows, err := tx.Query("SELECT id FROM products WHERE category_id = $1 FOR UPDATE", id)
+		if err != nil {
+			return 0, nil, err
+		}
+		var locked []uuid.UUID
+		for rows.Next() {
+			var productID uuid.UUID
+			if err := rows.Scan(&productID); err != nil {
+				rows.Close()
+				return 0, nil, err
+			}
+			locked = append(locked, productID)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return 0, nil, err
+		}
+
+		// as in a single product delete, relations go first so the lists
+		// that pointed at these products are left without gaps
+		ids := make([]string, len(locked))
+		for i, productID := range locked {
+			if err := deleteProductRelations(tx, productID); err != nil {
+				return 0, nil, err
+			}
+			ids[i] = productID.String()
+		}
+
+		rows, err = tx.Query("DELETE FROM products WHERE id = ANY($1::uuid[]) RETURNING id", ids)
+		if err != nil {
+			return 0, nil, err
+		}
+		for rows.Next() {
+			var productID uuid.UUID
+			if err := rows.Scan(&productID); err != nil {
+				rows.Close()
+				return 0, nil, err
+			}
+			deleted = append(deleted, productID)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return 0, nil, err
+		}
+		affected = int64(len(deleted))
+
+	case models.DeletePolicyReassign:
+		var exists bool
+		err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM categories WHERE id = $1 FOR SHARE)", targetID).Scan(&exists)
+		if err != nil {
+			return 0, nil, err
+		}
+		if !exists {
+			return 0, nil, errors.New("target category not found")
+		}
+
+		result, err := tx.Exec(
+			"UPDATE products SET category_id = $1, updated_at = CURRENT_TIMESTAMP WHERE category_id = $2",
+			targetID, id,
+		)
+		if err != nil {
+			return 0, nil, err
+		}
+		if affected, err = result.RowsAffected(); err != nil {
+			return 0, nil, err
+		}
+
+	default:
+		// lock the rows so nobody adds a product between the count and the delete
+		var count int64
+		err := tx.QueryRow(
+			"SELECT COUNT(*) FROM (SELECT 1 FROM products WHERE category_id = $1 FOR UPDATE) p",
+			id,
+		).Scan(&count)
+		if err != nil {
+			return 0, nil, err
+		}
+		if count > 0 {
+			return count, nil, &models.CategoryNotEmptyError{Products: count}
+		}
+	}
+
+	_, err = tx.Exec(
+		"UPDATE categories SET parent_id = $1, updated_at = CURRENT_TIMESTAMP WHERE parent_id = $2",
+		parentID, id,
+	)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if _, err := tx.Exec("DELETE FROM categories WHERE id = $1", id); err != nil {
+		return 0, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, nil, err
+	}
+
+	return affected, deleted, nil
+}
+
+func (r *categoryRepository) FindByName(name string) (*models.Category, error) {
+
+	query := "SELECT " + categoryColumns + " FROM categories c WHERE c.name = $1"
+
+	var c models.Category
+	err := scanCategory(r.db.QueryRow(query, name), &c)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (r *categoryRepository) GetChildren(id uuid.UUID) ([]models.Category, error) {
+
+	query := "SELECT " + categoryColumns + " FROM categories c WHERE c.parent_id = $1 ORDER BY c.name"
+
+	return r.queryCategories(query, id)
+}
+
+// GetAncestors returns the parent chain of a category, root first.
+func (r *categoryRepository) GetAncestors(id uuid.UUID) ([]models.Category, error) {
+
+	query := `
+		WITH RECURSIVE ancestors AS (
+			SELECT c.id, c.name, c.slug, c.description, c.parent_id, c.tax_class_id, c.created_at, c.updated_at, 1 AS depth
+			FROM categories c
+			WHERE c.id = (SELECT parent_id FROM categories WHERE id = $1)
+			UNION ALL
+			SELECT c.id, c.name, c.slug, c.description, c.parent_id, c.tax_class_id, c.created_at, c.updated_at, a.depth + 1
+			FROM categories c
+			JOIN ancestors a ON c.id = a.parent_id
+			WHERE a.depth < $2
+		)
+		SELECT ` + categoryColumns + `, c.depth
+		FROM ancestors c
+		ORDER BY c.depth DESC
+	`
+
+	rows, err := r.db.Query(query, id, models.MaxCategoryDepth)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanCategoriesWithDepth(rows, true)
+}
+
+// GetDescendants returns the whole subtree below a category. Depth is
+// relative to the given category, so direct children have depth 1.
+func (r *categoryRepository) GetDescendants(id uuid.UUID) ([]models.Category, error) {
+
+	query := `
+		WITH RECURSIVE descendants AS (
+			SELECT c.id, c.name, c.slug, c.description, c.parent_id, c.tax_class_id, c.created_at, c.updated_at, 1 AS depth
+			FROM categories c
+			WHERE c.parent_id = $1
+			UNION ALL
+			SELECT c.id, c.name, c.slug, c.description, c.parent_id, c.tax_class_id, c.created_at, c.updated_at, d.depth + 1
+			FROM categories c
+			JOIN descendants d ON c.parent_id = d.id
+			WHERE d.depth < $2
+		)
+		SELECT ` + categoryColumns + `, c.depth
+		FROM descendants c
+		ORDER BY c.depth, c.name
+	`
+
+	rows, err := r.db.Query(query, id, models.MaxCategoryDepth)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanCategoriesWithDepth(rows, false)
 }
 
-func (r *categoryRepository) Delete(id uuid.UUID) error {
+// checkParent checks that parentID can hold categoryID (uuid.Nil for a new
+// category) without creating a cycle or pushing its subtree past
+// models.MaxCategoryDepth. It first locks the parent's chain and the
+// category's subtree, so two moves that could combine into a bad tree wait
+// for each other and the second one sees the first.
+func checkParent(tx *sql.Tx, categoryID, parentID uuid.UUID) error {
 
-	query := "DELETE FROM categories WHERE id = $1"
+	if parentID == categoryID {
+		return errors.New("category cannot be its own parent")
+	}
 
-	result, err := r.db.Exec(query, id)
+	rows, err := tx.Query(`
+		WITH RECURSIVE up AS (
+			SELECT id, parent_id FROM categories WHERE id = $1
+			UNION
+			SELECT c.id, c.parent_id FROM categories c JOIN up ON c.id = up.parent_id
+		), down AS (
+			SELECT id FROM categories WHERE id = $2
+			UNION
+			SELECT c.id FROM categories c JOIN down ON c.parent_id = down.id
+		)
+		SELECT id FROM categories
+		WHERE id IN (SELECT id FROM up UNION SELECT id FROM down)
+		ORDER BY id
+		FOR UPDATE
+	`, parentID, categoryID)
 	if err != nil {
 		return err
 	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
 
-	rowsAffected, err := result.RowsAffected()
+	// the parent's level, root being 1, and whether the category is above it
+	var parentDepth int
+	var cycle bool
+	err = tx.QueryRow(`
+		WITH RECURSIVE up AS (
+			SELECT id, parent_id, 1 AS depth FROM categories WHERE id = $1
+			UNION ALL
+			SELECT c.id, c.parent_id, up.depth + 1
+			FROM categories c
+			JOIN up ON c.id = up.parent_id
+			WHERE up.depth <= $3
+		)
+		SELECT COUNT(*), COALESCE(BOOL_OR(id = $2), false) FROM up
+	`, parentID, categoryID, models.MaxCategoryDepth).Scan(&parentDepth, &cycle)
 	if err != nil {
 		return err
 	}
+	if parentDepth == 0 {
+		return errors.New("parent category not found")
+	}
+	if cycle {
+		return errors.New("parent category cannot be a descendant of the category")
+	}
+
+	// how far the category's subtree reaches below it
+	height := 0
+	if categoryID != uuid.Nil {
+		err = tx.QueryRow(`
+			WITH RECURSIVE down AS (
+				SELECT id, 0 AS depth FROM categories WHERE id = $1
+				UNION ALL
+				SELECT c.id, down.depth + 1
+				FROM categories c
+				JOIN down ON c.parent_id = down.id
+				WHERE down.depth <= $2
+			)
+			SELECT MAX(depth) FROM down
+		`, categoryID, models.MaxCategoryDepth).Scan(&height)
+		if err != nil {
+			return err
+		}
+	}
 
-	if rowsAffected == 0 {
-		return errors.New("category not found")
+	if parentDepth+1+height > models.MaxCategoryDepth {
+		return fmt.Errorf("category tree must not exceed %d levels", models.MaxCategoryDepth)
 	}
 
 	return nil
 }
 
-func (r *categoryRepository) FindByName(name string) (*models.Category, error) {
+// categoryColumns is selected by category reads; queries alias categories as c.
+const categoryColumns = "c.id, c.name, c.slug, c.description, c.parent_id, c.tax_class_id, c.created_at, c.updated_at"
+
+// scanCategory scans categoryColumns into c, followed by any extra columns.
+func scanCategory(row interface{ Scan(...any) error }, c *models.Category, extra ...any) error {
+	dest := []any{&c.ID, &c.Name, &c.Slug, &c.Description, &c.ParentID, &c.TaxClassID, &c.CreatedAt, &c.UpdatedAt}
+	return row.Scan(append(dest, extra...)...)
+}
 
-	query := "SELECT id, name, description, created_at, updated_at FROM categories WHERE name = $1"
+func (r *categoryRepository) queryCategories(query string, args ...any) ([]models.Category, error) {
 
-	var c models.Category
-	err := r.db.QueryRow(query, name).Scan(&c.ID, &c.Name, &c.Description, &c.CreatedAt, &c.UpdatedAt)
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []models.Category
+	for rows.Next() {
+		var c models.Category
+		if err := scanCategory(rows, &c); err != nil {
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+func scanCategoriesWithDepth(rows *sql.Rows, fromRoot bool) ([]models.Category, error) {
+
+	var categories []models.Category
+	for rows.Next() {
+		var c models.Category
+		if err := scanCategory(rows, &c, &c.Depth); err != nil {
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// ancestors are collected leaf-first, so renumber them from the root
+	if fromRoot {
+		for i := range categories {
+			categories[i].Depth = i + 1
+		}
+	}
+
+	return categories, nil
+}
+
+// Merge moves every product and direct subcategory of source to target,
+// deletes source and leaves a redirect behind, in a single transaction.
+// Redirects that pointed at source are repointed so chains stay one hop long,
+// and so are the customer price rules and promotions scoped to source.
+// Source's attribute schema is merged into target's, see
+// mergeCategoryAttributes.
+// Each subcategory is checked with checkParent against its new parent inside
+// the transaction, so a concurrent move cannot slip a cycle or an over-deep
+// tree past the check.
+func (r *categoryRepository) Merge(sourceID, targetID uuid.UUID) (*models.MergeCategoryResult, error) {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var locked int
+	err = tx.QueryRow(
+		"SELECT COUNT(*) FROM (SELECT 1 FROM categories WHERE id IN ($1, $2) FOR UPDATE) c",
+		sourceID, targetID,
+	).Scan(&locked)
+	if err != nil {
+		return nil, err
+	}
+	if locked != 2 {
+		return nil, errors.New("category not found")
+	}
+
+	var sourceSlug string
+	if err := tx.QueryRow("SELECT slug FROM categories WHERE id = $1", sourceID).Scan(&sourceSlug); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query("SELECT id FROM categories WHERE parent_id = $1 ORDER BY id FOR UPDATE", sourceID)
+	if err != nil {
+		return nil, err
+	}
+	var children []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		children = append(children, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, child := range children {
+		if err := checkParent(tx, child, targetID); err != nil {
+			if strings.Contains(err.Error(), "parent") {
+				return nil, errors.New("category cannot be merged into its own subcategory")
+			}
+			return nil, err
+		}
+	}
+
+	result := &models.MergeCategoryResult{SourceID: sourceID, TargetID: targetID}
+
+	res, err := tx.Exec(
+		"UPDATE products SET category_id = $1, updated_at = CURRENT_TIMESTAMP WHERE category_id = $2",
+		targetID, sourceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if result.ProductsMoved, err = res.RowsAffected(); err != nil {
+		return nil, err
+	}
+
+	res, err = tx.Exec(
+		"UPDATE categories SET parent_id = $1, updated_at = CURRENT_TIMESTAMP WHERE parent_id = $2",
+		targetID, sourceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if result.ChildrenMoved, err = res.RowsAffected(); err != nil {
+		return nil, err
+	}
+
+	// repoint before deleting, the FK would otherwise cascade these away
+	_, err = tx.Exec("UPDATE category_redirects SET target_id = $1 WHERE target_id = $2", targetID, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec("UPDATE category_slug_redirects SET category_id = $1 WHERE category_id = $2", targetID, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec("UPDATE price_rules SET category_id = $1 WHERE category_id = $2", targetID, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	// a promotion already on target keeps that row; its source row cascades
+	_, err = tx.Exec(`
+		UPDATE promotion_targets pt SET category_id = $1
+		WHERE pt.category_id = $2
+		  AND NOT EXISTS (
+			SELECT 1 FROM promotion_targets t
+			WHERE t.promotion_id = pt.promotion_id AND t.category_id = $1
+		  )
+	`, targetID, sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := mergeCategoryAttributes(tx, sourceID, targetID); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec("DELETE FROM categories WHERE id = $1", sourceID); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO category_redirects (old_id, target_id) VALUES ($1, $2)
+		ON CONFLICT (old_id) DO UPDATE SET target_id = EXCLUDED.target_id
+	`, sourceID, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := insertCategorySlugRedirect(tx, sourceSlug, targetID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *categoryRepository) FindRedirect(id uuid.UUID) (*uuid.UUID, error) {
+
+	var targetID uuid.UUID
+	err := r.db.QueryRow("SELECT target_id FROM category_redirects WHERE old_id = $1", id).Scan(&targetID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -162,5 +672,288 @@ func (r *categoryRepository) FindByName(name string) (*models.Category, error) {
 		return nil, err
 	}
 
-	return &c, nil
+	return &targetID, nil
+}
+
+// FindBySlug resolves a current slug, or an old one through its redirect. The
+// flag reports whether a redirect was followed.
+func (r *categoryRepository) FindBySlug(slug string) (*models.Category, bool, error) {
+
+	var c models.Category
+	err := scanCategory(r.db.QueryRow("SELECT "+categoryColumns+" FROM categories c WHERE c.slug = $1", slug), &c)
+	if err == nil {
+		return &c, false, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, false, err
+	}
+
+	query := `
+		SELECT ` + categoryColumns + `
+		FROM category_slug_redirects sr
+		JOIN categories c ON c.id = sr.category_id
+		WHERE sr.old_slug = $1
+	`
+	err = scanCategory(r.db.QueryRow(query, slug), &c)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, false, errors.New("category not found")
+		}
+		return nil, false, err
+	}
+
+	return &c, true, nil
+}
+
+// SlugTaken reports whether slug is in use, either live or as a redirect.
+func (r *categoryRepository) SlugTaken(slug string) (bool, error) {
+
+	var taken bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM categories WHERE slug = $1)
+		    OR EXISTS (SELECT 1 FROM category_slug_redirects WHERE old_slug = $1)
+	`, slug).Scan(&taken)
+	return taken, err
+}
+
+func insertCategorySlugRedirect(tx *sql.Tx, oldSlug string, categoryID uuid.UUID) error {
+	_, err := tx.Exec(`
+		INSERT INTO category_slug_redirects (old_slug, category_id) VALUES ($1, $2)
+		ON CONFLICT (old_slug) DO UPDATE SET category_id = EXCLUDED.category_id, created_at = CURRENT_TIMESTAMP
+	`, oldSlug, categoryID)
+	return err
+}
+
+const categoryStatsColumns = `
+	COUNT(p.id),
+	COUNT(p.id) FILTER (WHERE p.stock > 0),
+	COALESCE(SUM(p.stock), 0),
+	MIN(effective_price(p.id, p.price)),
+	MAX(effective_price(p.id, p.price)),
+	ROUND(AVG(effective_price(p.id, p.price)))::BIGINT
+`
+
+func (r *categoryRepository) GetAllWithStats() ([]models.Category, error) {
+
+	query := `
+		SELECT ` + categoryColumns + `,
+		` + categoryStatsColumns + `
+		FROM categories c
+		LEFT JOIN products p ON p.category_id = c.id
+		GROUP BY c.id
+		ORDER BY c.name
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []models.Category
+	for rows.Next() {
+		var c models.Category
+		var st models.CategoryStats
+		err := scanCategory(rows, &c,
+			&st.ProductCount, &st.InStockCount, &st.TotalStock,
+			&st.MinPrice, &st.MaxPrice, &st.AvgPrice,
+		)
+		if err != nil {
+			return nil, err
+		}
+		c.Stats = &st
+		categories = append(categories, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+func (r *categoryRepository) GetStats(id uuid.UUID) (*models.CategoryStats, error) {
+
+	query := "SELECT " + categoryStatsColumns + " FROM products p WHERE p.category_id = $1"
+
+	var st models.CategoryStats
+	err := r.db.QueryRow(query, id).Scan(
+		&st.ProductCount, &st.InStockCount, &st.TotalStock,
+		&st.MinPrice, &st.MaxPrice, &st.AvgPrice,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &st, nil
+}
+
+func (r *categoryRepository) GetAttributes(categoryID uuid.UUID) ([]models.CategoryAttribute, error) {
+
+	query := `
+		SELECT id, category_id, name, type, unit, "values", required, position
+		FROM category_attributes
+		WHERE category_id = $1
+		ORDER BY position, name
+	`
+
+	rows, err := r.db.Query(query, categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanCategoryAttributes(rows)
+}
+
+func scanCategoryAttributes(rows *sql.Rows) ([]models.CategoryAttribute, error) {
+	defer rows.Close()
+
+	var attributes []models.CategoryAttribute
+	for rows.Next() {
+		var a models.CategoryAttribute
+		var valuesData []byte
+		if err := rows.Scan(&a.ID, &a.CategoryID, &a.Name, &a.Type, &a.Unit, &valuesData, &a.Required, &a.Position); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(valuesData, &a.Values); err != nil {
+			return nil, err
+		}
+		attributes = append(attributes, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return attributes, nil
+}
+
+// mergeCategoryAttributes adds the attribute schema of source to target
+// ahead of a merge. An attribute both define must agree on type and unit;
+// enum values are unioned and target keeps its required flag. Attributes
+// only source has come over optional, since target's own products lack them.
+func mergeCategoryAttributes(tx *sql.Tx, sourceID, targetID uuid.UUID) error {
+
+	query := `
+		SELECT id, category_id, name, type, unit, "values", required, position
+		FROM category_attributes
+		WHERE category_id = $1
+		ORDER BY position, name
+		FOR UPDATE
+	`
+
+	rows, err := tx.Query(query, targetID)
+	if err != nil {
+		return err
+	}
+	targetAttributes, err := scanCategoryAttributes(rows)
+	if err != nil {
+		return err
+	}
+	rows, err = tx.Query(query, sourceID)
+	if err != nil {
+		return err
+	}
+	sourceAttributes, err := scanCategoryAttributes(rows)
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]*models.CategoryAttribute, len(targetAttributes))
+	position := 0
+	for i := range targetAttributes {
+		existing[targetAttributes[i].Name] = &targetAttributes[i]
+		if targetAttributes[i].Position >= position {
+			position = targetAttributes[i].Position + 1
+		}
+	}
+
+	for _, a := range sourceAttributes {
+		values := a.Values
+		if values == nil {
+			values = []string{}
+		}
+
+		if t, ok := existing[a.Name]; ok {
+			if t.Type != a.Type || t.Unit != a.Unit {
+				return fmt.Errorf("attribute %q conflicts with the target category: type %s, unit %q there; type %s, unit %q here",
+					a.Name, t.Type, t.Unit, a.Type, a.Unit)
+			}
+			if a.Type != models.AttributeEnum {
+				continue
+			}
+			values = t.Values
+			for _, v := range a.Values {
+				if !slices.Contains(values, v) {
+					values = append(values, v)
+				}
+			}
+			valuesData, err := json.Marshal(values)
+			if err != nil {
+				return err
+			}
+			if _, err := tx.Exec(`UPDATE category_attributes SET "values" = $1 WHERE id = $2`, valuesData, t.ID); err != nil {
+				return err
+			}
+			continue
+		}
+
+		valuesData, err := json.Marshal(values)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`
+			INSERT INTO category_attributes (category_id, name, type, unit, "values", required, position)
+			VALUES ($1, $2, $3, $4, $5, false, $6)
+		`, targetID, a.Name, a.Type, a.Unit, valuesData, position)
+		if err != nil {
+			return err
+		}
+		position++
+	}
+
+	return nil
+}
+
+// SetAttributes replaces the attribute schema of a category. Values already
+// stored on products are left as they are.
+func (r *categoryRepository) SetAttributes(categoryID uuid.UUID, attributes []models.CategoryAttribute) error {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM category_attributes WHERE category_id = $1", categoryID); err != nil {
+		return err
+	}
+
+	for i := range attributes {
+		values := attributes[i].Values
+		if values == nil {
+			values = []string{}
+		}
+		valuesData, err := json.Marshal(values)
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRow(`
+			INSERT INTO category_attributes (category_id, name, type, unit, "values", required, position)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id
+		`, categoryID, attributes[i].Name, attributes[i].Type, attributes[i].Unit, valuesData,
+			attributes[i].Required, attributes[i].Position,
+		).Scan(&attributes[i].ID)
+		if err != nil {
+			if strings.Contains(err.Error(), "foreign key constraint") {
+				return errors.New("category not found")
+			}
+			return err
+		}
+		attributes[i].CategoryID = categoryID
+	}
+
+	return tx.Commit()
 }
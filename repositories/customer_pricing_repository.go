@@ -0,0 +1,297 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/google/uuid"
+)
+
+type CustomerPricingRepository interface {
+	GetGroups() ([]models.CustomerGroup, error)
+	GetGroupByID(id uuid.UUID) (*models.CustomerGroup, error)
+	GetGroupByCode(code string) (*models.CustomerGroup, error)
+	CreateGroup(group *models.CustomerGroup) error
+	UpdateGroup(id uuid.UUID, group *models.CustomerGroup) error
+	DeleteGroup(id uuid.UUID) error
+	GetRules(filter models.PriceRuleFilter) ([]models.PriceRule, error)
+	CreateRule(rule *models.PriceRule) error
+	DeleteRule(id uuid.UUID) error
+	GetApplicableRules(productIDs []uuid.UUID, groupID *uuid.UUID) ([]models.ApplicableRule, error)
+}
+
+type customerPricingRepository struct {
+	db *sql.DB
+}
+
+func NewCustomerPricingRepository(db *sql.DB) CustomerPricingRepository {
+	return &customerPricingRepository{db: db}
+}
+
+const customerGroupColumns = "id, code, name, created_at, updated_at"
+
+func scanCustomerGroup(row interface{ Scan(...any) error }, g *models.CustomerGroup) error {
+	return row.Scan(&g.ID, &g.Code, &g.Name, &g.CreatedAt, &g.UpdatedAt)
+}
+
+func (r *customerPricingRepository) GetGroups() ([]models.CustomerGroup, error) {
+
+	rows, err := r.db.Query("SELECT " + customerGroupColumns + " FROM customer_groups ORDER BY code")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []models.CustomerGroup
+	for rows.Next() {
+		var g models.CustomerGroup
+		if err := scanCustomerGroup(rows, &g); err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+func (r *customerPricingRepository) GetGroupByID(id uuid.UUID) (*models.CustomerGroup, error) {
+
+	var g models.CustomerGroup
+	err := scanCustomerGroup(r.db.QueryRow("SELECT "+customerGroupColumns+" FROM customer_groups WHERE id = $1", id), &g)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("customer group not found")
+		}
+		return nil, err
+	}
+
+	return &g, nil
+}
+
+func (r *customerPricingRepository) GetGroupByCode(code string) (*models.CustomerGroup, error) {
+
+	var g models.CustomerGroup
+	err := scanCustomerGroup(r.db.QueryRow("SELECT "+customerGroupColumns+" FROM customer_groups WHERE code = $1", code), &g)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("customer group not found")
+		}
+		return nil, err
+	}
+
+	return &g, nil
+}
+
+func (r *customerPricingRepository) CreateGroup(group *models.CustomerGroup) error {
+
+	err := r.db.QueryRow(`
+		INSERT INTO customer_groups (code, name)
+		VALUES ($1, $2)
+		RETURNING id, created_at, updated_at
+	`, group.Code, group.Name).Scan(&group.ID, &group.CreatedAt, &group.UpdatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return errors.New("customer group with this code already exists")
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *customerPricingRepository) UpdateGroup(id uuid.UUID, group *models.CustomerGroup) error {
+
+	err := r.db.QueryRow(`
+		UPDATE customer_groups
+		SET name = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+		RETURNING updated_at
+	`, group.Name, id).Scan(&group.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("customer group not found")
+		}
+		return err
+	}
+
+	return nil
+}
+
+// DeleteGroup removes a customer group together with its price rules.
+func (r *customerPricingRepository) DeleteGroup(id uuid.UUID) error {
+
+	result, err := r.db.Exec("DELETE FROM customer_groups WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("customer group not found")
+	}
+
+	return nil
+}
+
+const priceRuleColumns = "r.id, r.customer_group_id, r.product_id, r.category_id, r.min_qty, r.discount_bp, r.fixed_price, r.created_at"
+
+func scanPriceRule(row interface{ Scan(...any) error }, rule *models.PriceRule, extra ...any) error {
+	dest := []any{
+		&rule.ID, &rule.CustomerGroupID, &rule.ProductID, &rule.CategoryID,
+		&rule.MinQty, &rule.DiscountBP, &rule.FixedPrice, &rule.CreatedAt,
+	}
+	return row.Scan(append(dest, extra...)...)
+}
+
+func (r *customerPricingRepository) GetRules(filter models.PriceRuleFilter) ([]models.PriceRule, error) {
+
+	var args []any
+	var where []string
+	if filter.CustomerGroupID != nil {
+		args = append(args, *filter.CustomerGroupID)
+		where = append(where, fmt.Sprintf("r.customer_group_id = $%d", len(args)))
+	}
+	if filter.ProductID != nil {
+		args = append(args, *filter.ProductID)
+		where = append(where, fmt.Sprintf("r.product_id = $%d", len(args)))
+	}
+	if filter.CategoryID != nil {
+		args = append(args, *filter.CategoryID)
+		where = append(where, fmt.Sprintf("r.category_id = $%d", len(args)))
+	}
+
+	query := "SELECT " + priceRuleColumns + " FROM price_rules r"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY r.product_id NULLS LAST, r.category_id, r.min_qty"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []models.PriceRule
+	for rows.Next() {
+		var rule models.PriceRule
+		if err := scanPriceRule(rows, &rule); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func (r *customerPricingRepository) CreateRule(rule *models.PriceRule) error {
+
+	err := r.db.QueryRow(`
+		INSERT INTO price_rules (customer_group_id, product_id, category_id, min_qty, discount_bp, fixed_price)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`, rule.CustomerGroupID, rule.ProductID, rule.CategoryID, rule.MinQty, rule.DiscountBP, rule.FixedPrice,
+	).Scan(&rule.ID, &rule.CreatedAt)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "customer_group_id"):
+			return errors.New("customer group not found")
+		case strings.Contains(err.Error(), "product_id"):
+			return errors.New("product not found")
+		case strings.Contains(err.Error(), "category_id"):
+			return errors.New("category not found")
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *customerPricingRepository) DeleteRule(id uuid.UUID) error {
+
+	result, err := r.db.Exec("DELETE FROM price_rules WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("price rule not found")
+	}
+
+	return nil
+}
+
+// GetApplicableRules returns every rule that could price one of productIDs
+// for the group: rules on the product itself and rules on its category or
+// any ancestor category. Rules for everyone are always included; group rules
+// only when groupID is set.
+func (r *customerPricingRepository) GetApplicableRules(productIDs []uuid.UUID, groupID *uuid.UUID) ([]models.ApplicableRule, error) {
+
+	ids := make([]string, len(productIDs))
+	for i, id := range productIDs {
+		ids[i] = id.String()
+	}
+
+	query := `
+		WITH RECURSIVE chain AS (
+			SELECT p.id AS product_id, p.category_id, 1 AS depth
+			FROM products p
+			WHERE p.id = ANY($1::uuid[])
+			UNION ALL
+			SELECT ch.product_id, c.parent_id, ch.depth + 1
+			FROM chain ch
+			JOIN categories c ON c.id = ch.category_id
+			WHERE c.parent_id IS NOT NULL AND ch.depth < $3
+		)
+		SELECT ` + priceRuleColumns + `, r.product_id, 0
+		FROM price_rules r
+		WHERE r.product_id = ANY($1::uuid[])
+		  AND (r.customer_group_id IS NULL OR r.customer_group_id = $2::uuid)
+		UNION ALL
+		SELECT ` + priceRuleColumns + `, ch.product_id, ch.depth
+		FROM chain ch
+		JOIN price_rules r ON r.category_id = ch.category_id
+		WHERE r.customer_group_id IS NULL OR r.customer_group_id = $2::uuid
+	`
+
+	rows, err := r.db.Query(query, ids, groupID, models.MaxCategoryDepth)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []models.ApplicableRule
+	for rows.Next() {
+		var ar models.ApplicableRule
+		if err := scanPriceRule(rows, &ar.Rule, &ar.ProductID, &ar.Depth); err != nil {
+			return nil, err
+		}
+		rules = append(rules, ar)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
@@ -0,0 +1,304 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/google/uuid"
+)
+
+type ImageRepository interface {
+	GetByProduct(productID uuid.UUID) ([]models.ProductImage, error)
+	GetForProducts(productIDs []uuid.UUID) (map[uuid.UUID][]models.ProductImage, error)
+	GetByID(productID, imageID uuid.UUID) (*models.ProductImage, error)
+	Create(image *models.ProductImage) error
+	UpdateAltText(productID, imageID uuid.UUID, altText string) error
+	Reorder(productID uuid.UUID, imageIDs []uuid.UUID) error
+	Delete(productID, imageID uuid.UUID) (*models.ProductImage, error)
+}
+
+type imageRepository struct {
+	db *sql.DB
+}
+
+func NewImageRepository(db *sql.DB) ImageRepository {
+	return &imageRepository{db: db}
+}
+
+const imageColumns = `id, product_id, position, alt_text, content_type, width, height, size_bytes, storage_key, created_at`
+
+func scanImage(row interface{ Scan(...any) error }, img *models.ProductImage) error {
+	return row.Scan(
+		&img.ID, &img.ProductID, &img.Position, &img.AltText, &img.ContentType,
+		&img.Width, &img.Height, &img.SizeBytes, &img.StorageKey, &img.CreatedAt,
+	)
+}
+
+func (r *imageRepository) GetByProduct(productID uuid.UUID) ([]models.ProductImage, error) {
+
+	byProduct, err := r.GetForProducts([]uuid.UUID{productID})
+	if err != nil {
+		return nil, err
+	}
+	return byProduct[productID], nil
+}
+
+// GetForProducts loads the images of many products at once, in position order.
+func (r *imageRepository) GetForProducts(productIDs []uuid.UUID) (map[uuid.UUID][]models.ProductImage, error) {
+
+	result := make(map[uuid.UUID][]models.ProductImage)
+	if len(productIDs) == 0 {
+		return result, nil
+	}
+
+	ids := make([]string, len(productIDs))
+	for i, id := range productIDs {
+		ids[i] = id.String()
+	}
+
+	rows, err := r.db.Query(
+		"SELECT "+imageColumns+" FROM product_images WHERE product_id = ANY($1::uuid[]) ORDER BY product_id, position",
+		ids,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var images []models.ProductImage
+	for rows.Next() {
+		var img models.ProductImage
+		if err := scanImage(rows, &img); err != nil {
+			return nil, err
+		}
+		images = append(images, img)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.loadVariants(images); err != nil {
+		return nil, err
+	}
+
+	for _, img := range images {
+		result[img.ProductID] = append(result[img.ProductID], img)
+	}
+	return result, nil
+}
+
+func (r *imageRepository) GetByID(productID, imageID uuid.UUID) (*models.ProductImage, error) {
+
+	var img models.ProductImage
+	err := scanImage(r.db.QueryRow(
+		"SELECT "+imageColumns+" FROM product_images WHERE id = $1 AND product_id = $2", imageID, productID,
+	), &img)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("image not found")
+		}
+		return nil, err
+	}
+
+	images := []models.ProductImage{img}
+	if err := r.loadVariants(images); err != nil {
+		return nil, err
+	}
+	return &images[0], nil
+}
+
+// Create appends the image after the product's last one. The product row is
+// locked so concurrent uploads cannot both take the last free slot.
+func (r *imageRepository) Create(image *models.ProductImage) error {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var locked uuid.UUID
+	err = tx.QueryRow("SELECT id FROM products WHERE id = $1 FOR UPDATE", image.ProductID).Scan(&locked)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("product not found")
+		}
+		return err
+	}
+
+	var count int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM product_images WHERE product_id = $1", image.ProductID).Scan(&count); err != nil {
+		return err
+	}
+	if count >= models.MaxImagesPerProduct {
+		return fmt.Errorf("product cannot have more than %d images", models.MaxImagesPerProduct)
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO product_images (id, product_id, position, alt_text, content_type, width, height, size_bytes, storage_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING position, created_at
+	`, image.ID, image.ProductID, count, image.AltText, image.ContentType,
+		image.Width, image.Height, image.SizeBytes, image.StorageKey,
+	).Scan(&image.Position, &image.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range image.Variants {
+		_, err := tx.Exec(`
+			INSERT INTO product_image_variants (image_id, name, format, content_type, width, height, size_bytes, storage_key)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`, image.ID, v.Name, v.Format, v.ContentType, v.Width, v.Height, v.SizeBytes, v.StorageKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *imageRepository) UpdateAltText(productID, imageID uuid.UUID, altText string) error {
+
+	result, err := r.db.Exec(
+		"UPDATE product_images SET alt_text = $1 WHERE id = $2 AND product_id = $3", altText, imageID, productID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("image not found")
+	}
+	return nil
+}
+
+// Reorder sets positions from imageIDs, which must name each of the
+// product's images exactly once.
+func (r *imageRepository) Reorder(productID uuid.UUID, imageIDs []uuid.UUID) error {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT id FROM product_images WHERE product_id = $1 FOR UPDATE", productID)
+	if err != nil {
+		return err
+	}
+	current := make(map[uuid.UUID]bool)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		current[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(imageIDs) != len(current) {
+		return errors.New("image_ids must list every image of the product exactly once")
+	}
+	seen := make(map[uuid.UUID]bool)
+	for _, id := range imageIDs {
+		if !current[id] || seen[id] {
+			return errors.New("image_ids must list every image of the product exactly once")
+		}
+		seen[id] = true
+	}
+
+	for position, id := range imageIDs {
+		if _, err := tx.Exec("UPDATE product_images SET position = $1 WHERE id = $2", position, id); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Delete removes the image and closes the gap in positions. It returns the
+// deleted row so its files can be removed.
+func (r *imageRepository) Delete(productID, imageID uuid.UUID) (*models.ProductImage, error) {
+
+	img, err := r.GetByID(productID, imageID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var position int
+	err = tx.QueryRow(
+		"DELETE FROM product_images WHERE id = $1 AND product_id = $2 RETURNING position", imageID, productID,
+	).Scan(&position)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("image not found")
+		}
+		return nil, err
+	}
+
+	_, err = tx.Exec(
+		"UPDATE product_images SET position = position - 1 WHERE product_id = $1 AND position > $2", productID, position,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+func (r *imageRepository) loadVariants(images []models.ProductImage) error {
+
+	if len(images) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(images))
+	index := make(map[uuid.UUID]int, len(images))
+	for i := range images {
+		ids[i] = images[i].ID.String()
+		index[images[i].ID] = i
+		images[i].Variants = []models.ImageVariant{}
+	}
+
+	rows, err := r.db.Query(`
+		SELECT image_id, name, format, content_type, width, height, size_bytes, storage_key
+		FROM product_image_variants
+		WHERE image_id = ANY($1::uuid[])
+		ORDER BY image_id, width, format
+	`, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var imageID uuid.UUID
+		var v models.ImageVariant
+		if err := rows.Scan(&imageID, &v.Name, &v.Format, &v.ContentType, &v.Width, &v.Height, &v.SizeBytes, &v.StorageKey); err != nil {
+			return err
+		}
+		i := index[imageID]
+		images[i].Variants = append(images[i].Variants, v)
+	}
+	return rows.Err()
+}
@@ -0,0 +1,124 @@
+package repositories
+
+import (
+	"database/sql"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/google/uuid"
+)
+
+type InventoryRepository interface {
+	GetLowStock() ([]models.LowStockItem, error)
+	GetCostHistories() ([]models.ProductCostHistory, error)
+}
+
+type inventoryRepository struct {
+	db *sql.DB
+}
+
+func NewInventoryRepository(db *sql.DB) InventoryRepository {
+	return &inventoryRepository{db: db}
+}
+
+// GetLowStock returns products with a threshold whose available stock
+// (stock minus active reservations) is at or under it, ordered by category.
+func (r *inventoryRepository) GetLowStock() ([]models.LowStockItem, error) {
+
+	query := `
+		SELECT * FROM (
+			SELECT ` + publicProductColumns + `, COALESCE(c.name, '') AS category_name
+			FROM products p
+			LEFT JOIN categories c ON c.id = p.category_id
+			WHERE p.low_stock_threshold IS NOT NULL
+		) low
+		WHERE low.stock - low.reserved <= low.low_stock_threshold
+		ORDER BY low.category_name, low.name
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.LowStockItem
+	for rows.Next() {
+		var item models.LowStockItem
+		if err := scanProduct(rows, &item.Product, &item.CategoryName); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// GetCostHistories returns every product in stock, ordered by category, with
+// its ledger oldest first. Paired warehouse transfer entries cancel out per
+// product and are left out.
+func (r *inventoryRepository) GetCostHistories() ([]models.ProductCostHistory, error) {
+
+	rows, err := r.db.Query(`
+		SELECT p.id, p.name, p.category_id, COALESCE(c.name, ''), p.stock, p.cost_price
+		FROM products p
+		LEFT JOIN categories c ON c.id = p.category_id
+		WHERE p.stock > 0
+		ORDER BY COALESCE(c.name, ''), p.category_id, p.name, p.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var histories []models.ProductCostHistory
+	index := make(map[uuid.UUID]int)
+	for rows.Next() {
+		var h models.ProductCostHistory
+		if err := rows.Scan(&h.ProductID, &h.Name, &h.CategoryID, &h.CategoryName, &h.Stock, &h.CostPrice); err != nil {
+			return nil, err
+		}
+		index[h.ProductID] = len(histories)
+		histories = append(histories, h)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(histories) == 0 {
+		return histories, nil
+	}
+
+	movements, err := r.db.Query(`
+		SELECT m.product_id, m.quantity, m.unit_cost
+		FROM stock_movements m
+		JOIN products p ON p.id = m.product_id
+		WHERE p.stock > 0 AND m.transfer_id IS NULL
+		ORDER BY m.product_id, m.created_at, m.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer movements.Close()
+
+	for movements.Next() {
+		var productID uuid.UUID
+		var m models.CostedMovement
+		if err := movements.Scan(&productID, &m.Quantity, &m.UnitCost); err != nil {
+			return nil, err
+		}
+		if i, ok := index[productID]; ok {
+			histories[i].Movements = append(histories[i].Movements, m)
+		}
+	}
+
+	if err = movements.Err(); err != nil {
+		return nil, err
+	}
+
+	return histories, nil
+}
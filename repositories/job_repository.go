@@ -0,0 +1,234 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/google/uuid"
+)
+
+type JobRepository interface {
+	Create(job *models.Job) error
+	GetByID(id uuid.UUID) (*models.Job, error)
+	ClaimNext(staleAfter time.Duration, maxAttempts int) (*models.Job, error)
+	FailExhausted(staleAfter time.Duration, maxAttempts int) error
+	UpdateProgress(job *models.Job) error
+	Heartbeat(job *models.Job) error
+	Complete(job *models.Job) error
+	Fail(job *models.Job) error
+}
+
+type jobRepository struct {
+	db *sql.DB
+}
+
+func NewJobRepository(db *sql.DB) JobRepository {
+	return &jobRepository{db: db}
+}
+
+const jobColumns = `
+	id, type, format, status, input_path, artifact_path,
+	total, processed, succeeded, failed, errors, attempts,
+	started_at, finished_at, created_at, updated_at
+`
+
+func scanJob(row interface{ Scan(...any) error }) (*models.Job, error) {
+	var j models.Job
+	var errorsData []byte
+
+	err := row.Scan(
+		&j.ID, &j.Type, &j.Format, &j.Status, &j.InputPath, &j.ArtifactPath,
+		&j.Total, &j.Processed, &j.Succeeded, &j.Failed, &errorsData, &j.Attempts,
+		&j.StartedAt, &j.FinishedAt, &j.CreatedAt, &j.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(errorsData, &j.Errors); err != nil {
+		return nil, err
+	}
+
+	return &j, nil
+}
+
+func (r *jobRepository) Create(job *models.Job) error {
+
+	query := `
+		INSERT INTO jobs (type, format, status, input_path)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + jobColumns
+
+	created, err := scanJob(r.db.QueryRow(query, job.Type, job.Format, models.JobStatusPending, job.InputPath))
+	if err != nil {
+		return err
+	}
+
+	*job = *created
+	return nil
+}
+
+func (r *jobRepository) GetByID(id uuid.UUID) (*models.Job, error) {
+
+	query := "SELECT " + jobColumns + " FROM jobs WHERE id = $1"
+
+	job, err := scanJob(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("job not found")
+		}
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// ClaimNext picks the oldest pending job, or a running job whose worker stopped
+// heartbeating, and marks it as running. SKIP LOCKED lets several workers (and
+// several server instances) poll the same table without handing out a job twice.
+func (r *jobRepository) ClaimNext(staleAfter time.Duration, maxAttempts int) (*models.Job, error) {
+
+	query := `
+		UPDATE jobs
+		SET status = 'running',
+		    attempts = attempts + 1,
+		    locked_at = CURRENT_TIMESTAMP,
+		    started_at = COALESCE(started_at, CURRENT_TIMESTAMP),
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE status = 'pending'
+			   OR (status = 'running'
+			       AND locked_at < CURRENT_TIMESTAMP - make_interval(secs => $1)
+			       AND attempts < $2)
+			ORDER BY created_at
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING ` + jobColumns
+
+	job, err := scanJob(r.db.QueryRow(query, staleAfter.Seconds(), maxAttempts))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (r *jobRepository) FailExhausted(staleAfter time.Duration, maxAttempts int) error {
+
+	query := `
+		UPDATE jobs
+		SET status = 'failed',
+		    errors = errors || '[{"message": "job abandoned after too many attempts"}]'::jsonb,
+		    finished_at = CURRENT_TIMESTAMP,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE status = 'running'
+		  AND locked_at < CURRENT_TIMESTAMP - make_interval(secs => $1)
+		  AND attempts >= $2
+	`
+
+	_, err := r.db.Exec(query, staleAfter.Seconds(), maxAttempts)
+	return err
+}
+
+// errJobLost is returned by writes from a worker whose claim went stale and
+// was taken over; job.Attempts identifies the claim.
+var errJobLost = errors.New("job was claimed by another worker")
+
+// UpdateProgress stores the counters and refreshes locked_at, which doubles as
+// the worker heartbeat.
+func (r *jobRepository) UpdateProgress(job *models.Job) error {
+
+	errorsData, err := marshalJobErrors(job.Errors)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE jobs
+		SET total = $1, processed = $2, succeeded = $3, failed = $4, errors = $5,
+		    locked_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $6 AND status = 'running' AND attempts = $7
+	`
+
+	result, err := r.db.Exec(query, job.Total, job.Processed, job.Succeeded, job.Failed, errorsData, job.ID, job.Attempts)
+	if err != nil {
+		return err
+	}
+	return checkJobOwned(result)
+}
+
+// Heartbeat refreshes locked_at so a long run is not mistaken for an
+// abandoned one between progress updates.
+func (r *jobRepository) Heartbeat(job *models.Job) error {
+
+	result, err := r.db.Exec(`
+		UPDATE jobs SET locked_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'running' AND attempts = $2
+	`, job.ID, job.Attempts)
+	if err != nil {
+		return err
+	}
+	return checkJobOwned(result)
+}
+
+// Complete and Fail only finish the job while the caller's claim is still the
+// current one; a worker that lost it gets errJobLost and must stop.
+func (r *jobRepository) Complete(job *models.Job) error {
+	return r.finish(job, models.JobStatusCompleted)
+}
+
+func (r *jobRepository) Fail(job *models.Job) error {
+	return r.finish(job, models.JobStatusFailed)
+}
+
+func (r *jobRepository) finish(job *models.Job, status string) error {
+
+	errorsData, err := marshalJobErrors(job.Errors)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE jobs
+		SET status = $1, artifact_path = $2,
+		    total = $3, processed = $4, succeeded = $5, failed = $6, errors = $7,
+		    locked_at = NULL, finished_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $8 AND status = 'running' AND attempts = $9
+	`
+
+	result, err := r.db.Exec(query,
+		status, job.ArtifactPath,
+		job.Total, job.Processed, job.Succeeded, job.Failed, errorsData,
+		job.ID, job.Attempts,
+	)
+	if err != nil {
+		return err
+	}
+	return checkJobOwned(result)
+}
+
+func checkJobOwned(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errJobLost
+	}
+	return nil
+}
+
+func marshalJobErrors(jobErrors []models.JobError) ([]byte, error) {
+	if jobErrors == nil {
+		jobErrors = []models.JobError{}
+	}
+	return json.Marshal(jobErrors)
+}
@@ -0,0 +1,334 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/google/uuid"
+)
+
+type PriceListRepository interface {
+	GetAll() ([]models.PriceList, error)
+	GetByID(id uuid.UUID) (*models.PriceList, error)
+	GetByCode(code string) (*models.PriceList, error)
+	Create(list *models.PriceList) error
+	Update(id uuid.UUID, list *models.PriceList) error
+	Delete(id uuid.UUID) error
+	GetItems(listID uuid.UUID) ([]models.PriceListItem, error)
+	SetItems(listID uuid.UUID, items []models.PriceListItemInput) error
+	DeleteItem(listID, productID uuid.UUID) error
+	GetItemPrices(listID uuid.UUID, productIDs []uuid.UUID) (map[uuid.UUID]int64, error)
+	GetRates() ([]models.ExchangeRate, error)
+	GetRate(base, quote string) (string, error)
+	SetRate(rate *models.ExchangeRate) error
+	DeleteRate(base, quote string) error
+}
+
+type priceListRepository struct {
+	db *sql.DB
+}
+
+func NewPriceListRepository(db *sql.DB) PriceListRepository {
+	return &priceListRepository{db: db}
+}
+
+const priceListColumns = "id, code, name, currency, created_at, updated_at"
+
+func scanPriceList(row interface{ Scan(...any) error }, l *models.PriceList) error {
+	return row.Scan(&l.ID, &l.Code, &l.Name, &l.Currency, &l.CreatedAt, &l.UpdatedAt)
+}
+
+func (r *priceListRepository) GetAll() ([]models.PriceList, error) {
+
+	rows, err := r.db.Query("SELECT " + priceListColumns + " FROM price_lists ORDER BY code")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lists []models.PriceList
+	for rows.Next() {
+		var l models.PriceList
+		if err := scanPriceList(rows, &l); err != nil {
+			return nil, err
+		}
+		lists = append(lists, l)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return lists, nil
+}
+
+func (r *priceListRepository) GetByID(id uuid.UUID) (*models.PriceList, error) {
+
+	var l models.PriceList
+	err := scanPriceList(r.db.QueryRow("SELECT "+priceListColumns+" FROM price_lists WHERE id = $1", id), &l)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("price list not found")
+		}
+		return nil, err
+	}
+
+	return &l, nil
+}
+
+func (r *priceListRepository) GetByCode(code string) (*models.PriceList, error) {
+
+	var l models.PriceList
+	err := scanPriceList(r.db.QueryRow("SELECT "+priceListColumns+" FROM price_lists WHERE code = $1", code), &l)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("price list not found")
+		}
+		return nil, err
+	}
+
+	return &l, nil
+}
+
+func (r *priceListRepository) Create(list *models.PriceList) error {
+
+	err := r.db.QueryRow(`
+		INSERT INTO price_lists (code, name, currency)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at
+	`, list.Code, list.Name, list.Currency).Scan(&list.ID, &list.CreatedAt, &list.UpdatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return errors.New("price list with this code already exists")
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *priceListRepository) Update(id uuid.UUID, list *models.PriceList) error {
+
+	err := r.db.QueryRow(`
+		UPDATE price_lists
+		SET name = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+		RETURNING updated_at
+	`, list.Name, id).Scan(&list.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("price list not found")
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *priceListRepository) Delete(id uuid.UUID) error {
+
+	result, err := r.db.Exec("DELETE FROM price_lists WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("price list not found")
+	}
+
+	return nil
+}
+
+func (r *priceListRepository) GetItems(listID uuid.UUID) ([]models.PriceListItem, error) {
+
+	rows, err := r.db.Query(`
+		SELECT i.price_list_id, i.product_id, i.price, l.currency, i.updated_at
+		FROM price_list_items i
+		JOIN price_lists l ON l.id = i.price_list_id
+		WHERE i.price_list_id = $1
+		ORDER BY i.updated_at DESC, i.product_id
+	`, listID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.PriceListItem
+	for rows.Next() {
+		var item models.PriceListItem
+		err := rows.Scan(&item.PriceListID, &item.ProductID, &item.Price.Amount, &item.Price.Currency, &item.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// SetItems upserts every item in one transaction, so a bad product ID leaves
+// the list untouched.
+func (r *priceListRepository) SetItems(listID uuid.UUID, items []models.PriceListItemInput) error {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, item := range items {
+		_, err := tx.Exec(`
+			INSERT INTO price_list_items (price_list_id, product_id, price)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (price_list_id, product_id)
+			DO UPDATE SET price = EXCLUDED.price, updated_at = CURRENT_TIMESTAMP
+		`, listID, item.ProductID, item.Price)
+		if err != nil {
+			if isForeignKeyError(err) {
+				return errors.New("product " + item.ProductID.String() + " not found")
+			}
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *priceListRepository) DeleteItem(listID, productID uuid.UUID) error {
+
+	result, err := r.db.Exec("DELETE FROM price_list_items WHERE price_list_id = $1 AND product_id = $2", listID, productID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("price list item not found")
+	}
+
+	return nil
+}
+
+// GetItemPrices returns the list's prices for whichever of productIDs it covers.
+func (r *priceListRepository) GetItemPrices(listID uuid.UUID, productIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+
+	ids := make([]string, len(productIDs))
+	for i, id := range productIDs {
+		ids[i] = id.String()
+	}
+
+	rows, err := r.db.Query(
+		"SELECT product_id, price FROM price_list_items WHERE price_list_id = $1 AND product_id = ANY($2::uuid[])",
+		listID, ids,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prices := make(map[uuid.UUID]int64)
+	for rows.Next() {
+		var productID uuid.UUID
+		var price int64
+		if err := rows.Scan(&productID, &price); err != nil {
+			return nil, err
+		}
+		prices[productID] = price
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return prices, nil
+}
+
+func (r *priceListRepository) GetRates() ([]models.ExchangeRate, error) {
+
+	rows, err := r.db.Query(`
+		SELECT base_currency, quote_currency, rate::TEXT, updated_at
+		FROM exchange_rates
+		ORDER BY base_currency, quote_currency
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []models.ExchangeRate
+	for rows.Next() {
+		var rate models.ExchangeRate
+		if err := rows.Scan(&rate.Base, &rate.Quote, &rate.Rate, &rate.UpdatedAt); err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+func (r *priceListRepository) GetRate(base, quote string) (string, error) {
+
+	var rate string
+	err := r.db.QueryRow(
+		"SELECT rate::TEXT FROM exchange_rates WHERE base_currency = $1 AND quote_currency = $2",
+		base, quote,
+	).Scan(&rate)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", errors.New("exchange rate not found")
+		}
+		return "", err
+	}
+
+	return rate, nil
+}
+
+func (r *priceListRepository) SetRate(rate *models.ExchangeRate) error {
+
+	return r.db.QueryRow(`
+		INSERT INTO exchange_rates (base_currency, quote_currency, rate)
+		VALUES ($1, $2, $3::NUMERIC)
+		ON CONFLICT (base_currency, quote_currency)
+		DO UPDATE SET rate = EXCLUDED.rate, updated_at = CURRENT_TIMESTAMP
+		RETURNING rate::TEXT, updated_at
+	`, rate.Base, rate.Quote, rate.Rate).Scan(&rate.Rate, &rate.UpdatedAt)
+}
+
+func (r *priceListRepository) DeleteRate(base, quote string) error {
+
+	result, err := r.db.Exec("DELETE FROM exchange_rates WHERE base_currency = $1 AND quote_currency = $2", base, quote)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("exchange rate not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,216 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/google/uuid"
+)
+
+type PriceRepository interface {
+	GetHistory(productID uuid.UUID, limit, offset int) ([]models.PriceChange, int, error)
+	GetSchedules(productID uuid.UUID) ([]models.PriceSchedule, error)
+	CreateSchedule(schedule *models.PriceSchedule) error
+	DeleteSchedule(productID, id uuid.UUID) error
+	MaterializeScheduled() (int64, error)
+}
+
+type priceRepository struct {
+	db *sql.DB
+}
+
+func NewPriceRepository(db *sql.DB) PriceRepository {
+	return &priceRepository{db: db}
+}
+
+func (r *priceRepository) GetHistory(productID uuid.UUID, limit, offset int) ([]models.PriceChange, int, error) {
+
+	var total int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM price_history WHERE product_id = $1", productID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT id, product_id, price, previous_price, list_price, reason, actor, effective_at
+		FROM price_history
+		WHERE product_id = $1
+		ORDER BY effective_at DESC, id
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.Query(query, productID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var changes []models.PriceChange
+	for rows.Next() {
+		var c models.PriceChange
+		err := rows.Scan(&c.ID, &c.ProductID, &c.Price, &c.PreviousPrice, &c.ListPrice, &c.Reason, &c.Actor, &c.EffectiveAt)
+		if err != nil {
+			return nil, 0, err
+		}
+		changes = append(changes, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return changes, total, nil
+}
+
+const priceScheduleColumns = `
+	id, product_id, price, starts_at, ends_at, priority, label,
+	starts_at <= CURRENT_TIMESTAMP AND (ends_at IS NULL OR ends_at > CURRENT_TIMESTAMP),
+	created_at
+`
+
+func scanPriceSchedule(row interface{ Scan(...any) error }, ps *models.PriceSchedule) error {
+	return row.Scan(&ps.ID, &ps.ProductID, &ps.Price, &ps.StartsAt, &ps.EndsAt, &ps.Priority, &ps.Label, &ps.Active, &ps.CreatedAt)
+}
+
+func (r *priceRepository) GetSchedules(productID uuid.UUID) ([]models.PriceSchedule, error) {
+
+	rows, err := r.db.Query(
+		"SELECT "+priceScheduleColumns+" FROM price_schedules WHERE product_id = $1 ORDER BY starts_at, priority DESC",
+		productID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []models.PriceSchedule
+	for rows.Next() {
+		var ps models.PriceSchedule
+		if err := scanPriceSchedule(rows, &ps); err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, ps)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+func (r *priceRepository) CreateSchedule(schedule *models.PriceSchedule) error {
+
+	query := `
+		INSERT INTO price_schedules (product_id, price, starts_at, ends_at, priority, label)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + priceScheduleColumns
+
+	err := scanPriceSchedule(r.db.QueryRow(query,
+		schedule.ProductID, schedule.Price, schedule.StartsAt, schedule.EndsAt, schedule.Priority, schedule.Label,
+	), schedule)
+	if err != nil {
+		if isForeignKeyError(err) {
+			return errors.New("product not found")
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *priceRepository) DeleteSchedule(productID, id uuid.UUID) error {
+
+	result, err := r.db.Exec("DELETE FROM price_schedules WHERE id = $1 AND product_id = $2", id, productID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("price schedule not found")
+	}
+
+	return nil
+}
+
+// MaterializeScheduled appends a price history entry for every product whose
+// effective price no longer matches its latest recorded price, i.e. a
+// schedule opened or closed since the last run. The entry is dated at the
+// schedule boundary when there is one, so lowest_price_30d stays accurate
+// even if the scheduler ran late, but never before the schedule was created:
+// a schedule written with a start in the past did not change the price then. An advisory lock keeps concurrent
+// instances from recording the same transition twice.
+func (r *priceRepository) MaterializeScheduled() (int64, error) {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var locked bool
+	if err := tx.QueryRow("SELECT pg_try_advisory_xact_lock(hashtext('price_schedules'))").Scan(&locked); err != nil {
+		return 0, err
+	}
+	if !locked {
+		return 0, nil
+	}
+
+	result, err := tx.Exec(`
+		WITH current AS (
+			SELECT p.id,
+			       effective_price(p.id, p.price) AS price,
+			       p.price AS list_price,
+			       last.price AS last_price,
+			       last.effective_at AS last_at,
+			       (
+			           SELECT MAX(GREATEST(b.at, ps.created_at))
+			           FROM price_schedules ps
+			           CROSS JOIN LATERAL (VALUES (ps.starts_at), (ps.ends_at)) AS b(at)
+			           WHERE ps.product_id = p.id AND b.at <= CURRENT_TIMESTAMP
+			       ) AS boundary_at
+			FROM products p
+			LEFT JOIN LATERAL (
+				SELECT ph.price, ph.effective_at
+				FROM price_history ph
+				WHERE ph.product_id = p.id AND ph.effective_at <= CURRENT_TIMESTAMP
+				ORDER BY ph.effective_at DESC, ph.id
+				LIMIT 1
+			) last ON true
+		)
+		INSERT INTO price_history (product_id, price, previous_price, list_price, reason, effective_at)
+		SELECT id, price, last_price, list_price, 'scheduled price change',
+		       CASE WHEN boundary_at > last_at THEN boundary_at ELSE CURRENT_TIMESTAMP END
+		FROM current
+		WHERE last_price IS DISTINCT FROM price
+	`)
+	if err != nil {
+		return 0, err
+	}
+
+	recorded, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return recorded, tx.Commit()
+}
+
+func insertPriceChange(tx *sql.Tx, c *models.PriceChange) error {
+
+	query := `
+		INSERT INTO price_history (product_id, price, previous_price, list_price, reason, actor)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, effective_at
+	`
+
+	return tx.QueryRow(query,
+		c.ProductID, c.Price, c.PreviousPrice, c.ListPrice, c.Reason, c.Actor,
+	).Scan(&c.ID, &c.EffectiveAt)
+}
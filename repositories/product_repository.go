@@ -4,6 +4,9 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/anggakrnwn/product-catalog-api/models"
@@ -13,11 +16,19 @@ import (
 type ProductRepository interface {
 	GetAll() ([]models.Product, error)
 	GetByID(id uuid.UUID) (*models.Product, error)
-	GetWithCategory(id uuid.UUID) (*models.ProductWithCategory, error)
+	GetWithCategory(id uuid.UUID, includeCost bool) (*models.ProductWithCategory, error)
 	Create(product *models.Product) error
-	Update(id uuid.UUID, product *models.Product) error
+	Update(id uuid.UUID, product *models.Product, actor string, stock *int) error
 	Delete(id uuid.UUID) error
 	GetByCategoryID(categoryID uuid.UUID) ([]models.Product, error)
+	GetByCategoryTree(categoryID uuid.UUID) ([]models.Product, error)
+	List(filter models.ProductFilter) ([]models.Product, error)
+	PriceRange(filter models.ProductFilter) (*int64, *int64, error)
+	Facets(filter models.ProductFilter, priceBounds []int64) (*models.ProductFacets, error)
+	FindBySKU(sku string) (*models.ProductLookup, error)
+	FindByBarcode(code string) (*models.ProductLookup, error)
+	FindBySlug(slug string) (*models.ProductLookup, error)
+	SlugTaken(slug string) (bool, error)
 }
 
 type productRepository struct {
@@ -29,29 +40,58 @@ func NewProductRepository(db *sql.DB) ProductRepository {
 }
 
 func (r *productRepository) GetAll() ([]models.Product, error) {
+	return r.List(models.ProductFilter{})
+}
+
+// List returns products matching filter, each with its category embedded.
+// With a warehouse filter, warehouse_stock carries the quantity held there.
+func (r *productRepository) List(filter models.ProductFilter) ([]models.Product, error) {
+
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	warehouseStock := "NULL::INT"
+	joins := ""
+	if filter.WarehouseID != nil {
+		warehouseStock = "COALESCE(sl.quantity, 0)"
+		joins += " LEFT JOIN stock_levels sl ON sl.product_id = p.id AND sl.warehouse_id = " + arg(*filter.WarehouseID)
+	}
+
+	where := productConditions(filter, arg)
+	orderBy := "p.name"
+	if filter.Query != "" {
+		orderBy = "ts_rank(p.search_vector, websearch_to_tsquery('simple', " + arg(filter.Query) + ")) DESC, p.name"
+	}
+
+	whereClause := whereSQL(where)
+
+	columns := publicProductColumns
+	if filter.IncludeCost {
+		columns = productColumns
+	}
 
 	query := `
-        SELECT 
-            p.id, 
-            p.name, 
-            p.price, 
-            p.stock, 
-            p.category_id, 
-            p.created_at, 
-            p.updated_at,
+        SELECT ` + columns + `,
             json_build_object(
                 'id', c.id,
                 'name', c.name,
+                'slug', c.slug,
                 'description', c.description,
                 'created_at', c.created_at,
                 'updated_at', c.updated_at
-            ) as category
+            ) as category,
+            ` + warehouseStock + `
         FROM products p
         LEFT JOIN categories c ON p.category_id = c.id
-        ORDER BY p.name
+        ` + joins + `
+        ` + whereClause + `
+        ORDER BY ` + orderBy + `
     `
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -62,12 +102,7 @@ func (r *productRepository) GetAll() ([]models.Product, error) {
 		var p models.Product
 		var categoryData []byte
 
-		err := rows.Scan(
-			&p.ID, &p.Name, &p.Price, &p.Stock,
-			&p.CategoryID, &p.CreatedAt, &p.UpdatedAt,
-			&categoryData,
-		)
-		if err != nil {
+		if err := scanProduct(rows, &p, &categoryData, &p.WarehouseStock); err != nil {
 			return nil, err
 		}
 
@@ -81,22 +116,209 @@ func (r *productRepository) GetAll() ([]models.Product, error) {
 		products = append(products, p)
 	}
 
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
 	return products, nil
 }
 
+// PriceRange returns the lowest and highest effective price among products
+// matching filter, or nils when none match.
+func (r *productRepository) PriceRange(filter models.ProductFilter) (*int64, *int64, error) {
+
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	query := "SELECT MIN(effective_price(p.id, p.price)), MAX(effective_price(p.id, p.price)) FROM products p " +
+		whereSQL(productConditions(filter, arg))
+
+	var min, max *int64
+	if err := r.db.QueryRow(query, args...).Scan(&min, &max); err != nil {
+		return nil, nil, err
+	}
+	return min, max, nil
+}
+
+// Facets counts the products matching filter by category, price bucket,
+// stock status and attribute value. Each facet is counted with its own
+// filter left out. priceBounds are the ascending lower bounds of the price
+// buckets; prices below the first one are not counted.
+func (r *productRepository) Facets(filter models.ProductFilter, priceBounds []int64) (*models.ProductFacets, error) {
+
+	facets := &models.ProductFacets{
+		Categories:  []models.CategoryFacet{},
+		Prices:      []models.PriceBucket{},
+		StockStatus: []models.FacetValue{},
+		Attributes:  []models.AttributeFacet{},
+	}
+
+	err := r.countFacet(filter, "SELECT COUNT(*) FROM products p", "", func(rows *sql.Rows) error {
+		return rows.Scan(&facets.Total)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byCategory := filter
+	byCategory.CategoryID = nil
+	err = r.countFacet(byCategory, "SELECT c.id, c.name, COUNT(*) FROM products p JOIN categories c ON c.id = p.category_id",
+		"GROUP BY c.id, c.name ORDER BY COUNT(*) DESC, c.name", func(rows *sql.Rows) error {
+			var f models.CategoryFacet
+			if err := rows.Scan(&f.ID, &f.Name, &f.Count); err != nil {
+				return err
+			}
+			facets.Categories = append(facets.Categories, f)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(priceBounds) > 0 {
+		for i, min := range priceBounds {
+			bucket := models.PriceBucket{Min: min}
+			if i+1 < len(priceBounds) {
+				max := priceBounds[i+1] - 1
+				bucket.Max = &max
+			}
+			facets.Prices = append(facets.Prices, bucket)
+		}
+
+		byPrice := filter
+		byPrice.MinPrice, byPrice.MaxPrice = nil, nil
+		bounds := "'{" + joinInt64(priceBounds) + "}'::bigint[]"
+		err = r.countFacet(byPrice, "SELECT width_bucket(effective_price(p.id, p.price), "+bounds+"), COUNT(*) FROM products p",
+			"GROUP BY 1", func(rows *sql.Rows) error {
+				var bucket int
+				var count int64
+				if err := rows.Scan(&bucket, &count); err != nil {
+					return err
+				}
+				// width_bucket numbers the buckets from 1
+				if bucket >= 1 && bucket <= len(facets.Prices) {
+					facets.Prices[bucket-1].Count = count
+				}
+				return nil
+			})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	statusCounts := make(map[string]int64)
+	byStatus := filter
+	byStatus.StockStatus = ""
+	err = r.countFacet(byStatus, "SELECT "+productStockStatus+", COUNT(*) FROM products p", "GROUP BY 1", func(rows *sql.Rows) error {
+		var status string
+		var count int64
+		if err := rows.Scan(&status, &count); err != nil {
+			return err
+		}
+		statusCounts[status] = count
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, status := range []string{models.StockStatusInStock, models.StockStatusLowStock, models.StockStatusOutOfStock} {
+		facets.StockStatus = append(facets.StockStatus, models.FacetValue{Value: status, Count: statusCounts[status]})
+	}
+
+	// attributes without a filter of their own share one pass; each filtered
+	// attribute is counted again without its filter
+	values := make(map[string][]models.FacetValue)
+	collect := func(rows *sql.Rows) error {
+		var name string
+		var v models.FacetValue
+		if err := rows.Scan(&name, &v.Value, &v.Count); err != nil {
+			return err
+		}
+		if len(values[name]) < models.MaxFacetValues {
+			values[name] = append(values[name], v)
+		}
+		return nil
+	}
+	const attributeSelect = "SELECT a.key, a.value, COUNT(*) FROM products p CROSS JOIN LATERAL jsonb_each_text(p.attributes) a"
+	const attributeGroup = "GROUP BY a.key, a.value ORDER BY a.key, COUNT(*) DESC, a.value"
+
+	filtered := make(map[string]bool)
+	for _, f := range filter.Attributes {
+		filtered[f.Name] = true
+	}
+	if err := r.countFacet(filter, attributeSelect, attributeGroup, collect); err != nil {
+		return nil, err
+	}
+	for name := range filtered {
+		delete(values, name)
+	}
+
+	for name := range filtered {
+		byAttribute := filter
+		byAttribute.Attributes = nil
+		for _, f := range filter.Attributes {
+			if f.Name != name {
+				byAttribute.Attributes = append(byAttribute.Attributes, f)
+			}
+		}
+		// names are plain identifiers, so one that passes the check is safe to inline
+		if err := models.ValidateAttributeName(name); err != nil {
+			return nil, err
+		}
+		if err := r.countFacet(byAttribute, attributeSelect, "AND a.key = '"+name+"' "+attributeGroup, collect); err != nil {
+			return nil, err
+		}
+	}
+
+	for name, v := range values {
+		facets.Attributes = append(facets.Attributes, models.AttributeFacet{Name: name, Values: v})
+	}
+	sort.Slice(facets.Attributes, func(i, j int) bool {
+		return facets.Attributes[i].Name < facets.Attributes[j].Name
+	})
+
+	return facets, nil
+}
+
+// countFacet runs selectSQL filtered by filter, followed by tail, and hands
+// each row to scan.
+func (r *productRepository) countFacet(filter models.ProductFilter, selectSQL, tail string, scan func(*sql.Rows) error) error {
+
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	query := selectSQL + " " + whereSQL(productConditions(filter, arg)) + " " + tail
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
 func (r *productRepository) GetByID(id uuid.UUID) (*models.Product, error) {
 
 	query := `
-		SELECT id, name, price, stock, category_id, created_at, updated_at 
-		FROM products 
-		WHERE id = $1
+		SELECT ` + productColumns + `
+		FROM products p
+		WHERE p.id = $1
 	`
 
 	var p models.Product
-	err := r.db.QueryRow(query, id).Scan(
-		&p.ID, &p.Name, &p.Price, &p.Stock,
-		&p.CategoryID, &p.CreatedAt, &p.UpdatedAt,
-	)
+	err := scanProduct(r.db.QueryRow(query, id), &p)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -109,12 +331,15 @@ func (r *productRepository) GetByID(id uuid.UUID) (*models.Product, error) {
 }
 
 // join
-func (r *productRepository) GetWithCategory(id uuid.UUID) (*models.ProductWithCategory, error) {
+func (r *productRepository) GetWithCategory(id uuid.UUID, includeCost bool) (*models.ProductWithCategory, error) {
+
+	columns := publicProductColumns
+	if includeCost {
+		columns = productColumns
+	}
 
 	query := `
-		SELECT 
-			p.id, p.name, p.price, p.stock, 
-			p.category_id, p.created_at, p.updated_at,
+		SELECT ` + columns + `,
 			c.name as category_name
 		FROM products p
 		LEFT JOIN categories c ON p.category_id = c.id
@@ -122,11 +347,7 @@ func (r *productRepository) GetWithCategory(id uuid.UUID) (*models.ProductWithCa
 	`
 
 	var result models.ProductWithCategory
-	err := r.db.QueryRow(query, id).Scan(
-		&result.ID, &result.Name, &result.Price, &result.Stock,
-		&result.CategoryID, &result.CreatedAt, &result.UpdatedAt,
-		&result.CategoryName,
-	)
+	err := scanProduct(r.db.QueryRow(query, id), &result.Product, &result.CategoryName)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -138,75 +359,236 @@ func (r *productRepository) GetWithCategory(id uuid.UUID) (*models.ProductWithCa
 	return &result, nil
 }
 
+// Create inserts the product and, when it starts with stock, the matching
+// receipt in the stock ledger and the default warehouse.
 func (r *productRepository) Create(product *models.Product) error {
 
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	attributesData, err := marshalAttributes(product.Attributes)
+	if err != nil {
+		return err
+	}
+
 	query := `
-		INSERT INTO products (name, price, stock, category_id) 
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO products (name, price, stock, category_id, low_stock_threshold, reorder_qty, tax_class_id, cost_price, sku, barcode, slug, short_description, description, attributes) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 		RETURNING id, created_at, updated_at
 	`
 
-	err := r.db.QueryRow(
+	err = tx.QueryRow(
 		query,
 		strings.TrimSpace(product.Name),
 		product.Price,
 		product.Stock,
 		product.CategoryID,
+		product.LowStockThreshold,
+		product.ReorderQty,
+		product.TaxClassID,
+		product.CostPrice,
+		product.SKU,
+		product.Barcode,
+		product.Slug,
+		product.ShortDescription,
+		product.Description,
+		attributesData,
 	).Scan(&product.ID, &product.CreatedAt, &product.UpdatedAt)
 
 	if err != nil {
+		if identifierErr := productIdentifierError(err); identifierErr != nil {
+			return identifierErr
+		}
+		if strings.Contains(err.Error(), "tax_class_id") {
+			return errors.New("tax class not found")
+		}
 		if strings.Contains(err.Error(), "foreign key constraint") {
 			return errors.New("category not found")
 		}
 		return err
 	}
 
-	return nil
+	product.Available = product.Stock
+	product.ListPrice = product.Price
+
+	err = insertPriceChange(tx, &models.PriceChange{
+		ProductID: product.ID,
+		Price:     product.Price,
+		ListPrice: &product.ListPrice,
+		Reason:    "initial price",
+	})
+	if err != nil {
+		return err
+	}
+
+	if product.Stock != 0 {
+		warehouseID, err := applyWarehouseDelta(tx, product.ID, nil, product.Stock)
+		if err != nil {
+			return err
+		}
+
+		err = insertStockMovement(tx, &models.StockMovement{
+			ProductID:    product.ID,
+			WarehouseID:  &warehouseID,
+			Type:         models.MovementReceipt,
+			Quantity:     product.Stock,
+			BalanceAfter: product.Stock,
+			UnitCost:     product.CostPrice,
+			Reason:       "initial stock",
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
-func (r *productRepository) Update(id uuid.UUID, product *models.Product) error {
+// Update writes everything except stock, which only changes through the
+// stock ledger: a non-nil stock is set there as part of the same transaction.
+// It also appends to the price history when the effective or the list price
+// moves, and keeps a replaced slug as a redirect.
+func (r *productRepository) Update(id uuid.UUID, product *models.Product, actor string, stock *int) error {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var previousPrice, previousListPrice int64
+	var previousSlug string
+	err = tx.QueryRow(
+		"SELECT effective_price(id, price), price, slug FROM products WHERE id = $1 FOR UPDATE", id,
+	).Scan(&previousPrice, &previousListPrice, &previousSlug)
+	if err == sql.ErrNoRows {
+		return errors.New("product not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	attributesData, err := marshalAttributes(product.Attributes)
+	if err != nil {
+		return err
+	}
 
 	query := `
 		UPDATE products 
-		SET name = $1, price = $2, stock = $3, 
-		    category_id = $4, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $5
-		RETURNING updated_at
+		SET name = $1, price = $2, 
+		    category_id = $3, low_stock_threshold = $4, reorder_qty = $5,
+		    tax_class_id = $6, cost_price = $7, sku = $8, barcode = $9, slug = $10,
+		    short_description = $11, description = $12, attributes = $13,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $14
 	`
 
-	result, err := r.db.Exec(
+	_, err = tx.Exec(
 		query,
 		strings.TrimSpace(product.Name),
-		product.Price,
-		product.Stock,
+		product.ListPrice,
 		product.CategoryID,
+		product.LowStockThreshold,
+		product.ReorderQty,
+		product.TaxClassID,
+		product.CostPrice,
+		product.SKU,
+		product.Barcode,
+		product.Slug,
+		product.ShortDescription,
+		product.Description,
+		attributesData,
 		id,
 	)
 
 	if err != nil {
+		if identifierErr := productIdentifierError(err); identifierErr != nil {
+			return identifierErr
+		}
+		if strings.Contains(err.Error(), "tax_class_id") {
+			return errors.New("tax class not found")
+		}
 		if strings.Contains(err.Error(), "foreign key constraint") {
 			return errors.New("category not found")
 		}
 		return err
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	if product.Slug != previousSlug {
+		_, err = tx.Exec(`
+			INSERT INTO product_slug_redirects (old_slug, product_id) VALUES ($1, $2)
+			ON CONFLICT (old_slug) DO UPDATE SET product_id = EXCLUDED.product_id, created_at = CURRENT_TIMESTAMP
+		`, previousSlug, id)
+		if err != nil {
+			return err
+		}
+
+		// the slug is live again, so it no longer redirects anywhere
+		if _, err := tx.Exec("DELETE FROM product_slug_redirects WHERE old_slug = $1", product.Slug); err != nil {
+			return err
+		}
+	}
+
+	var price int64
+	err = tx.QueryRow("SELECT effective_price(id, price) FROM products WHERE id = $1", id).Scan(&price)
 	if err != nil {
 		return err
 	}
 
-	if rowsAffected == 0 {
-		return errors.New("product not found")
+	// a sale schedule can hold the effective price while the list price moves
+	if price != previousPrice || product.ListPrice != previousListPrice {
+		reason := "price updated"
+		if price == previousPrice {
+			reason = "list price updated"
+		}
+		err = insertPriceChange(tx, &models.PriceChange{
+			ProductID:     id,
+			Price:         price,
+			PreviousPrice: &previousPrice,
+			ListPrice:     &product.ListPrice,
+			Reason:        reason,
+			Actor:         actor,
+		})
+		if err != nil {
+			return err
+		}
 	}
 
-	return nil
+	if stock != nil {
+		movement := &models.StockMovement{
+			ProductID: id,
+			Type:      models.MovementAdjustment,
+			Reason:    "stock set via product update",
+			Actor:     actor,
+		}
+		if err := setStock(tx, movement, *stock); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
+// Delete removes the product. Its relations go first, so the lists of the
+// products that pointed at it are left without gaps.
 func (r *productRepository) Delete(id uuid.UUID) error {
 
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteProductRelations(tx, id); err != nil {
+		return err
+	}
+
 	query := "DELETE FROM products WHERE id = $1"
 
-	result, err := r.db.Exec(query, id)
+	result, err := tx.Exec(query, id)
 	if err != nil {
 		return err
 	}
@@ -220,36 +602,305 @@ func (r *productRepository) Delete(id uuid.UUID) error {
 		return errors.New("product not found")
 	}
 
-	return nil
+	return tx.Commit()
 }
 
-func (r *productRepository) GetByCategoryID(categoryID uuid.UUID) ([]models.Product, error) {
+// FindBySKU matches product SKUs first and variant SKUs second.
+func (r *productRepository) FindBySKU(sku string) (*models.ProductLookup, error) {
+	return r.findByCode("sku", sku)
+}
 
-	query := `
-		SELECT id, name, price, stock, category_id, created_at, updated_at 
-		FROM products 
-		WHERE category_id = $1
-		ORDER BY name
-	`
+// FindByBarcode matches product barcodes first and variant barcodes second.
+func (r *productRepository) FindByBarcode(code string) (*models.ProductLookup, error) {
+	return r.findByCode("barcode", code)
+}
 
-	rows, err := r.db.Query(query, categoryID)
+// findByCode looks column up on products, then on product_variants. column
+// is one of the fixed identifier names, never client input.
+func (r *productRepository) findByCode(column, value string) (*models.ProductLookup, error) {
+
+	var lookup models.ProductLookup
+	err := r.db.QueryRow("SELECT id, slug FROM products WHERE "+column+" = $1", value).Scan(&lookup.ProductID, &lookup.Slug)
+	if err == nil {
+		return &lookup, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	var variantID uuid.UUID
+	err = r.db.QueryRow(`
+		SELECT p.id, p.slug, v.id
+		FROM product_variants v
+		JOIN products p ON p.id = v.product_id
+		WHERE v.`+column+` = $1
+		ORDER BY v.created_at
+		LIMIT 1
+	`, value).Scan(&lookup.ProductID, &lookup.Slug, &variantID)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("product not found")
+		}
 		return nil, err
 	}
-	defer rows.Close()
 
-	var products []models.Product
-	for rows.Next() {
-		var p models.Product
-		err := rows.Scan(
-			&p.ID, &p.Name, &p.Price, &p.Stock,
-			&p.CategoryID, &p.CreatedAt, &p.UpdatedAt,
-		)
-		if err != nil {
-			return nil, err
+	lookup.VariantID = &variantID
+	return &lookup, nil
+}
+
+// FindBySlug resolves a current slug, or an old one through its redirect.
+func (r *productRepository) FindBySlug(slug string) (*models.ProductLookup, error) {
+
+	var lookup models.ProductLookup
+	err := r.db.QueryRow("SELECT id, slug FROM products WHERE slug = $1", slug).Scan(&lookup.ProductID, &lookup.Slug)
+	if err == nil {
+		return &lookup, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	err = r.db.QueryRow(`
+		SELECT p.id, p.slug
+		FROM product_slug_redirects sr
+		JOIN products p ON p.id = sr.product_id
+		WHERE sr.old_slug = $1
+	`, slug).Scan(&lookup.ProductID, &lookup.Slug)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("product not found")
 		}
-		products = append(products, p)
+		return nil, err
 	}
 
-	return products, nil
+	lookup.Redirected = true
+	return &lookup, nil
+}
+
+// SlugTaken reports whether slug is in use, either live or as a redirect.
+func (r *productRepository) SlugTaken(slug string) (bool, error) {
+
+	var taken bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM products WHERE slug = $1)
+		    OR EXISTS (SELECT 1 FROM product_slug_redirects WHERE old_slug = $1)
+	`, slug).Scan(&taken)
+	return taken, err
+}
+
+func (r *productRepository) GetByCategoryID(categoryID uuid.UUID) ([]models.Product, error) {
+	return r.List(models.ProductFilter{CategoryID: &categoryID})
+}
+
+// GetByCategoryTree returns products in the category and all of its descendants.
+func (r *productRepository) GetByCategoryTree(categoryID uuid.UUID) ([]models.Product, error) {
+	return r.List(models.ProductFilter{CategoryID: &categoryID, IncludeDescendants: true})
+}
+
+// productReserved counts units held by active, unexpired reservations.
+const productReserved = `COALESCE((
+		SELECT SUM(rl.quantity)
+		FROM reservation_lines rl
+		JOIN reservations rv ON rv.id = rl.reservation_id
+		WHERE rl.product_id = p.id
+		  AND rv.status = 'active'
+		  AND rv.expires_at > CURRENT_TIMESTAMP
+	), 0)`
+
+// productStockStatus classifies available stock as out_of_stock, low_stock
+// (at or below the threshold) or in_stock.
+const productStockStatus = `CASE
+		WHEN p.stock - ` + productReserved + ` <= 0 THEN 'out_of_stock'
+		WHEN p.low_stock_threshold IS NOT NULL AND p.stock - ` + productReserved + ` <= p.low_stock_threshold THEN 'low_stock'
+		ELSE 'in_stock'
+	END`
+
+// productColumns is selected by every product read; queries alias products as p.
+// price is the effective price, list_price the stored one.
+// lowest_price_30d is the lowest price in effect during the 30 days before the
+// current price took effect, including the price that was already current
+// when that window opened but not the current price itself. Entries that only
+// moved the list price do not count as the current price taking effect. It
+// is NULL when the product has no earlier price.
+const productColumns = `
+	p.id, p.name, effective_price(p.id, p.price), p.stock, p.category_id, p.created_at, p.updated_at,
+	p.low_stock_threshold, p.reorder_qty, p.tax_class_id, p.cost_price,
+	p.sku, p.barcode, p.slug, p.short_description, p.description, p.attributes,
+	` + productReserved + ` AS reserved,
+	(
+		SELECT MIN(ph.price)
+		FROM price_history ph
+		JOIN LATERAL (
+			SELECT cur.id, cur.effective_at
+			FROM price_history cur
+			WHERE cur.product_id = p.id AND cur.effective_at <= CURRENT_TIMESTAMP
+			  AND cur.previous_price IS DISTINCT FROM cur.price
+			ORDER BY cur.effective_at DESC, cur.id
+			LIMIT 1
+		) cur ON true
+		WHERE ph.product_id = p.id
+		  AND ph.id <> cur.id
+		  AND ph.effective_at <= cur.effective_at
+		  AND ph.effective_at >= COALESCE((
+			SELECT MAX(prior.effective_at)
+			FROM price_history prior
+			WHERE prior.product_id = p.id
+			  AND prior.id <> cur.id
+			  AND prior.effective_at <= cur.effective_at - INTERVAL '30 days'
+		  ), '-infinity')
+	) AS lowest_price_30d,
+	p.price AS list_price
+`
+
+// publicProductColumns is productColumns without the cost, for reads that
+// are not for admins.
+var publicProductColumns = strings.Replace(productColumns, "p.cost_price", "NULL::BIGINT", 1)
+
+// scanProduct scans productColumns into p, followed by any extra columns.
+func scanProduct(row interface{ Scan(...any) error }, p *models.Product, extra ...any) error {
+
+	var attributesData []byte
+	dest := []any{
+		&p.ID, &p.Name, &p.Price, &p.Stock,
+		&p.CategoryID, &p.CreatedAt, &p.UpdatedAt,
+		&p.LowStockThreshold, &p.ReorderQty, &p.TaxClassID, &p.CostPrice,
+		&p.SKU, &p.Barcode, &p.Slug, &p.ShortDescription, &p.Description, &attributesData,
+		&p.Reserved, &p.LowestPrice30d, &p.ListPrice,
+	}
+
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(attributesData, &p.Attributes); err != nil {
+		return err
+	}
+
+	p.Available = p.Stock - p.Reserved
+	if p.Price < p.ListPrice {
+		listPrice := p.ListPrice
+		p.CompareAtPrice = &listPrice
+	}
+	return nil
+}
+
+// productConditions renders filter as conditions on products aliased p,
+// registering arguments through arg. Listings and facets share it so both
+// see the same product set.
+func productConditions(filter models.ProductFilter, arg func(any) string) []string {
+
+	var where []string
+	if len(filter.IDs) > 0 {
+		ids := make([]string, len(filter.IDs))
+		for i, id := range filter.IDs {
+			ids[i] = id.String()
+		}
+		where = append(where, "p.id = ANY("+arg(ids)+"::uuid[])")
+	}
+	if filter.Query != "" {
+		where = append(where, "p.search_vector @@ websearch_to_tsquery('simple', "+arg(filter.Query)+")")
+	}
+	for _, f := range filter.Attributes {
+		where = append(where, attributeCondition(f, arg))
+	}
+	if filter.CategoryID != nil {
+		if filter.IncludeDescendants {
+			where = append(where, `p.category_id IN (
+				WITH RECURSIVE tree AS (
+					SELECT id, 1 AS depth FROM categories WHERE id = `+arg(*filter.CategoryID)+`
+					UNION ALL
+					SELECT c.id, t.depth + 1
+					FROM categories c
+					JOIN tree t ON c.parent_id = t.id
+					WHERE t.depth < `+arg(models.MaxCategoryDepth)+`
+				)
+				SELECT id FROM tree
+			)`)
+		} else {
+			where = append(where, "p.category_id = "+arg(*filter.CategoryID))
+		}
+	}
+	if filter.MinPrice != nil {
+		where = append(where, "effective_price(p.id, p.price) >= "+arg(*filter.MinPrice))
+	}
+	if filter.MaxPrice != nil {
+		where = append(where, "effective_price(p.id, p.price) <= "+arg(*filter.MaxPrice))
+	}
+	if filter.StockStatus != "" {
+		where = append(where, productStockStatus+" = "+arg(filter.StockStatus))
+	}
+	if len(filter.Tags) > 0 {
+		tagged := `(
+			SELECT COUNT(*) FROM product_tags pt
+			JOIN tags t ON t.id = pt.tag_id
+			WHERE pt.product_id = p.id AND t.name = ANY(` + arg(filter.Tags) + `::text[])
+		)`
+		if filter.TagsMode == models.TagsModeAll {
+			where = append(where, tagged+" = "+arg(len(filter.Tags)))
+		} else {
+			where = append(where, tagged+" > 0")
+		}
+	}
+	return where
+}
+
+// attributeCondition renders an attribute filter. Equality compares the
+// text form of the value, so it works for every type; range operators only
+// match numeric values, whatever type the same name has in other categories.
+func attributeCondition(f models.AttributeFilter, arg func(any) string) string {
+
+	name := arg(f.Name)
+	if f.Op == models.AttributeEqual {
+		values := make([]string, len(f.Values))
+		for i, v := range f.Values {
+			values[i] = strings.ToLower(v)
+		}
+		return "lower(p.attributes->>" + name + ") = ANY(" + arg(values) + "::text[])"
+	}
+
+	return "(CASE WHEN jsonb_typeof(p.attributes->" + name + ") = 'number' THEN (p.attributes->>" + name + ")::numeric END) " +
+		f.Op + " " + arg(f.Values[0]) + "::numeric"
+}
+
+// whereSQL joins conditions into a WHERE clause. With no conditions it
+// still opens one, so callers can append further conditions with AND.
+func whereSQL(conditions []string) string {
+	if len(conditions) == 0 {
+		return "WHERE TRUE"
+	}
+	return "WHERE " + strings.Join(conditions, " AND ")
+}
+
+func joinInt64(values []int64) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.FormatInt(v, 10)
+	}
+	return strings.Join(parts, ",")
+}
+
+func marshalAttributes(attributes map[string]any) ([]byte, error) {
+	if attributes == nil {
+		attributes = map[string]any{}
+	}
+	return json.Marshal(attributes)
+}
+
+// productIdentifierError turns a unique violation on sku, barcode or slug,
+// or a sku or barcode a variant already uses, into a conflict the handler
+// can report.
+func productIdentifierError(err error) error {
+	switch {
+	case strings.Contains(err.Error(), "products_sku_key"):
+		return errors.New("product with this SKU already exists")
+	case strings.Contains(err.Error(), "products_barcode_key"):
+		return errors.New("product with this barcode already exists")
+	case strings.Contains(err.Error(), "variant_sku_clash"):
+		return errors.New("variant with this SKU already exists")
+	case strings.Contains(err.Error(), "variant_barcode_clash"):
+		return errors.New("variant with this barcode already exists")
+	case strings.Contains(err.Error(), "products_slug_key"):
+		return errors.New("product with this slug already exists")
+	}
+	return nil
 }
@@ -0,0 +1,361 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/google/uuid"
+)
+
+type PromotionRepository interface {
+	GetAll(runningAt *time.Time) ([]models.Promotion, error)
+	GetByID(id uuid.UUID) (*models.Promotion, error)
+	Create(promotion *models.Promotion) error
+	Update(id uuid.UUID, promotion *models.Promotion) error
+	Delete(id uuid.UUID) error
+	GetForCart(at time.Time, codes []string) ([]models.Promotion, error)
+	GetProductCategories(productIDs []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error)
+	Redeem(ids []uuid.UUID) error
+}
+
+type promotionRepository struct {
+	db *sql.DB
+}
+
+func NewPromotionRepository(db *sql.DB) PromotionRepository {
+	return &promotionRepository{db: db}
+}
+
+const promotionColumns = `id, name, code, type, scope, discount_bp, amount, buy_qty, get_qty, bundle_qty, bundle_price,
+	starts_at, ends_at, usage_limit, usage_count, priority, stackable, active, created_at, updated_at`
+
+func scanPromotion(row interface{ Scan(...any) error }, p *models.Promotion) error {
+	return row.Scan(
+		&p.ID, &p.Name, &p.Code, &p.Type, &p.Scope, &p.DiscountBP, &p.Amount, &p.BuyQty, &p.GetQty, &p.BundleQty, &p.BundlePrice,
+		&p.StartsAt, &p.EndsAt, &p.UsageLimit, &p.UsageCount, &p.Priority, &p.Stackable, &p.Active, &p.CreatedAt, &p.UpdatedAt,
+	)
+}
+
+// GetAll lists promotions by priority. With runningAt set only active
+// promotions whose window covers it are returned.
+func (r *promotionRepository) GetAll(runningAt *time.Time) ([]models.Promotion, error) {
+
+	query := "SELECT " + promotionColumns + " FROM promotions"
+	var args []any
+	if runningAt != nil {
+		query += " WHERE active AND (starts_at IS NULL OR starts_at <= $1) AND (ends_at IS NULL OR ends_at > $1)"
+		args = append(args, *runningAt)
+	}
+	query += " ORDER BY priority DESC, created_at, id"
+
+	return r.queryPromotions(query, args...)
+}
+
+func (r *promotionRepository) GetByID(id uuid.UUID) (*models.Promotion, error) {
+
+	var p models.Promotion
+	err := scanPromotion(r.db.QueryRow("SELECT "+promotionColumns+" FROM promotions WHERE id = $1", id), &p)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("promotion not found")
+		}
+		return nil, err
+	}
+
+	promotions := []models.Promotion{p}
+	if err := r.loadTargets(promotions); err != nil {
+		return nil, err
+	}
+
+	return &promotions[0], nil
+}
+
+func (r *promotionRepository) Create(promotion *models.Promotion) error {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO promotions (name, code, type, discount_bp, amount, buy_qty, get_qty, bundle_qty, bundle_price,
+			starts_at, ends_at, usage_limit, priority, stackable, active, scope)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		RETURNING id, usage_count, created_at, updated_at
+	`, promotion.Name, promotion.Code, promotion.Type, promotion.DiscountBP, promotion.Amount,
+		promotion.BuyQty, promotion.GetQty, promotion.BundleQty, promotion.BundlePrice,
+		promotion.StartsAt, promotion.EndsAt, promotion.UsageLimit, promotion.Priority, promotion.Stackable, promotion.Active,
+		promotion.Scope,
+	).Scan(&promotion.ID, &promotion.UsageCount, &promotion.CreatedAt, &promotion.UpdatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return errors.New("promotion with this code already exists")
+		}
+		return err
+	}
+
+	if err := insertPromotionTargets(tx, promotion); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Update rewrites the promotion and replaces its targets in one transaction.
+func (r *promotionRepository) Update(id uuid.UUID, promotion *models.Promotion) error {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		UPDATE promotions
+		SET name = $1, code = $2, discount_bp = $3, amount = $4, buy_qty = $5, get_qty = $6,
+			bundle_qty = $7, bundle_price = $8, starts_at = $9, ends_at = $10, usage_limit = $11,
+			priority = $12, stackable = $13, active = $14, scope = $15, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $16
+		RETURNING updated_at
+	`, promotion.Name, promotion.Code, promotion.DiscountBP, promotion.Amount, promotion.BuyQty, promotion.GetQty,
+		promotion.BundleQty, promotion.BundlePrice, promotion.StartsAt, promotion.EndsAt, promotion.UsageLimit,
+		promotion.Priority, promotion.Stackable, promotion.Active, promotion.Scope, id,
+	).Scan(&promotion.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("promotion not found")
+		}
+		if strings.Contains(err.Error(), "duplicate key") {
+			return errors.New("promotion with this code already exists")
+		}
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM promotion_targets WHERE promotion_id = $1", id); err != nil {
+		return err
+	}
+
+	if err := insertPromotionTargets(tx, promotion); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *promotionRepository) Delete(id uuid.UUID) error {
+
+	result, err := r.db.Exec("DELETE FROM promotions WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("promotion not found")
+	}
+
+	return nil
+}
+
+// GetForCart returns the automatic promotions running at at, plus every
+// promotion whose code is among codes whatever its state, so the caller can
+// explain why a coupon does not apply.
+func (r *promotionRepository) GetForCart(at time.Time, codes []string) ([]models.Promotion, error) {
+
+	if codes == nil {
+		codes = []string{}
+	}
+
+	return r.queryPromotions(`
+		SELECT `+promotionColumns+`
+		FROM promotions
+		WHERE code = ANY($2::text[])
+		   OR (code IS NULL AND active
+		       AND (starts_at IS NULL OR starts_at <= $1)
+		       AND (ends_at IS NULL OR ends_at > $1))
+		ORDER BY priority DESC, created_at, id
+	`, at, codes)
+}
+
+// GetProductCategories maps each product to its category and every ancestor
+// of it, so category promotions reach products in subcategories.
+func (r *promotionRepository) GetProductCategories(productIDs []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error) {
+
+	ids := make([]string, len(productIDs))
+	for i, id := range productIDs {
+		ids[i] = id.String()
+	}
+
+	rows, err := r.db.Query(`
+		WITH RECURSIVE chain AS (
+			SELECT p.id AS product_id, p.category_id, 1 AS depth
+			FROM products p
+			WHERE p.id = ANY($1::uuid[]) AND p.category_id IS NOT NULL
+			UNION ALL
+			SELECT ch.product_id, c.parent_id, ch.depth + 1
+			FROM chain ch
+			JOIN categories c ON c.id = ch.category_id
+			WHERE c.parent_id IS NOT NULL AND ch.depth < $2
+		)
+		SELECT product_id, category_id FROM chain
+	`, ids, models.MaxCategoryDepth)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := make(map[uuid.UUID][]uuid.UUID)
+	for rows.Next() {
+		var productID, categoryID uuid.UUID
+		if err := rows.Scan(&productID, &categoryID); err != nil {
+			return nil, err
+		}
+		categories[productID] = append(categories[productID], categoryID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+// Redeem counts one use of every promotion in ids. It fails without counting
+// anything if any of them has reached its usage limit in the meantime.
+func (r *promotionRepository) Redeem(ids []uuid.UUID) error {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, id := range ids {
+		result, err := tx.Exec(`
+			UPDATE promotions
+			SET usage_count = usage_count + 1
+			WHERE id = $1 AND (usage_limit IS NULL OR usage_count < usage_limit)
+		`, id)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return fmt.Errorf("promotion %s usage limit reached", id)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *promotionRepository) queryPromotions(query string, args ...any) ([]models.Promotion, error) {
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var promotions []models.Promotion
+	for rows.Next() {
+		var p models.Promotion
+		if err := scanPromotion(rows, &p); err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.loadTargets(promotions); err != nil {
+		return nil, err
+	}
+
+	return promotions, nil
+}
+
+// loadTargets fills ProductIDs and CategoryIDs on promotions in one query.
+func (r *promotionRepository) loadTargets(promotions []models.Promotion) error {
+
+	if len(promotions) == 0 {
+		return nil
+	}
+
+	index := make(map[uuid.UUID]int, len(promotions))
+	ids := make([]string, len(promotions))
+	for i := range promotions {
+		index[promotions[i].ID] = i
+		ids[i] = promotions[i].ID.String()
+		promotions[i].ProductIDs = []uuid.UUID{}
+		promotions[i].CategoryIDs = []uuid.UUID{}
+	}
+
+	rows, err := r.db.Query(`
+		SELECT promotion_id, product_id, category_id
+		FROM promotion_targets
+		WHERE promotion_id = ANY($1::uuid[])
+		ORDER BY promotion_id, product_id, category_id
+	`, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var promotionID uuid.UUID
+		var productID, categoryID *uuid.UUID
+		if err := rows.Scan(&promotionID, &productID, &categoryID); err != nil {
+			return err
+		}
+		p := &promotions[index[promotionID]]
+		if productID != nil {
+			p.ProductIDs = append(p.ProductIDs, *productID)
+		}
+		if categoryID != nil {
+			p.CategoryIDs = append(p.CategoryIDs, *categoryID)
+		}
+	}
+
+	return rows.Err()
+}
+
+func insertPromotionTargets(tx *sql.Tx, promotion *models.Promotion) error {
+
+	for _, id := range promotion.ProductIDs {
+		_, err := tx.Exec("INSERT INTO promotion_targets (promotion_id, product_id) VALUES ($1, $2)", promotion.ID, id)
+		if err != nil {
+			if isForeignKeyError(err) {
+				return errors.New("product " + id.String() + " not found")
+			}
+			return err
+		}
+	}
+
+	for _, id := range promotion.CategoryIDs {
+		_, err := tx.Exec("INSERT INTO promotion_targets (promotion_id, category_id) VALUES ($1, $2)", promotion.ID, id)
+		if err != nil {
+			if isForeignKeyError(err) {
+				return errors.New("category " + id.String() + " not found")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,273 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/google/uuid"
+)
+
+type RelationRepository interface {
+	GetByProduct(productID uuid.UUID) (map[string][]models.RelatedProduct, error)
+	Set(productID uuid.UUID, relationType string, relatedIDs []uuid.UUID) error
+	Remove(productID uuid.UUID, relationType string, relatedID uuid.UUID) error
+}
+
+type relationRepository struct {
+	db *sql.DB
+}
+
+func NewRelationRepository(db *sql.DB) RelationRepository {
+	return &relationRepository{db: db}
+}
+
+// GetByProduct returns the product's relations keyed by type, each in
+// position order.
+func (r *relationRepository) GetByProduct(productID uuid.UUID) (map[string][]models.RelatedProduct, error) {
+
+	rows, err := r.db.Query(`
+		SELECT pr.type, p.id, p.name, p.slug, p.sku, effective_price(p.id, p.price), pr.position
+		FROM product_relations pr
+		JOIN products p ON p.id = pr.related_id
+		WHERE pr.product_id = $1
+		ORDER BY pr.type, pr.position
+	`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string][]models.RelatedProduct)
+	for rows.Next() {
+		var relationType string
+		var rp models.RelatedProduct
+		if err := rows.Scan(&relationType, &rp.ID, &rp.Name, &rp.Slug, &rp.SKU, &rp.Price, &rp.Position); err != nil {
+			return nil, err
+		}
+		result[relationType] = append(result[relationType], rp)
+	}
+	return result, rows.Err()
+}
+
+// Set replaces the product's list of relatedIDs under relationType. For
+// symmetric types, products dropped from the list lose the way back and
+// products added to it get it, at the end of their own list.
+func (r *relationRepository) Set(productID uuid.UUID, relationType string, relatedIDs []uuid.UUID) error {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	ids := make([]string, len(relatedIDs))
+	for i, id := range relatedIDs {
+		ids[i] = id.String()
+	}
+
+	var locked int
+	err = tx.QueryRow(
+		"SELECT COUNT(*) FROM (SELECT 1 FROM products WHERE id = $1 OR id = ANY($2::uuid[]) FOR UPDATE) p",
+		productID, ids,
+	).Scan(&locked)
+	if err != nil {
+		return err
+	}
+	if locked != len(relatedIDs)+1 {
+		return errors.New("product not found")
+	}
+
+	rows, err := tx.Query(
+		"SELECT related_id FROM product_relations WHERE product_id = $1 AND type = $2", productID, relationType,
+	)
+	if err != nil {
+		return err
+	}
+	previous := make(map[uuid.UUID]bool)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		previous[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM product_relations WHERE product_id = $1 AND type = $2", productID, relationType); err != nil {
+		return err
+	}
+	for position, relatedID := range relatedIDs {
+		_, err := tx.Exec(
+			"INSERT INTO product_relations (product_id, related_id, type, position) VALUES ($1, $2, $3, $4)",
+			productID, relatedID, relationType, position,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	if models.SymmetricRelation(relationType) {
+		current := make(map[uuid.UUID]bool, len(relatedIDs))
+		for _, relatedID := range relatedIDs {
+			current[relatedID] = true
+			if previous[relatedID] {
+				continue
+			}
+			if err := appendRelation(tx, relatedID, productID, relationType); err != nil {
+				return err
+			}
+		}
+		for relatedID := range previous {
+			if current[relatedID] {
+				continue
+			}
+			if err := removeRelation(tx, relatedID, productID, relationType); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *relationRepository) Remove(productID uuid.UUID, relationType string, relatedID uuid.UUID) error {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		"DELETE FROM product_relations WHERE product_id = $1 AND related_id = $2 AND type = $3",
+		productID, relatedID, relationType,
+	)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("relation not found")
+	}
+
+	if err := renumberRelations(tx, productID, relationType); err != nil {
+		return err
+	}
+	if models.SymmetricRelation(relationType) {
+		if err := removeRelation(tx, relatedID, productID, relationType); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// appendRelation adds relatedID at the end of productID's list, unless it is
+// already there.
+func appendRelation(tx *sql.Tx, productID, relatedID uuid.UUID, relationType string) error {
+
+	result, err := tx.Exec(`
+		INSERT INTO product_relations (product_id, related_id, type, position)
+		SELECT $1, $2, $3, COALESCE(MAX(position) + 1, 0)
+		FROM product_relations
+		WHERE product_id = $1 AND type = $3
+		ON CONFLICT (product_id, type, related_id) DO NOTHING
+	`, productID, relatedID, relationType)
+	if err != nil {
+		return err
+	}
+
+	if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+		return err
+	}
+
+	var count int
+	err = tx.QueryRow(
+		"SELECT COUNT(*) FROM product_relations WHERE product_id = $1 AND type = $2", productID, relationType,
+	).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > models.MaxRelationsPerType {
+		return fmt.Errorf("product %s cannot have more than %d %s products", productID, models.MaxRelationsPerType, relationType)
+	}
+	return nil
+}
+
+// removeRelation drops relatedID from productID's list and closes the gap.
+func removeRelation(tx *sql.Tx, productID, relatedID uuid.UUID, relationType string) error {
+
+	_, err := tx.Exec(
+		"DELETE FROM product_relations WHERE product_id = $1 AND related_id = $2 AND type = $3",
+		productID, relatedID, relationType,
+	)
+	if err != nil {
+		return err
+	}
+	return renumberRelations(tx, productID, relationType)
+}
+
+// renumberRelations makes the positions of one list consecutive from zero.
+func renumberRelations(tx *sql.Tx, productID uuid.UUID, relationType string) error {
+
+	_, err := tx.Exec(`
+		UPDATE product_relations pr
+		SET position = ordered.rn - 1
+		FROM (
+			SELECT related_id, ROW_NUMBER() OVER (ORDER BY position, created_at) AS rn
+			FROM product_relations
+			WHERE product_id = $1 AND type = $2
+		) ordered
+		WHERE pr.product_id = $1 AND pr.type = $2 AND pr.related_id = ordered.related_id
+	`, productID, relationType)
+	return err
+}
+
+// deleteProductRelations removes every relation from or to the product and
+// closes the gaps it leaves in other products' lists.
+func deleteProductRelations(tx *sql.Tx, productID uuid.UUID) error {
+
+	rows, err := tx.Query(
+		"DELETE FROM product_relations WHERE related_id = $1 RETURNING product_id, type", productID,
+	)
+	if err != nil {
+		return err
+	}
+	type list struct {
+		productID    uuid.UUID
+		relationType string
+	}
+	var affected []list
+	for rows.Next() {
+		var l list
+		if err := rows.Scan(&l.productID, &l.relationType); err != nil {
+			rows.Close()
+			return err
+		}
+		affected = append(affected, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM product_relations WHERE product_id = $1", productID); err != nil {
+		return err
+	}
+
+	for _, l := range affected {
+		if err := renumberRelations(tx, l.productID, l.relationType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
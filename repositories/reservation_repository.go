@@ -0,0 +1,347 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/google/uuid"
+)
+
+type ReservationRepository interface {
+	Create(reservation *models.Reservation) error
+	GetByID(id uuid.UUID) (*models.Reservation, error)
+	Confirm(id uuid.UUID, actor string) error
+	Release(id uuid.UUID) error
+	ExpireStale() (int64, error)
+}
+
+type reservationRepository struct {
+	db *sql.DB
+}
+
+func NewReservationRepository(db *sql.DB) ReservationRepository {
+	return &reservationRepository{db: db}
+}
+
+// Create holds stock for every line or for none of them. Product rows, and
+// then the rows of their variants, are locked in the order of
+// reservation.Lines, which callers sort by product and variant ID, so two
+// checkouts racing for the last unit are serialized instead of deadlocking.
+// A product with variants can only be held per variant, checked under the
+// same locks.
+func (r *reservationRepository) Create(reservation *models.Reservation) error {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// a product's lines share its stock, so check them against it together
+	held := make(map[uuid.UUID]int)
+	for _, line := range reservation.Lines {
+		stock, reserved, err := lockStock(tx, line.ProductID)
+		if err != nil {
+			if err.Error() == "product not found" {
+				return fmt.Errorf("product %s not found", line.ProductID)
+			}
+			return err
+		}
+
+		held[line.ProductID] += line.Quantity
+		if available := stock - reserved; available < held[line.ProductID] {
+			return fmt.Errorf("insufficient stock for product %s: %d available", line.ProductID, available)
+		}
+
+		if line.VariantID == nil {
+			var hasVariants bool
+			err := tx.QueryRow(
+				"SELECT EXISTS (SELECT 1 FROM product_variants WHERE product_id = $1)", line.ProductID,
+			).Scan(&hasVariants)
+			if err != nil {
+				return err
+			}
+			if hasVariants {
+				return fmt.Errorf("variant_id is required for product %s, it has variants", line.ProductID)
+			}
+			continue
+		}
+
+		stock, reserved, err = lockVariantStock(tx, line.ProductID, *line.VariantID)
+		if err != nil {
+			return err
+		}
+		if available := stock - reserved; available < line.Quantity {
+			return fmt.Errorf("insufficient stock for variant %s: %d available", *line.VariantID, available)
+		}
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO reservations (status, reference, expires_at)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at
+	`, models.ReservationActive, reservation.Reference, reservation.ExpiresAt).Scan(
+		&reservation.ID, &reservation.CreatedAt, &reservation.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	reservation.Status = models.ReservationActive
+
+	for _, line := range reservation.Lines {
+		_, err := tx.Exec(
+			"INSERT INTO reservation_lines (reservation_id, product_id, variant_id, quantity) VALUES ($1, $2, $3, $4)",
+			reservation.ID, line.ProductID, line.VariantID, line.Quantity,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// lockVariantStock locks a variant of productID and returns its stock and
+// the units active reservations hold of it.
+func lockVariantStock(tx *sql.Tx, productID, variantID uuid.UUID) (stock, reserved int, err error) {
+
+	err = tx.QueryRow(
+		"SELECT stock FROM product_variants WHERE id = $1 AND product_id = $2 FOR UPDATE", variantID, productID,
+	).Scan(&stock)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, 0, fmt.Errorf("variant %s not found", variantID)
+		}
+		return 0, 0, err
+	}
+
+	err = tx.QueryRow(`
+		SELECT COALESCE(SUM(rl.quantity), 0)
+		FROM reservation_lines rl
+		JOIN reservations rv ON rv.id = rl.reservation_id
+		WHERE rl.variant_id = $1
+		  AND rv.status = 'active'
+		  AND rv.expires_at > CURRENT_TIMESTAMP
+	`, variantID).Scan(&reserved)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return stock, reserved, nil
+}
+
+func (r *reservationRepository) GetByID(id uuid.UUID) (*models.Reservation, error) {
+
+	var res models.Reservation
+	err := r.db.QueryRow(`
+		SELECT id, status, reference, expires_at, created_at, updated_at
+		FROM reservations
+		WHERE id = $1
+	`, id).Scan(&res.ID, &res.Status, &res.Reference, &res.ExpiresAt, &res.CreatedAt, &res.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("reservation not found")
+		}
+		return nil, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT product_id, variant_id, quantity
+		FROM reservation_lines
+		WHERE reservation_id = $1
+		ORDER BY product_id, variant_id NULLS FIRST
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var line models.ReservationLine
+		if err := rows.Scan(&line.ProductID, &line.VariantID, &line.Quantity); err != nil {
+			return nil, err
+		}
+		res.Lines = append(res.Lines, line)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// Confirm turns the hold into a sale: stock is decremented through the ledger,
+// along with the variant's for a variant line, and the reservation stops
+// counting towards reserved.
+func (r *reservationRepository) Confirm(id uuid.UUID, actor string) error {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status string
+	var expired bool
+	err = tx.QueryRow(`
+		SELECT status, expires_at <= CURRENT_TIMESTAMP
+		FROM reservations
+		WHERE id = $1
+		FOR UPDATE
+	`, id).Scan(&status, &expired)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("reservation not found")
+		}
+		return err
+	}
+
+	if status != models.ReservationActive {
+		return fmt.Errorf("reservation is %s and cannot be confirmed", status)
+	}
+	if expired {
+		return errors.New("reservation has expired and cannot be confirmed")
+	}
+
+	rows, err := tx.Query(
+		"SELECT product_id, variant_id, quantity FROM reservation_lines WHERE reservation_id = $1 ORDER BY product_id, variant_id NULLS FIRST",
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	var lines []models.ReservationLine
+	for rows.Next() {
+		var line models.ReservationLine
+		if err := rows.Scan(&line.ProductID, &line.VariantID, &line.Quantity); err != nil {
+			rows.Close()
+			return err
+		}
+		lines = append(lines, line)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, line := range lines {
+		var balance int
+		err := tx.QueryRow(`
+			UPDATE products
+			SET stock = stock - $1, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $2 AND stock - $1 >= 0
+			RETURNING stock
+		`, line.Quantity, line.ProductID).Scan(&balance)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("insufficient stock for product %s", line.ProductID)
+			}
+			return err
+		}
+
+		// the product's stock is the sum of its variants, so both move
+		if line.VariantID != nil {
+			result, err := tx.Exec(`
+				UPDATE product_variants
+				SET stock = stock - $1, updated_at = CURRENT_TIMESTAMP
+				WHERE id = $2 AND stock - $1 >= 0
+			`, line.Quantity, *line.VariantID)
+			if err != nil {
+				return err
+			}
+			rowsAffected, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+			if rowsAffected == 0 {
+				return fmt.Errorf("insufficient stock for variant %s", *line.VariantID)
+			}
+		}
+
+		allocations, err := drawFromWarehouses(tx, line.ProductID, line.Quantity)
+		if err != nil {
+			return err
+		}
+
+		// one ledger entry per warehouse the units came from
+		balance += line.Quantity
+		for _, a := range allocations {
+			balance -= a.Quantity
+			warehouseID := a.WarehouseID
+			movement := &models.StockMovement{
+				ProductID:    line.ProductID,
+				WarehouseID:  &warehouseID,
+				Type:         models.MovementSale,
+				Quantity:     -a.Quantity,
+				BalanceAfter: balance,
+				Reason:       "reservation confirmed",
+				Reference:    "reservation:" + id.String(),
+				Actor:        actor,
+			}
+			if err := insertStockMovement(tx, movement); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = tx.Exec(
+		"UPDATE reservations SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2",
+		models.ReservationConfirmed, id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *reservationRepository) Release(id uuid.UUID) error {
+
+	result, err := r.db.Exec(`
+		UPDATE reservations
+		SET status = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND status = $3
+	`, models.ReservationReleased, id, models.ReservationActive)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		var status string
+		err := r.db.QueryRow("SELECT status FROM reservations WHERE id = $1", id).Scan(&status)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return errors.New("reservation not found")
+			}
+			return err
+		}
+		return fmt.Errorf("reservation is %s and cannot be released", status)
+	}
+
+	return nil
+}
+
+// ExpireStale marks active reservations past their TTL as expired. Reads
+// already ignore them, this just keeps the status column honest.
+func (r *reservationRepository) ExpireStale() (int64, error) {
+
+	result, err := r.db.Exec(`
+		UPDATE reservations
+		SET status = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE status = $2 AND expires_at <= CURRENT_TIMESTAMP
+	`, models.ReservationExpired, models.ReservationActive)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,380 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/google/uuid"
+)
+
+type StockRepository interface {
+	Adjust(movement *models.StockMovement) error
+	Set(movement *models.StockMovement, stock int) error
+	GetHistory(productID uuid.UUID, limit, offset int) ([]models.StockMovement, int, error)
+	GetLevels(productID uuid.UUID) ([]models.StockLevel, error)
+}
+
+type stockRepository struct {
+	db *sql.DB
+}
+
+func NewStockRepository(db *sql.DB) StockRepository {
+	return &stockRepository{db: db}
+}
+
+// Adjust applies movement.Quantity to the product stock and to the stock level
+// of movement.WarehouseID (the default warehouse when nil), and writes the
+// ledger entry, all in the same transaction. A decrement may not dig into
+// units held by active reservations.
+func (r *stockRepository) Adjust(movement *models.StockMovement) error {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stock, reserved, err := lockStock(tx, movement.ProductID)
+	if err != nil {
+		return err
+	}
+	if movement.Quantity < 0 && stock+movement.Quantity < reserved {
+		return fmt.Errorf("insufficient stock: %d available", stock-reserved)
+	}
+
+	err = tx.QueryRow(`
+		UPDATE products
+		SET stock = stock + $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+		RETURNING stock
+	`, movement.Quantity, movement.ProductID).Scan(&movement.BalanceAfter)
+	if err != nil {
+		return err
+	}
+
+	warehouseID, err := applyWarehouseDelta(tx, movement.ProductID, movement.WarehouseID, movement.Quantity)
+	if err != nil {
+		return err
+	}
+	movement.WarehouseID = &warehouseID
+
+	if err := insertStockMovement(tx, movement); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Set moves the product stock to an absolute value, recording the difference
+// in the ledger: an increase lands in the default warehouse and a decrease is
+// drawn from the warehouses holding the units. Nothing is written when
+// the stock already matches. Lowering it below the units held by active
+// reservations is refused.
+func (r *stockRepository) Set(movement *models.StockMovement, stock int) error {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := setStock(tx, movement, stock); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// setStock is Set inside an open transaction, for writes that have to land
+// together with the stock change. An increase goes to the default warehouse.
+// A decrease spread over several warehouses writes one entry per warehouse,
+// and movement is left holding the last one.
+func setStock(tx *sql.Tx, movement *models.StockMovement, stock int) error {
+
+	current, reserved, err := lockStock(tx, movement.ProductID)
+	if err != nil {
+		return err
+	}
+
+	if current == stock {
+		return nil
+	}
+	if stock < current && stock < reserved {
+		return fmt.Errorf("insufficient stock: %d units are held by reservations", reserved)
+	}
+
+	_, err = tx.Exec(
+		"UPDATE products SET stock = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2",
+		stock, movement.ProductID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if stock > current {
+		movement.Quantity = stock - current
+		movement.BalanceAfter = stock
+
+		warehouseID, err := applyWarehouseDelta(tx, movement.ProductID, nil, movement.Quantity)
+		if err != nil {
+			return err
+		}
+		movement.WarehouseID = &warehouseID
+
+		return insertStockMovement(tx, movement)
+	}
+
+	// a decrease comes out of whichever warehouses hold the units, as a
+	// confirmed reservation does, with one ledger entry per warehouse
+	allocations, err := drawFromWarehouses(tx, movement.ProductID, current-stock)
+	if err != nil {
+		return err
+	}
+
+	template := *movement
+	balance := current
+	for _, a := range allocations {
+		balance -= a.Quantity
+		warehouseID := a.WarehouseID
+		*movement = template
+		movement.WarehouseID = &warehouseID
+		movement.Quantity = -a.Quantity
+		movement.BalanceAfter = balance
+		if err := insertStockMovement(tx, movement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *stockRepository) GetHistory(productID uuid.UUID, limit, offset int) ([]models.StockMovement, int, error) {
+
+	var total int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM stock_movements WHERE product_id = $1", productID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT id, product_id, warehouse_id, transfer_id, type, quantity, balance_after,
+		       unit_cost, reason, reference, actor, created_at
+		FROM stock_movements
+		WHERE product_id = $1
+		ORDER BY created_at DESC, id
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.Query(query, productID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var movements []models.StockMovement
+	for rows.Next() {
+		var m models.StockMovement
+		err := rows.Scan(
+			&m.ID, &m.ProductID, &m.WarehouseID, &m.TransferID, &m.Type, &m.Quantity, &m.BalanceAfter,
+			&m.UnitCost, &m.Reason, &m.Reference, &m.Actor, &m.CreatedAt,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		movements = append(movements, m)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return movements, total, nil
+}
+
+func (r *stockRepository) GetLevels(productID uuid.UUID) ([]models.StockLevel, error) {
+
+	query := `
+		SELECT sl.product_id, sl.warehouse_id, w.code, sl.quantity, sl.updated_at
+		FROM stock_levels sl
+		JOIN warehouses w ON w.id = sl.warehouse_id
+		WHERE sl.product_id = $1
+		ORDER BY w.is_default DESC, w.code
+	`
+
+	rows, err := r.db.Query(query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var levels []models.StockLevel
+	for rows.Next() {
+		var l models.StockLevel
+		if err := rows.Scan(&l.ProductID, &l.WarehouseID, &l.WarehouseCode, &l.Quantity, &l.UpdatedAt); err != nil {
+			return nil, err
+		}
+		levels = append(levels, l)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return levels, nil
+}
+
+// lockStock locks the product row, as reservations do, and returns its stock
+// and the units held by active, unexpired reservations.
+func lockStock(tx *sql.Tx, productID uuid.UUID) (stock, reserved int, err error) {
+
+	err = tx.QueryRow("SELECT stock FROM products WHERE id = $1 FOR UPDATE", productID).Scan(&stock)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, 0, errors.New("product not found")
+		}
+		return 0, 0, err
+	}
+
+	err = tx.QueryRow(`
+		SELECT COALESCE(SUM(rl.quantity), 0)
+		FROM reservation_lines rl
+		JOIN reservations rv ON rv.id = rl.reservation_id
+		WHERE rl.product_id = $1
+		  AND rv.status = 'active'
+		  AND rv.expires_at > CURRENT_TIMESTAMP
+	`, productID).Scan(&reserved)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return stock, reserved, nil
+}
+
+// insertStockMovement records a ledger entry inside a transaction that has
+// already changed products.stock.
+func insertStockMovement(tx *sql.Tx, m *models.StockMovement) error {
+
+	query := `
+		INSERT INTO stock_movements (product_id, warehouse_id, transfer_id, type, quantity, balance_after, unit_cost, reason, reference, actor)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at
+	`
+
+	return tx.QueryRow(query,
+		m.ProductID, m.WarehouseID, m.TransferID, m.Type, m.Quantity, m.BalanceAfter,
+		m.UnitCost, m.Reason, m.Reference, m.Actor,
+	).Scan(&m.ID, &m.CreatedAt)
+}
+
+// applyWarehouseDelta changes one product's stock level in one warehouse
+// (the default warehouse when warehouseID is nil) and returns the warehouse used.
+func applyWarehouseDelta(tx *sql.Tx, productID uuid.UUID, warehouseID *uuid.UUID, delta int) (uuid.UUID, error) {
+
+	var id uuid.UUID
+	if warehouseID != nil {
+		id = *warehouseID
+	} else {
+		err := tx.QueryRow("SELECT id FROM warehouses WHERE is_default").Scan(&id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return uuid.Nil, errors.New("default warehouse not found")
+			}
+			return uuid.Nil, err
+		}
+	}
+
+	if delta >= 0 {
+		_, err := tx.Exec(`
+			INSERT INTO stock_levels (product_id, warehouse_id, quantity)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (product_id, warehouse_id)
+			DO UPDATE SET quantity = stock_levels.quantity + EXCLUDED.quantity, updated_at = CURRENT_TIMESTAMP
+		`, productID, id, delta)
+		if err != nil {
+			if isForeignKeyError(err) {
+				return uuid.Nil, errors.New("warehouse not found")
+			}
+			return uuid.Nil, err
+		}
+		return id, nil
+	}
+
+	result, err := tx.Exec(`
+		UPDATE stock_levels
+		SET quantity = quantity + $1, updated_at = CURRENT_TIMESTAMP
+		WHERE product_id = $2 AND warehouse_id = $3 AND quantity + $1 >= 0
+	`, delta, productID, id)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if rowsAffected == 0 {
+		return uuid.Nil, errors.New("insufficient stock in warehouse")
+	}
+
+	return id, nil
+}
+
+// stockAllocation is the part of a decrement taken from one warehouse.
+type stockAllocation struct {
+	WarehouseID uuid.UUID
+	Quantity    int
+}
+
+// drawFromWarehouses takes quantity units of a product out of its warehouses,
+// default warehouse first and then the fullest ones.
+func drawFromWarehouses(tx *sql.Tx, productID uuid.UUID, quantity int) ([]stockAllocation, error) {
+
+	rows, err := tx.Query(`
+		SELECT sl.warehouse_id, sl.quantity
+		FROM stock_levels sl
+		JOIN warehouses w ON w.id = sl.warehouse_id
+		WHERE sl.product_id = $1 AND sl.quantity > 0
+		ORDER BY w.is_default DESC, sl.quantity DESC
+		FOR UPDATE OF sl
+	`, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	var allocations []stockAllocation
+	remaining := quantity
+	for rows.Next() && remaining > 0 {
+		var a stockAllocation
+		var onHand int
+		if err := rows.Scan(&a.WarehouseID, &onHand); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		a.Quantity = min(onHand, remaining)
+		remaining -= a.Quantity
+		allocations = append(allocations, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if remaining > 0 {
+		return nil, errors.New("insufficient stock in warehouses")
+	}
+
+	for _, a := range allocations {
+		if _, err := applyWarehouseDelta(tx, productID, &a.WarehouseID, -a.Quantity); err != nil {
+			return nil, err
+		}
+	}
+
+	return allocations, nil
+}
+
+func isForeignKeyError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "foreign key constraint")
+}
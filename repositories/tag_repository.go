@@ -0,0 +1,310 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/google/uuid"
+)
+
+type TagRepository interface {
+	GetAll() ([]models.Tag, error)
+	GetByID(id uuid.UUID) (*models.Tag, error)
+	FindByName(name string) (*models.Tag, error)
+	Create(tag *models.Tag) error
+	Rename(id uuid.UUID, name string) error
+	Delete(id uuid.UUID) error
+	Merge(sourceID, targetID uuid.UUID) (*models.MergeTagResult, error)
+	GetByProduct(productID uuid.UUID) ([]models.Tag, error)
+	GetForProducts(productIDs []uuid.UUID) (map[uuid.UUID][]string, error)
+	AddToProduct(productID uuid.UUID, names []string) error
+	RemoveFromProduct(productID, tagID uuid.UUID) error
+}
+
+type tagRepository struct {
+	db *sql.DB
+}
+
+func NewTagRepository(db *sql.DB) TagRepository {
+	return &tagRepository{db: db}
+}
+
+const tagColumns = `
+	t.id, t.name,
+	(SELECT COUNT(*) FROM product_tags counted WHERE counted.tag_id = t.id),
+	t.created_at, t.updated_at
+`
+
+func scanTag(row interface{ Scan(...any) error }, t *models.Tag) error {
+	return row.Scan(&t.ID, &t.Name, &t.ProductCount, &t.CreatedAt, &t.UpdatedAt)
+}
+
+func (r *tagRepository) GetAll() ([]models.Tag, error) {
+	return r.queryTags("SELECT " + tagColumns + " FROM tags t ORDER BY t.name")
+}
+
+func (r *tagRepository) GetByID(id uuid.UUID) (*models.Tag, error) {
+	return r.getTag("SELECT "+tagColumns+" FROM tags t WHERE t.id = $1", id)
+}
+
+func (r *tagRepository) FindByName(name string) (*models.Tag, error) {
+	return r.getTag("SELECT "+tagColumns+" FROM tags t WHERE t.name = $1", name)
+}
+
+func (r *tagRepository) Create(tag *models.Tag) error {
+
+	err := r.db.QueryRow(
+		"INSERT INTO tags (name) VALUES ($1) RETURNING id, created_at, updated_at", tag.Name,
+	).Scan(&tag.ID, &tag.CreatedAt, &tag.UpdatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return fmt.Errorf("tag %s already exists", tag.Name)
+		}
+		return err
+	}
+	return nil
+}
+
+// Rename changes the name in place, so every product keeps the tag.
+func (r *tagRepository) Rename(id uuid.UUID, name string) error {
+
+	result, err := r.db.Exec(
+		"UPDATE tags SET name = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2", name, id,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return fmt.Errorf("tag %s already exists, merge the tags instead", name)
+		}
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("tag not found")
+	}
+	return nil
+}
+
+func (r *tagRepository) Delete(id uuid.UUID) error {
+
+	result, err := r.db.Exec("DELETE FROM tags WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("tag not found")
+	}
+	return nil
+}
+
+// Merge gives every product tagged with source the target tag instead and
+// deletes source, in one transaction.
+func (r *tagRepository) Merge(sourceID, targetID uuid.UUID) (*models.MergeTagResult, error) {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var locked int
+	err = tx.QueryRow(
+		"SELECT COUNT(*) FROM (SELECT 1 FROM tags WHERE id IN ($1, $2) FOR UPDATE) t",
+		sourceID, targetID,
+	).Scan(&locked)
+	if err != nil {
+		return nil, err
+	}
+	if locked != 2 {
+		return nil, errors.New("tag not found")
+	}
+
+	result := &models.MergeTagResult{SourceID: sourceID, TargetID: targetID}
+
+	// products already carrying the target keep their single association
+	res, err := tx.Exec(`
+		INSERT INTO product_tags (product_id, tag_id, created_at)
+		SELECT product_id, $1, created_at FROM product_tags WHERE tag_id = $2
+		ON CONFLICT (product_id, tag_id) DO NOTHING
+	`, targetID, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	if result.ProductsMoved, err = res.RowsAffected(); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec("DELETE FROM tags WHERE id = $1", sourceID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec("UPDATE tags SET updated_at = CURRENT_TIMESTAMP WHERE id = $1", targetID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *tagRepository) GetByProduct(productID uuid.UUID) ([]models.Tag, error) {
+	return r.queryTags(`
+		SELECT `+tagColumns+`
+		FROM tags t
+		JOIN product_tags pt ON pt.tag_id = t.id
+		WHERE pt.product_id = $1
+		ORDER BY t.name
+	`, productID)
+}
+
+// GetForProducts loads the tag names of many products at once.
+func (r *tagRepository) GetForProducts(productIDs []uuid.UUID) (map[uuid.UUID][]string, error) {
+
+	result := make(map[uuid.UUID][]string)
+	if len(productIDs) == 0 {
+		return result, nil
+	}
+
+	ids := make([]string, len(productIDs))
+	for i, id := range productIDs {
+		ids[i] = id.String()
+	}
+
+	rows, err := r.db.Query(`
+		SELECT pt.product_id, t.name
+		FROM product_tags pt
+		JOIN tags t ON t.id = pt.tag_id
+		WHERE pt.product_id = ANY($1::uuid[])
+		ORDER BY pt.product_id, t.name
+	`, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID uuid.UUID
+		var name string
+		if err := rows.Scan(&productID, &name); err != nil {
+			return nil, err
+		}
+		result[productID] = append(result[productID], name)
+	}
+	return result, rows.Err()
+}
+
+// AddToProduct tags the product with names, creating tags that do not exist
+// yet. Names must already be normalized.
+func (r *tagRepository) AddToProduct(productID uuid.UUID, names []string) error {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var locked uuid.UUID
+	err = tx.QueryRow("SELECT id FROM products WHERE id = $1 FOR UPDATE", productID).Scan(&locked)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("product not found")
+		}
+		return err
+	}
+
+	for _, name := range names {
+		// the no-op update makes RETURNING yield the existing row too
+		var tagID uuid.UUID
+		err := tx.QueryRow(`
+			INSERT INTO tags (name) VALUES ($1)
+			ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+			RETURNING id
+		`, name).Scan(&tagID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO product_tags (product_id, tag_id) VALUES ($1, $2)
+			ON CONFLICT (product_id, tag_id) DO NOTHING
+		`, productID, tagID)
+		if err != nil {
+			return err
+		}
+	}
+
+	var count int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM product_tags WHERE product_id = $1", productID).Scan(&count); err != nil {
+		return err
+	}
+	if count > models.MaxTagsPerProduct {
+		return fmt.Errorf("product cannot have more than %d tags", models.MaxTagsPerProduct)
+	}
+
+	return tx.Commit()
+}
+
+func (r *tagRepository) RemoveFromProduct(productID, tagID uuid.UUID) error {
+
+	result, err := r.db.Exec("DELETE FROM product_tags WHERE product_id = $1 AND tag_id = $2", productID, tagID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("tag not found on product")
+	}
+	return nil
+}
+
+func (r *tagRepository) getTag(query string, args ...any) (*models.Tag, error) {
+
+	var t models.Tag
+	if err := scanTag(r.db.QueryRow(query, args...), &t); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("tag not found")
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *tagRepository) queryTags(query string, args ...any) ([]models.Tag, error) {
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []models.Tag
+	for rows.Next() {
+		var t models.Tag
+		if err := scanTag(rows, &t); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
@@ -0,0 +1,302 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/google/uuid"
+)
+
+type TaxRepository interface {
+	GetAll() ([]models.TaxClass, error)
+	GetByID(id uuid.UUID) (*models.TaxClass, error)
+	Create(class *models.TaxClass, openingRate *models.TaxRate) error
+	Update(id uuid.UUID, class *models.TaxClass) error
+	Delete(id uuid.UUID) error
+	GetRates(classID uuid.UUID) ([]models.TaxRate, error)
+	CreateRate(rate *models.TaxRate) error
+	GetProductTaxes(productIDs []uuid.UUID) (map[uuid.UUID]models.ProductTax, error)
+}
+
+type taxRepository struct {
+	db *sql.DB
+}
+
+func NewTaxRepository(db *sql.DB) TaxRepository {
+	return &taxRepository{db: db}
+}
+
+// taxClassColumns carries the rate in effect now, if any, as nullable columns.
+const taxClassColumns = `
+	tc.id, tc.code, tc.name, tc.created_at, tc.updated_at,
+	cr.id, cr.rate_bp, cr.effective_from, cr.created_at
+`
+
+const taxClassFrom = `
+	FROM tax_classes tc
+	LEFT JOIN LATERAL (
+		SELECT id, rate_bp, effective_from, created_at
+		FROM tax_rates
+		WHERE tax_class_id = tc.id AND effective_from <= CURRENT_TIMESTAMP
+		ORDER BY effective_from DESC
+		LIMIT 1
+	) cr ON true
+`
+
+func scanTaxClass(row interface{ Scan(...any) error }, tc *models.TaxClass) error {
+
+	var rateID *uuid.UUID
+	var rateBP *int
+	var effectiveFrom, createdAt sql.NullTime
+
+	err := row.Scan(
+		&tc.ID, &tc.Code, &tc.Name, &tc.CreatedAt, &tc.UpdatedAt,
+		&rateID, &rateBP, &effectiveFrom, &createdAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if rateID != nil {
+		tc.CurrentRate = &models.TaxRate{
+			ID:            *rateID,
+			TaxClassID:    tc.ID,
+			RateBP:        *rateBP,
+			EffectiveFrom: effectiveFrom.Time,
+			CreatedAt:     createdAt.Time,
+		}
+	}
+
+	return nil
+}
+
+func (r *taxRepository) GetAll() ([]models.TaxClass, error) {
+
+	rows, err := r.db.Query("SELECT " + taxClassColumns + taxClassFrom + " ORDER BY tc.code")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var classes []models.TaxClass
+	for rows.Next() {
+		var tc models.TaxClass
+		if err := scanTaxClass(rows, &tc); err != nil {
+			return nil, err
+		}
+		classes = append(classes, tc)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return classes, nil
+}
+
+func (r *taxRepository) GetByID(id uuid.UUID) (*models.TaxClass, error) {
+
+	var tc models.TaxClass
+	err := scanTaxClass(r.db.QueryRow("SELECT "+taxClassColumns+taxClassFrom+" WHERE tc.id = $1", id), &tc)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("tax class not found")
+		}
+		return nil, err
+	}
+
+	return &tc, nil
+}
+
+func (r *taxRepository) Create(class *models.TaxClass, openingRate *models.TaxRate) error {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO tax_classes (code, name)
+		VALUES ($1, $2)
+		RETURNING id, created_at, updated_at
+	`, class.Code, class.Name).Scan(&class.ID, &class.CreatedAt, &class.UpdatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return errors.New("tax class with this code already exists")
+		}
+		return err
+	}
+
+	if openingRate != nil {
+		openingRate.TaxClassID = class.ID
+		if err := insertTaxRate(tx, openingRate); err != nil {
+			return err
+		}
+		class.CurrentRate = openingRate
+	}
+
+	return tx.Commit()
+}
+
+func (r *taxRepository) Update(id uuid.UUID, class *models.TaxClass) error {
+
+	err := r.db.QueryRow(`
+		UPDATE tax_classes
+		SET name = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+		RETURNING updated_at
+	`, class.Name, id).Scan(&class.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("tax class not found")
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Delete removes a tax class. Products and categories using it fall back to
+// inheriting, the foreign keys set them to NULL.
+func (r *taxRepository) Delete(id uuid.UUID) error {
+
+	result, err := r.db.Exec("DELETE FROM tax_classes WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("tax class not found")
+	}
+
+	return nil
+}
+
+func (r *taxRepository) GetRates(classID uuid.UUID) ([]models.TaxRate, error) {
+
+	rows, err := r.db.Query(`
+		SELECT id, tax_class_id, rate_bp, effective_from, created_at
+		FROM tax_rates
+		WHERE tax_class_id = $1
+		ORDER BY effective_from DESC
+	`, classID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []models.TaxRate
+	for rows.Next() {
+		var rate models.TaxRate
+		if err := rows.Scan(&rate.ID, &rate.TaxClassID, &rate.RateBP, &rate.EffectiveFrom, &rate.CreatedAt); err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+func (r *taxRepository) CreateRate(rate *models.TaxRate) error {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertTaxRate(tx, rate); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func insertTaxRate(tx *sql.Tx, rate *models.TaxRate) error {
+
+	err := tx.QueryRow(`
+		INSERT INTO tax_rates (tax_class_id, rate_bp, effective_from)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`, rate.TaxClassID, rate.RateBP, rate.EffectiveFrom).Scan(&rate.ID, &rate.CreatedAt)
+	if err != nil {
+		if isForeignKeyError(err) {
+			return errors.New("tax class not found")
+		}
+		if strings.Contains(err.Error(), "duplicate key") {
+			return errors.New("a rate with this effective date already exists")
+		}
+		return err
+	}
+
+	return nil
+}
+
+// GetProductTaxes resolves the tax in effect for each product: its own tax
+// class, otherwise the one on its category or the nearest ancestor that has
+// one. Products without any tax class are left out of the map.
+func (r *taxRepository) GetProductTaxes(productIDs []uuid.UUID) (map[uuid.UUID]models.ProductTax, error) {
+
+	ids := make([]string, len(productIDs))
+	for i, id := range productIDs {
+		ids[i] = id.String()
+	}
+
+	query := `
+		WITH RECURSIVE chain AS (
+			SELECT p.id AS product_id, p.tax_class_id AS class_id, p.category_id AS next_id, 0 AS depth
+			FROM products p
+			WHERE p.id = ANY($1::uuid[])
+			UNION ALL
+			SELECT ch.product_id, c.tax_class_id, c.parent_id, ch.depth + 1
+			FROM chain ch
+			JOIN categories c ON c.id = ch.next_id
+			WHERE ch.class_id IS NULL AND ch.depth <= $2
+		)
+		SELECT DISTINCT ON (ch.product_id) ch.product_id, tc.code, COALESCE(cr.rate_bp, 0)
+		FROM chain ch
+		JOIN tax_classes tc ON tc.id = ch.class_id
+		LEFT JOIN LATERAL (
+			SELECT rate_bp
+			FROM tax_rates
+			WHERE tax_class_id = tc.id AND effective_from <= CURRENT_TIMESTAMP
+			ORDER BY effective_from DESC
+			LIMIT 1
+		) cr ON true
+		ORDER BY ch.product_id, ch.depth
+	`
+
+	rows, err := r.db.Query(query, ids, models.MaxCategoryDepth)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	taxes := make(map[uuid.UUID]models.ProductTax)
+	for rows.Next() {
+		var productID uuid.UUID
+		var tax models.ProductTax
+		if err := rows.Scan(&productID, &tax.TaxClassCode, &tax.RateBP); err != nil {
+			return nil, err
+		}
+		taxes[productID] = tax
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return taxes, nil
+}
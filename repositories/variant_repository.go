@@ -0,0 +1,339 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/google/uuid"
+)
+
+type VariantRepository interface {
+	GetOptions(productID uuid.UUID) ([]models.ProductOption, error)
+	SetOptions(productID uuid.UUID, options []models.ProductOption) error
+	GetByProductID(productID uuid.UUID) ([]models.ProductVariant, error)
+	GetByID(id uuid.UUID) (*models.ProductVariant, error)
+	Create(productID uuid.UUID, variants []models.ProductVariant) error
+	Update(id uuid.UUID, variant *models.ProductVariant) error
+	Delete(id uuid.UUID) error
+}
+
+type variantRepository struct {
+	db *sql.DB
+}
+
+func NewVariantRepository(db *sql.DB) VariantRepository {
+	return &variantRepository{db: db}
+}
+
+const variantColumns = `
+	v.id, v.product_id, v.sku, v.barcode, v.price_override,
+	COALESCE(v.price_override, effective_price(p.id, p.price)), v.stock, v.options,
+	v.created_at, v.updated_at
+`
+
+func scanVariant(row interface{ Scan(...any) error }) (*models.ProductVariant, error) {
+	var v models.ProductVariant
+	var optionsData []byte
+
+	err := row.Scan(
+		&v.ID, &v.ProductID, &v.SKU, &v.Barcode, &v.PriceOverride,
+		&v.Price, &v.Stock, &optionsData,
+		&v.CreatedAt, &v.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(optionsData, &v.Options); err != nil {
+		return nil, err
+	}
+
+	return &v, nil
+}
+
+func (r *variantRepository) GetOptions(productID uuid.UUID) ([]models.ProductOption, error) {
+
+	query := `
+		SELECT id, product_id, name, "values", position
+		FROM product_options
+		WHERE product_id = $1
+		ORDER BY position, name
+	`
+
+	rows, err := r.db.Query(query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var options []models.ProductOption
+	for rows.Next() {
+		var o models.ProductOption
+		var valuesData []byte
+		if err := rows.Scan(&o.ID, &o.ProductID, &o.Name, &valuesData, &o.Position); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(valuesData, &o.Values); err != nil {
+			return nil, err
+		}
+		options = append(options, o)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return options, nil
+}
+
+// SetOptions replaces the option definitions of a product. Existing variants
+// are left untouched.
+func (r *variantRepository) SetOptions(productID uuid.UUID, options []models.ProductOption) error {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM product_options WHERE product_id = $1", productID); err != nil {
+		return err
+	}
+
+	for i := range options {
+		valuesData, err := json.Marshal(options[i].Values)
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRow(`
+			INSERT INTO product_options (product_id, name, "values", position)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id
+		`, productID, options[i].Name, valuesData, options[i].Position).Scan(&options[i].ID)
+		if err != nil {
+			if strings.Contains(err.Error(), "foreign key constraint") {
+				return errors.New("product not found")
+			}
+			return err
+		}
+		options[i].ProductID = productID
+	}
+
+	return tx.Commit()
+}
+
+func (r *variantRepository) GetByProductID(productID uuid.UUID) ([]models.ProductVariant, error) {
+
+	query := `
+		SELECT ` + variantColumns + `
+		FROM product_variants v
+		JOIN products p ON p.id = v.product_id
+		WHERE v.product_id = $1
+		ORDER BY v.created_at, v.sku
+	`
+
+	rows, err := r.db.Query(query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var variants []models.ProductVariant
+	for rows.Next() {
+		v, err := scanVariant(rows)
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, *v)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return variants, nil
+}
+
+func (r *variantRepository) GetByID(id uuid.UUID) (*models.ProductVariant, error) {
+
+	query := `
+		SELECT ` + variantColumns + `
+		FROM product_variants v
+		JOIN products p ON p.id = v.product_id
+		WHERE v.id = $1
+	`
+
+	v, err := scanVariant(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("variant not found")
+		}
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// Create inserts the variants and refreshes the product's aggregate stock in
+// one transaction.
+func (r *variantRepository) Create(productID uuid.UUID, variants []models.ProductVariant) error {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i := range variants {
+		optionsData, err := json.Marshal(variants[i].Options)
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRow(`
+			INSERT INTO product_variants (product_id, sku, barcode, price_override, stock, options)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id, created_at, updated_at
+		`,
+			productID,
+			variants[i].SKU,
+			variants[i].Barcode,
+			variants[i].PriceOverride,
+			variants[i].Stock,
+			optionsData,
+		).Scan(&variants[i].ID, &variants[i].CreatedAt, &variants[i].UpdatedAt)
+		if err != nil {
+			return variantError(err)
+		}
+		variants[i].ProductID = productID
+	}
+
+	if err := syncVariantStock(tx, productID, "variants created"); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Update writes the variant and refreshes the product's aggregate stock. Like
+// every variant write it locks the product before the variant, the order
+// reservations lock them in.
+func (r *variantRepository) Update(id uuid.UUID, variant *models.ProductVariant) error {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, _, err := lockStock(tx, variant.ProductID); err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(`
+		UPDATE product_variants
+		SET sku = $1, barcode = $2, price_override = $3, stock = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $5
+		RETURNING updated_at
+	`,
+		variant.SKU,
+		variant.Barcode,
+		variant.PriceOverride,
+		variant.Stock,
+		id,
+	).Scan(&variant.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("variant not found")
+		}
+		return variantError(err)
+	}
+
+	if err := syncVariantStock(tx, variant.ProductID, "variant updated"); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *variantRepository) Delete(id uuid.UUID) error {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var productID uuid.UUID
+	err = tx.QueryRow("SELECT product_id FROM product_variants WHERE id = $1", id).Scan(&productID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("variant not found")
+		}
+		return err
+	}
+
+	if _, _, err := lockStock(tx, productID); err != nil {
+		return err
+	}
+
+	result, err := tx.Exec("DELETE FROM product_variants WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("variant not found")
+	}
+
+	if err := syncVariantStock(tx, productID, "variant deleted"); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// syncVariantStock keeps products.stock equal to the sum of its variants. The
+// difference goes through the stock ledger and the default warehouse, like
+// any other stock set.
+func syncVariantStock(tx *sql.Tx, productID uuid.UUID, reason string) error {
+
+	var total int
+	err := tx.QueryRow(
+		"SELECT COALESCE(SUM(stock), 0) FROM product_variants WHERE product_id = $1", productID,
+	).Scan(&total)
+	if err != nil {
+		return err
+	}
+
+	return setStock(tx, &models.StockMovement{
+		ProductID: productID,
+		Type:      models.MovementAdjustment,
+		Reason:    reason,
+	}, total)
+}
+
+func variantError(err error) error {
+	switch {
+	case strings.Contains(err.Error(), "product_variants_sku_key"):
+		return errors.New("variant with this SKU already exists")
+	case strings.Contains(err.Error(), "idx_product_variants_barcode"):
+		return errors.New("variant with this barcode already exists")
+	case strings.Contains(err.Error(), "product_sku_clash"):
+		return errors.New("product with this SKU already exists")
+	case strings.Contains(err.Error(), "product_barcode_clash"):
+		return errors.New("product with this barcode already exists")
+	case strings.Contains(err.Error(), "idx_product_variants_options"):
+		return errors.New("variant with these options already exists")
+	case strings.Contains(err.Error(), "foreign key constraint"):
+		return errors.New("product not found")
+	}
+	return err
+}
@@ -0,0 +1,273 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/google/uuid"
+)
+
+type WarehouseRepository interface {
+	GetAll() ([]models.Warehouse, error)
+	GetByID(id uuid.UUID) (*models.Warehouse, error)
+	Create(warehouse *models.Warehouse) error
+	Update(id uuid.UUID, warehouse *models.Warehouse) error
+	Delete(id uuid.UUID) error
+	GetStockLevels(id uuid.UUID) ([]models.StockLevel, error)
+	Transfer(transfer *models.Transfer, req *models.TransferStockRequest) error
+}
+
+type warehouseRepository struct {
+	db *sql.DB
+}
+
+func NewWarehouseRepository(db *sql.DB) WarehouseRepository {
+	return &warehouseRepository{db: db}
+}
+
+const warehouseColumns = "id, code, name, address, is_default, created_at, updated_at"
+
+func (r *warehouseRepository) GetAll() ([]models.Warehouse, error) {
+
+	rows, err := r.db.Query("SELECT " + warehouseColumns + " FROM warehouses ORDER BY is_default DESC, code")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var warehouses []models.Warehouse
+	for rows.Next() {
+		var w models.Warehouse
+		err := rows.Scan(&w.ID, &w.Code, &w.Name, &w.Address, &w.IsDefault, &w.CreatedAt, &w.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		warehouses = append(warehouses, w)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return warehouses, nil
+}
+
+func (r *warehouseRepository) GetByID(id uuid.UUID) (*models.Warehouse, error) {
+
+	var w models.Warehouse
+	err := r.db.QueryRow("SELECT "+warehouseColumns+" FROM warehouses WHERE id = $1", id).Scan(
+		&w.ID, &w.Code, &w.Name, &w.Address, &w.IsDefault, &w.CreatedAt, &w.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("warehouse not found")
+		}
+		return nil, err
+	}
+
+	return &w, nil
+}
+
+func (r *warehouseRepository) Create(warehouse *models.Warehouse) error {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if warehouse.IsDefault {
+		if _, err := tx.Exec("UPDATE warehouses SET is_default = FALSE, updated_at = CURRENT_TIMESTAMP WHERE is_default"); err != nil {
+			return err
+		}
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO warehouses (code, name, address, is_default)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`, warehouse.Code, warehouse.Name, warehouse.Address, warehouse.IsDefault).Scan(
+		&warehouse.ID, &warehouse.CreatedAt, &warehouse.UpdatedAt,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return errors.New("warehouse with this code already exists")
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *warehouseRepository) Update(id uuid.UUID, warehouse *models.Warehouse) error {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if warehouse.IsDefault {
+		_, err := tx.Exec(
+			"UPDATE warehouses SET is_default = FALSE, updated_at = CURRENT_TIMESTAMP WHERE is_default AND id <> $1",
+			id,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.QueryRow(`
+		UPDATE warehouses
+		SET name = $1, address = $2, is_default = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $4
+		RETURNING updated_at
+	`, warehouse.Name, warehouse.Address, warehouse.IsDefault, id).Scan(&warehouse.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("warehouse not found")
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *warehouseRepository) Delete(id uuid.UUID) error {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var isDefault bool
+	err = tx.QueryRow("SELECT is_default FROM warehouses WHERE id = $1 FOR UPDATE", id).Scan(&isDefault)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("warehouse not found")
+		}
+		return err
+	}
+	if isDefault {
+		return errors.New("default warehouse cannot be deleted")
+	}
+
+	var onHand int
+	err = tx.QueryRow("SELECT COALESCE(SUM(quantity), 0) FROM stock_levels WHERE warehouse_id = $1", id).Scan(&onHand)
+	if err != nil {
+		return err
+	}
+	if onHand > 0 {
+		return errors.New("warehouse still holds stock and cannot be deleted")
+	}
+
+	if _, err := tx.Exec("DELETE FROM stock_levels WHERE warehouse_id = $1", id); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM warehouses WHERE id = $1", id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *warehouseRepository) GetStockLevels(id uuid.UUID) ([]models.StockLevel, error) {
+
+	query := `
+		SELECT sl.product_id, p.name, sl.warehouse_id, sl.quantity, sl.updated_at
+		FROM stock_levels sl
+		JOIN products p ON p.id = sl.product_id
+		WHERE sl.warehouse_id = $1 AND sl.quantity > 0
+		ORDER BY p.name
+	`
+
+	rows, err := r.db.Query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var levels []models.StockLevel
+	for rows.Next() {
+		var l models.StockLevel
+		if err := rows.Scan(&l.ProductID, &l.ProductName, &l.WarehouseID, &l.Quantity, &l.UpdatedAt); err != nil {
+			return nil, err
+		}
+		levels = append(levels, l)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return levels, nil
+}
+
+// Transfer moves stock between two warehouses and writes the outgoing and
+// incoming ledger entries under one transfer ID. The product total does not
+// change. Levels are locked in warehouse ID order to avoid deadlocks between
+// opposite transfers.
+func (r *warehouseRepository) Transfer(transfer *models.Transfer, req *models.TransferStockRequest) error {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var balance int
+	err = tx.QueryRow("SELECT stock FROM products WHERE id = $1 FOR SHARE", req.ProductID).Scan(&balance)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("product not found")
+		}
+		return err
+	}
+
+	_, err = tx.Exec(`
+		SELECT 1 FROM stock_levels
+		WHERE product_id = $1 AND warehouse_id IN ($2, $3)
+		ORDER BY warehouse_id
+		FOR UPDATE
+	`, req.ProductID, req.FromWarehouseID, req.ToWarehouseID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := applyWarehouseDelta(tx, req.ProductID, &req.FromWarehouseID, -req.Quantity); err != nil {
+		return err
+	}
+	if _, err := applyWarehouseDelta(tx, req.ProductID, &req.ToWarehouseID, req.Quantity); err != nil {
+		return err
+	}
+
+	transferID := uuid.New()
+	transfer.ID = transferID
+	transfer.Outgoing = models.StockMovement{
+		ProductID:    req.ProductID,
+		WarehouseID:  &req.FromWarehouseID,
+		TransferID:   &transferID,
+		Type:         models.MovementTransfer,
+		Quantity:     -req.Quantity,
+		BalanceAfter: balance,
+		Reason:       req.Reason,
+		Reference:    req.Reference,
+		Actor:        req.Actor,
+	}
+	transfer.Incoming = transfer.Outgoing
+	transfer.Incoming.WarehouseID = &req.ToWarehouseID
+	transfer.Incoming.Quantity = req.Quantity
+
+	if err := insertStockMovement(tx, &transfer.Outgoing); err != nil {
+		return err
+	}
+	if err := insertStockMovement(tx, &transfer.Incoming); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
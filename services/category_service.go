@@ -2,9 +2,11 @@ package services
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
+	"github.com/anggakrnwn/product-catalog-api/events"
 	"github.com/anggakrnwn/product-catalog-api/models"
 	"github.com/anggakrnwn/product-catalog-api/repositories"
 	"github.com/google/uuid"
@@ -15,16 +17,28 @@ type CategoryService interface {
 	GetByID(id string) (*models.Category, error)
 	Create(req *models.CreateCategoryRequest) (*models.Category, error)
 	Update(id string, req *models.UpdateCategoryRequest) (*models.Category, error)
-	Delete(id string) error
+	Delete(id string, onProducts string, target string) (*models.DeleteCategoryResult, error)
 	BulkCreate(req *models.BulkCreateRequest) ([]models.Category, []error)
+	GetTree() ([]models.Category, error)
+	GetChildren(id string) ([]models.Category, error)
+	GetAncestors(id string) ([]models.Category, error)
+	GetDescendants(id string) ([]models.Category, error)
+	Merge(id string, req *models.MergeCategoryRequest) (*models.MergeCategoryResult, error)
+	FindRedirect(id string) (*uuid.UUID, error)
+	GetBySlug(slug string) (*models.Category, bool, error)
+	GetAllWithStats() ([]models.Category, error)
+	GetStats(id string) (*models.CategoryStats, error)
+	GetAttributes(id string) ([]models.CategoryAttribute, error)
+	SetAttributes(id string, req *models.SetAttributesRequest) ([]models.CategoryAttribute, error)
 }
 
 type categoryService struct {
 	repo repositories.CategoryRepository
+	bus  *events.Bus
 }
 
-func NewCategoryService(repo repositories.CategoryRepository) CategoryService {
-	return &categoryService{repo: repo}
+func NewCategoryService(repo repositories.CategoryRepository, bus *events.Bus) CategoryService {
+	return &categoryService{repo: repo, bus: bus}
 }
 
 func (s *categoryService) GetAll() ([]models.Category, error) {
@@ -66,10 +80,35 @@ func (s *categoryService) Create(req *models.CreateCategoryRequest) (*models.Cat
 		return nil, errors.New("category with this name already exists")
 	}
 
+	// the parent is checked where it is written, see the repository
+	if req.ParentID != nil && *req.ParentID == uuid.Nil {
+		req.ParentID = nil
+	}
+
+	if req.TaxClassID != nil && *req.TaxClassID == uuid.Nil {
+		req.TaxClassID = nil
+	}
+
+	slug := strings.TrimSpace(req.Slug)
+	if slug != "" {
+		if err := models.ValidateSlug(slug); err != nil {
+			return nil, err
+		}
+	} else {
+		generated, err := uniqueSlug(models.Slugify(req.Name), "category", s.repo.SlugTaken)
+		if err != nil {
+			return nil, err
+		}
+		slug = generated
+	}
+
 	category := &models.Category{
 		ID:          uuid.New(),
 		Name:        req.Name,
+		Slug:        slug,
 		Description: req.Description,
+		ParentID:    req.ParentID,
+		TaxClassID:  req.TaxClassID,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
@@ -118,10 +157,39 @@ func (s *categoryService) Update(id string, req *models.UpdateCategoryRequest) (
 		return nil, errors.New("category with this name already exists")
 	}
 
+	parentID := existing.ParentID
+	if req.ParentID != nil {
+		if *req.ParentID == uuid.Nil {
+			parentID = nil
+		} else {
+			parentID = req.ParentID
+		}
+	}
+
+	taxClassID := existing.TaxClassID
+	if req.TaxClassID != nil {
+		if *req.TaxClassID == uuid.Nil {
+			taxClassID = nil
+		} else {
+			taxClassID = req.TaxClassID
+		}
+	}
+
+	slug := existing.Slug
+	if req.Slug != nil {
+		slug = strings.TrimSpace(*req.Slug)
+		if err := models.ValidateSlug(slug); err != nil {
+			return nil, err
+		}
+	}
+
 	category := &models.Category{
 		ID:          existing.ID,
 		Name:        req.Name,
+		Slug:        slug,
 		Description: req.Description,
+		ParentID:    parentID,
+		TaxClassID:  taxClassID,
 		CreatedAt:   existing.CreatedAt,
 		UpdatedAt:   time.Now(),
 	}
@@ -134,23 +202,61 @@ func (s *categoryService) Update(id string, req *models.UpdateCategoryRequest) (
 	return category, nil
 }
 
-func (s *categoryService) Delete(id string) error {
+func (s *categoryService) Delete(id string, onProducts string, target string) (*models.DeleteCategoryResult, error) {
 
 	if strings.TrimSpace(id) == "" {
-		return errors.New("category ID is required")
+		return nil, errors.New("category ID is required")
 	}
 
 	categoryID, err := uuid.Parse(id)
 	if err != nil {
-		return errors.New("invalid category ID format")
+		return nil, errors.New("invalid category ID format")
+	}
+
+	result := &models.DeleteCategoryResult{
+		ID:         categoryID,
+		OnProducts: strings.ToLower(strings.TrimSpace(onProducts)),
+	}
+
+	switch result.OnProducts {
+	case "":
+		result.OnProducts = models.DeletePolicyRestrict
+	case models.DeletePolicyRestrict, models.DeletePolicyCascade:
+	case models.DeletePolicyReassign:
+		if strings.TrimSpace(target) == "" {
+			return nil, errors.New("target is required when on_products is reassign")
+		}
+		targetID, err := uuid.Parse(strings.TrimSpace(target))
+		if err != nil {
+			return nil, errors.New("invalid target category ID format")
+		}
+		if targetID == categoryID {
+			return nil, errors.New("target cannot be the category being deleted")
+		}
+		result.TargetID = &targetID
+	default:
+		return nil, errors.New("on_products must be restrict, cascade or reassign")
 	}
 
 	_, err = s.repo.GetByID(categoryID)
 	if err != nil {
-		return errors.New("category not found")
+		return nil, errors.New("category not found")
+	}
+
+	affected, deleted, err := s.repo.Delete(categoryID, result.OnProducts, result.TargetID)
+	if err != nil {
+		return nil, err
 	}
 
-	return s.repo.Delete(categoryID)
+	// cascaded products go the same way as a single delete, images included
+	if s.bus != nil {
+		for _, productID := range deleted {
+			s.bus.Publish(events.ProductDeleted, models.ProductDeletedEvent{ProductID: productID})
+		}
+	}
+
+	result.ProductsAffected = affected
+	return result, nil
 }
 
 func (s *categoryService) BulkCreate(req *models.BulkCreateRequest) ([]models.Category, []error) {
@@ -169,3 +275,207 @@ func (s *categoryService) BulkCreate(req *models.BulkCreateRequest) ([]models.Ca
 
 	return created, errs
 }
+
+func (s *categoryService) GetTree() ([]models.Category, error) {
+
+	categories, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[uuid.UUID][]models.Category)
+	var roots []models.Category
+	for _, c := range categories {
+		if c.ParentID == nil {
+			roots = append(roots, c)
+		} else {
+			children[*c.ParentID] = append(children[*c.ParentID], c)
+		}
+	}
+
+	var build func(nodes []models.Category, depth int) []models.Category
+	build = func(nodes []models.Category, depth int) []models.Category {
+		for i := range nodes {
+			nodes[i].Depth = depth
+			nodes[i].Children = build(children[nodes[i].ID], depth+1)
+		}
+		return nodes
+	}
+
+	return build(roots, 1), nil
+}
+
+func (s *categoryService) GetChildren(id string) ([]models.Category, error) {
+
+	category, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetChildren(category.ID)
+}
+
+func (s *categoryService) GetAncestors(id string) ([]models.Category, error) {
+
+	category, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetAncestors(category.ID)
+}
+
+func (s *categoryService) GetDescendants(id string) ([]models.Category, error) {
+
+	category, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetDescendants(category.ID)
+}
+
+func (s *categoryService) Merge(id string, req *models.MergeCategoryRequest) (*models.MergeCategoryResult, error) {
+
+	source, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.TargetID == uuid.Nil {
+		return nil, errors.New("target_id is required")
+	}
+
+	if req.TargetID == source.ID {
+		return nil, errors.New("category cannot be merged into itself")
+	}
+
+	if _, err := s.repo.GetByID(req.TargetID); err != nil {
+		return nil, errors.New("target category not found")
+	}
+
+	return s.repo.Merge(source.ID, req.TargetID)
+}
+
+func (s *categoryService) FindRedirect(id string) (*uuid.UUID, error) {
+
+	categoryID, err := uuid.Parse(strings.TrimSpace(id))
+	if err != nil {
+		return nil, errors.New("invalid category ID format")
+	}
+
+	return s.repo.FindRedirect(categoryID)
+}
+
+// GetBySlug resolves a category slug. The flag reports that slug is an old
+// one and the category has since moved to its current Slug.
+func (s *categoryService) GetBySlug(slug string) (*models.Category, bool, error) {
+
+	slug = strings.ToLower(strings.TrimSpace(slug))
+	if slug == "" {
+		return nil, false, errors.New("slug is required")
+	}
+	return s.repo.FindBySlug(slug)
+}
+
+func (s *categoryService) GetAllWithStats() ([]models.Category, error) {
+	return s.repo.GetAllWithStats()
+}
+
+func (s *categoryService) GetStats(id string) (*models.CategoryStats, error) {
+
+	category, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetStats(category.ID)
+}
+
+func (s *categoryService) GetAttributes(id string) ([]models.CategoryAttribute, error) {
+
+	category, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetAttributes(category.ID)
+}
+
+// SetAttributes replaces the category's attribute schema. Products keep their
+// stored values; they are checked against the new schema the next time their
+// attributes or category change.
+func (s *categoryService) SetAttributes(id string, req *models.SetAttributesRequest) ([]models.CategoryAttribute, error) {
+
+	category, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.Attributes) > models.MaxCategoryAttributes {
+		return nil, fmt.Errorf("category cannot have more than %d attributes", models.MaxCategoryAttributes)
+	}
+
+	seenNames := make(map[string]bool)
+	attributes := make([]models.CategoryAttribute, 0, len(req.Attributes))
+
+	for i, input := range req.Attributes {
+		name := strings.ToLower(strings.TrimSpace(input.Name))
+		if name == "" {
+			return nil, errors.New("attribute name is required")
+		}
+		if err := models.ValidateAttributeName(name); err != nil {
+			return nil, err
+		}
+		if seenNames[name] {
+			return nil, fmt.Errorf("attribute %s must only be defined once", name)
+		}
+		seenNames[name] = true
+
+		attrType := strings.ToLower(strings.TrimSpace(input.Type))
+		switch attrType {
+		case models.AttributeString, models.AttributeNumber, models.AttributeInteger, models.AttributeBoolean, models.AttributeEnum:
+		case "":
+			return nil, fmt.Errorf("attribute %s: type is required", name)
+		default:
+			return nil, fmt.Errorf("attribute %s: type must be string, number, integer, boolean or enum", name)
+		}
+
+		unit := strings.TrimSpace(input.Unit)
+		if len(unit) > models.MaxAttributeUnitLength {
+			return nil, fmt.Errorf("attribute %s: unit cannot be longer than %d characters", name, models.MaxAttributeUnitLength)
+		}
+
+		seenValues := make(map[string]bool)
+		var values []string
+		for _, v := range input.Values {
+			v = strings.TrimSpace(v)
+			if v == "" || seenValues[strings.ToLower(v)] {
+				continue
+			}
+			seenValues[strings.ToLower(v)] = true
+			values = append(values, v)
+		}
+		if attrType == models.AttributeEnum && len(values) == 0 {
+			return nil, fmt.Errorf("attribute %s must have at least one value", name)
+		}
+		if attrType != models.AttributeEnum && len(values) > 0 {
+			return nil, fmt.Errorf("attribute %s cannot have values unless its type is enum", name)
+		}
+
+		attributes = append(attributes, models.CategoryAttribute{
+			Name:     name,
+			Type:     attrType,
+			Unit:     unit,
+			Values:   values,
+			Required: input.Required,
+			Position: i,
+		})
+	}
+
+	if err := s.repo.SetAttributes(category.ID, attributes); err != nil {
+		return nil, err
+	}
+
+	return attributes, nil
+}
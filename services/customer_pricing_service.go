@@ -0,0 +1,270 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/anggakrnwn/product-catalog-api/repositories"
+	"github.com/google/uuid"
+)
+
+const maxQuoteItems = 200
+
+type CustomerPricingService interface {
+	GetGroups() ([]models.CustomerGroup, error)
+	GetGroupByID(id uuid.UUID) (*models.CustomerGroup, error)
+	CreateGroup(req *models.CreateCustomerGroupRequest) (*models.CustomerGroup, error)
+	UpdateGroup(id uuid.UUID, req *models.UpdateCustomerGroupRequest) (*models.CustomerGroup, error)
+	DeleteGroup(id uuid.UUID) error
+	GetRules(filter models.PriceRuleFilter) ([]models.PriceRule, error)
+	CreateRule(req *models.CreatePriceRuleRequest) (*models.PriceRule, error)
+	DeleteRule(id uuid.UUID) error
+	Quote(req *models.QuoteRequest) (*models.Quote, error)
+}
+
+type customerPricingService struct {
+	repo         repositories.CustomerPricingRepository
+	productRepo  repositories.ProductRepository
+	baseCurrency string
+}
+
+func NewCustomerPricingService(repo repositories.CustomerPricingRepository, productRepo repositories.ProductRepository, baseCurrency string) CustomerPricingService {
+	return &customerPricingService{
+		repo:         repo,
+		productRepo:  productRepo,
+		baseCurrency: baseCurrency,
+	}
+}
+
+func (s *customerPricingService) GetGroups() ([]models.CustomerGroup, error) {
+	return s.repo.GetGroups()
+}
+
+func (s *customerPricingService) GetGroupByID(id uuid.UUID) (*models.CustomerGroup, error) {
+
+	if id == uuid.Nil {
+		return nil, errors.New("customer group ID is required")
+	}
+	return s.repo.GetGroupByID(id)
+}
+
+func (s *customerPricingService) CreateGroup(req *models.CreateCustomerGroupRequest) (*models.CustomerGroup, error) {
+
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+	if code == "" {
+		return nil, errors.New("code is required")
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+
+	group := &models.CustomerGroup{
+		Code: code,
+		Name: name,
+	}
+
+	if err := s.repo.CreateGroup(group); err != nil {
+		return nil, err
+	}
+
+	return group, nil
+}
+
+func (s *customerPricingService) UpdateGroup(id uuid.UUID, req *models.UpdateCustomerGroupRequest) (*models.CustomerGroup, error) {
+
+	group, err := s.GetGroupByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, errors.New("name cannot be empty")
+		}
+		group.Name = name
+	}
+
+	if err := s.repo.UpdateGroup(id, group); err != nil {
+		return nil, err
+	}
+
+	return group, nil
+}
+
+func (s *customerPricingService) DeleteGroup(id uuid.UUID) error {
+
+	if id == uuid.Nil {
+		return errors.New("customer group ID is required")
+	}
+	return s.repo.DeleteGroup(id)
+}
+
+func (s *customerPricingService) GetRules(filter models.PriceRuleFilter) ([]models.PriceRule, error) {
+	return s.repo.GetRules(filter)
+}
+
+func (s *customerPricingService) CreateRule(req *models.CreatePriceRuleRequest) (*models.PriceRule, error) {
+
+	if (req.ProductID == nil) == (req.CategoryID == nil) {
+		return nil, errors.New("exactly one of product_id and category_id is required")
+	}
+
+	if (req.DiscountBP == nil) == (req.FixedPrice == nil) {
+		return nil, errors.New("exactly one of discount_bp and fixed_price is required")
+	}
+
+	if req.DiscountBP != nil && (*req.DiscountBP <= 0 || *req.DiscountBP > 10000) {
+		return nil, errors.New("discount_bp must be between 1 and 10000")
+	}
+
+	if req.FixedPrice != nil && *req.FixedPrice < 0 {
+		return nil, errors.New("fixed_price cannot be negative")
+	}
+
+	if req.MinQty == 0 {
+		req.MinQty = 1
+	}
+	if req.MinQty < 1 {
+		return nil, errors.New("min_qty must be at least 1")
+	}
+
+	if req.CustomerGroupID != nil && *req.CustomerGroupID == uuid.Nil {
+		req.CustomerGroupID = nil
+	}
+
+	rule := &models.PriceRule{
+		CustomerGroupID: req.CustomerGroupID,
+		ProductID:       req.ProductID,
+		CategoryID:      req.CategoryID,
+		MinQty:          req.MinQty,
+		DiscountBP:      req.DiscountBP,
+		FixedPrice:      req.FixedPrice,
+	}
+
+	if err := s.repo.CreateRule(rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (s *customerPricingService) DeleteRule(id uuid.UUID) error {
+
+	if id == uuid.Nil {
+		return errors.New("price rule ID is required")
+	}
+	return s.repo.DeleteRule(id)
+}
+
+// Quote prices every line from the product's current price. Of the rules
+// whose min_qty the line reaches, the one giving the lowest unit price wins;
+// on a tie the more specific rule (product over nearer category) is kept.
+func (s *customerPricingService) Quote(req *models.QuoteRequest) (*models.Quote, error) {
+
+	if len(req.Items) == 0 {
+		return nil, errors.New("at least one item is required")
+	}
+	if len(req.Items) > maxQuoteItems {
+		return nil, fmt.Errorf("a quote cannot have more than %d items", maxQuoteItems)
+	}
+
+	quote := &models.Quote{Currency: s.baseCurrency}
+
+	var groupID *uuid.UUID
+	if ref := strings.TrimSpace(req.CustomerGroup); ref != "" {
+		group, err := s.resolveGroup(ref)
+		if err != nil {
+			return nil, err
+		}
+		quote.CustomerGroup = group
+		groupID = &group.ID
+	}
+
+	// tiers go by the total quantity of a product across its lines
+	products := make(map[uuid.UUID]*models.Product)
+	quantities := make(map[uuid.UUID]int)
+	var ids []uuid.UUID
+	for _, item := range req.Items {
+		if item.ProductID == uuid.Nil {
+			return nil, errors.New("product ID is required on every item")
+		}
+		if item.Quantity <= 0 {
+			return nil, errors.New("quantity must be positive on every item")
+		}
+		quantities[item.ProductID] += item.Quantity
+		if _, seen := products[item.ProductID]; seen {
+			continue
+		}
+
+		product, err := s.productRepo.GetByID(item.ProductID)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				return nil, fmt.Errorf("product %s not found", item.ProductID)
+			}
+			return nil, err
+		}
+		products[item.ProductID] = product
+		ids = append(ids, item.ProductID)
+	}
+
+	applicable, err := s.repo.GetApplicableRules(ids, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	rulesByProduct := make(map[uuid.UUID][]models.ApplicableRule)
+	for _, ar := range applicable {
+		rulesByProduct[ar.ProductID] = append(rulesByProduct[ar.ProductID], ar)
+	}
+
+	for _, item := range req.Items {
+		product := products[item.ProductID]
+		line := models.QuoteLine{
+			ProductID:     product.ID,
+			Name:          product.Name,
+			Quantity:      item.Quantity,
+			BaseUnitPrice: product.Price,
+			UnitPrice:     product.Price,
+		}
+
+		var best *models.ApplicableRule
+		for i, ar := range rulesByProduct[product.ID] {
+			if quantities[product.ID] < ar.Rule.MinQty {
+				continue
+			}
+			price := ar.Rule.UnitPrice(product.Price)
+			if price > product.Price {
+				continue
+			}
+			if best == nil || price < line.UnitPrice || (price == line.UnitPrice && ar.Depth < best.Depth) {
+				best = &rulesByProduct[product.ID][i]
+				line.UnitPrice = price
+			}
+		}
+		if best != nil {
+			line.AppliedRule = &best.Rule
+		}
+
+		line.LineTotal = line.UnitPrice * int64(item.Quantity)
+		line.LineDiscount = line.BaseUnitPrice*int64(item.Quantity) - line.LineTotal
+
+		quote.Lines = append(quote.Lines, line)
+		quote.Subtotal += line.BaseUnitPrice * int64(item.Quantity)
+		quote.DiscountTotal += line.LineDiscount
+		quote.Total += line.LineTotal
+	}
+
+	return quote, nil
+}
+
+func (s *customerPricingService) resolveGroup(ref string) (*models.CustomerGroup, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		return s.repo.GetGroupByID(id)
+	}
+	return s.repo.GetGroupByCode(strings.ToUpper(ref))
+}
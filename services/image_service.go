@@ -0,0 +1,261 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/anggakrnwn/product-catalog-api/blobstore"
+	"github.com/anggakrnwn/product-catalog-api/events"
+	"github.com/anggakrnwn/product-catalog-api/media"
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/anggakrnwn/product-catalog-api/repositories"
+	"github.com/google/uuid"
+)
+
+type ImageService interface {
+	GetAll(productID uuid.UUID) ([]models.ProductImage, error)
+	Upload(productID uuid.UUID, data []byte, altText string) (*models.ProductImage, error)
+	Update(productID, imageID uuid.UUID, req *models.UpdateProductImageRequest) (*models.ProductImage, error)
+	Reorder(productID uuid.UUID, req *models.ReorderProductImagesRequest) ([]models.ProductImage, error)
+	Delete(productID, imageID uuid.UUID) error
+	Attach(products []models.Product) error
+	HandleProductDeleted(event events.Event)
+}
+
+type imageService struct {
+	repo        repositories.ImageRepository
+	productRepo repositories.ProductRepository
+	store       blobstore.BlobStore
+}
+
+func NewImageService(repo repositories.ImageRepository, productRepo repositories.ProductRepository, store blobstore.BlobStore) ImageService {
+	return &imageService{
+		repo:        repo,
+		productRepo: productRepo,
+		store:       store,
+	}
+}
+
+func (s *imageService) GetAll(productID uuid.UUID) ([]models.ProductImage, error) {
+
+	if _, err := s.productRepo.GetByID(productID); err != nil {
+		return nil, err
+	}
+
+	images, err := s.repo.GetByProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+	s.setURLs(images)
+	return images, nil
+}
+
+// Upload checks and resizes the image, writes the original and every
+// rendition to the blob store, then records them. Files written before a
+// failure are removed again.
+func (s *imageService) Upload(productID uuid.UUID, data []byte, altText string) (*models.ProductImage, error) {
+
+	if len(data) == 0 {
+		return nil, errors.New("file is required")
+	}
+
+	altText, err := validateAltText(altText)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.productRepo.GetByID(productID); err != nil {
+		return nil, err
+	}
+
+	processed, err := media.Process(data)
+	if err != nil {
+		return nil, err
+	}
+
+	image := &models.ProductImage{
+		ID:          uuid.New(),
+		ProductID:   productID,
+		AltText:     altText,
+		ContentType: processed.ContentType,
+		Width:       processed.Width,
+		Height:      processed.Height,
+		SizeBytes:   int64(len(data)),
+		Variants:    []models.ImageVariant{},
+	}
+	prefix := imagePrefix(productID, image.ID)
+	image.StorageKey = prefix + "original." + processed.Extension
+
+	ctx := context.Background()
+	var written []string
+	put := func(key string, data []byte, contentType string) error {
+		if err := s.store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+			return err
+		}
+		written = append(written, key)
+		return nil
+	}
+	cleanup := func() {
+		for _, key := range written {
+			if err := s.store.Delete(ctx, key); err != nil {
+				log.Printf("Failed to remove blob %s: %v", key, err)
+			}
+		}
+	}
+
+	if err := put(image.StorageKey, data, processed.ContentType); err != nil {
+		return nil, err
+	}
+	for _, r := range processed.Renditions {
+		key := prefix + r.Variant + "." + r.Format
+		if err := put(key, r.Data, r.ContentType); err != nil {
+			cleanup()
+			return nil, err
+		}
+		image.Variants = append(image.Variants, models.ImageVariant{
+			Name:        r.Variant,
+			Format:      r.Format,
+			ContentType: r.ContentType,
+			Width:       r.Width,
+			Height:      r.Height,
+			SizeBytes:   int64(len(r.Data)),
+			StorageKey:  key,
+		})
+	}
+
+	if err := s.repo.Create(image); err != nil {
+		cleanup()
+		return nil, err
+	}
+
+	images := []models.ProductImage{*image}
+	s.setURLs(images)
+	return &images[0], nil
+}
+
+func (s *imageService) Update(productID, imageID uuid.UUID, req *models.UpdateProductImageRequest) (*models.ProductImage, error) {
+
+	if req.AltText != nil {
+		altText, err := validateAltText(*req.AltText)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.repo.UpdateAltText(productID, imageID, altText); err != nil {
+			return nil, err
+		}
+	}
+
+	image, err := s.repo.GetByID(productID, imageID)
+	if err != nil {
+		return nil, err
+	}
+	images := []models.ProductImage{*image}
+	s.setURLs(images)
+	return &images[0], nil
+}
+
+func (s *imageService) Reorder(productID uuid.UUID, req *models.ReorderProductImagesRequest) ([]models.ProductImage, error) {
+
+	if _, err := s.productRepo.GetByID(productID); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Reorder(productID, req.ImageIDs); err != nil {
+		return nil, err
+	}
+
+	images, err := s.repo.GetByProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+	s.setURLs(images)
+	return images, nil
+}
+
+// Delete removes the image record first; a file left behind by a failing
+// store is logged rather than resurrecting the record.
+func (s *imageService) Delete(productID, imageID uuid.UUID) error {
+
+	image, err := s.repo.Delete(productID, imageID)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	keys := []string{image.StorageKey}
+	for _, v := range image.Variants {
+		keys = append(keys, v.StorageKey)
+	}
+	for _, key := range keys {
+		if err := s.store.Delete(ctx, key); err != nil {
+			log.Printf("Failed to remove blob %s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+// Attach loads the images of products for product reads.
+func (s *imageService) Attach(products []models.Product) error {
+
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(products))
+	for i := range products {
+		ids[i] = products[i].ID
+	}
+
+	byProduct, err := s.repo.GetForProducts(ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range products {
+		images := byProduct[products[i].ID]
+		s.setURLs(images)
+		products[i].Images = images
+	}
+	return nil
+}
+
+// HandleProductDeleted removes the files of a deleted product's images; the
+// rows went with the product.
+func (s *imageService) HandleProductDeleted(event events.Event) {
+
+	payload, ok := event.Payload.(models.ProductDeletedEvent)
+	if !ok {
+		return
+	}
+
+	prefix := "products/" + payload.ProductID.String()
+	if err := s.store.DeletePrefix(context.Background(), prefix); err != nil {
+		log.Printf("Failed to remove images of product %s: %v", payload.ProductID, err)
+	}
+}
+
+func (s *imageService) setURLs(images []models.ProductImage) {
+	for i := range images {
+		images[i].URL = s.store.URL(images[i].StorageKey)
+		for j := range images[i].Variants {
+			images[i].Variants[j].URL = s.store.URL(images[i].Variants[j].StorageKey)
+		}
+	}
+}
+
+func imagePrefix(productID, imageID uuid.UUID) string {
+	return fmt.Sprintf("products/%s/%s/", productID, imageID)
+}
+
+func validateAltText(altText string) (string, error) {
+	altText = strings.TrimSpace(altText)
+	if utf8.RuneCountInString(altText) > models.MaxAltTextLength {
+		return "", fmt.Errorf("alt_text cannot be longer than %d characters", models.MaxAltTextLength)
+	}
+	return altText, nil
+}
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/anggakrnwn/product-catalog-api/repositories"
+)
+
+type InventoryService interface {
+	GetLowStock() ([]models.LowStockGroup, error)
+	GetReplenishment() ([]models.LowStockItem, error)
+	GetValuation(method string) (*models.InventoryValuation, error)
+}
+
+type inventoryService struct {
+	repo         repositories.InventoryRepository
+	baseCurrency string
+}
+
+func NewInventoryService(repo repositories.InventoryRepository, baseCurrency string) InventoryService {
+	return &inventoryService{
+		repo:         repo,
+		baseCurrency: baseCurrency,
+	}
+}
+
+// GetLowStock groups low-stock products by category, keeping the
+// repository's category ordering.
+func (s *inventoryService) GetLowStock() ([]models.LowStockGroup, error) {
+
+	items, err := s.GetReplenishment()
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []models.LowStockGroup
+	index := make(map[string]int)
+	for _, item := range items {
+		key := item.CategoryID.String()
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, models.LowStockGroup{
+				CategoryID:   item.CategoryID,
+				CategoryName: item.CategoryName,
+			})
+		}
+		groups[i].Products = append(groups[i].Products, item)
+	}
+
+	return groups, nil
+}
+
+func (s *inventoryService) GetReplenishment() ([]models.LowStockItem, error) {
+
+	items, err := s.repo.GetLowStock()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range items {
+		items[i].SuggestedQty = suggestedOrderQty(&items[i].Product)
+	}
+
+	return items, nil
+}
+
+// suggestedOrderQty brings available stock back above the threshold. With a
+// reorder quantity set, it orders whole multiples of it; otherwise it refills
+// to twice the threshold.
+func suggestedOrderQty(p *models.Product) int {
+
+	if p.LowStockThreshold == nil {
+		return 0
+	}
+
+	threshold := *p.LowStockThreshold
+	shortfall := threshold - p.Available + 1
+	if shortfall <= 0 {
+		return 0
+	}
+
+	if p.ReorderQty > 0 {
+		packs := (shortfall + p.ReorderQty - 1) / p.ReorderQty
+		return packs * p.ReorderQty
+	}
+
+	qty := threshold*2 - p.Available
+	if qty < shortfall {
+		qty = shortfall
+	}
+	return qty
+}
+
+// GetValuation values current stock by category at cost. Costs come from the
+// unit_cost recorded on incoming movements; movements without one fall back
+// to the product's cost price, or to its first recorded unit cost when it has
+// no cost price.
+func (s *inventoryService) GetValuation(method string) (*models.InventoryValuation, error) {
+
+	switch method {
+	case "":
+		method = models.ValuationWeightedAverage
+	case models.ValuationWeightedAverage, models.ValuationFIFO:
+	default:
+		return nil, errors.New("method must be weighted_average or fifo")
+	}
+
+	histories, err := s.repo.GetCostHistories()
+	if err != nil {
+		return nil, err
+	}
+
+	valuation := &models.InventoryValuation{
+		Method:      method,
+		Currency:    s.baseCurrency,
+		Categories:  []models.CategoryValuation{},
+		GeneratedAt: time.Now(),
+	}
+
+	index := make(map[string]int)
+	for i := range histories {
+		h := &histories[i]
+		key := h.CategoryID.String()
+		c, ok := index[key]
+		if !ok {
+			c = len(valuation.Categories)
+			index[key] = c
+			valuation.Categories = append(valuation.Categories, models.CategoryValuation{
+				CategoryID:   h.CategoryID,
+				CategoryName: h.CategoryName,
+			})
+		}
+
+		pv := valueProduct(h, method)
+		category := &valuation.Categories[c]
+		category.Products = append(category.Products, pv)
+		category.Units += pv.Units
+		category.Value += pv.Value
+		valuation.Units += pv.Units
+		valuation.Value += pv.Value
+		if pv.Uncosted {
+			category.UncostedUnits += pv.Units
+			valuation.UncostedUnits += pv.Units
+		}
+	}
+
+	return valuation, nil
+}
+
+func valueProduct(h *models.ProductCostHistory, method string) models.ProductValuation {
+
+	pv := models.ProductValuation{
+		ProductID: h.ProductID,
+		Name:      h.Name,
+		Units:     h.Stock,
+	}
+
+	fallback := h.CostPrice
+	for i := 0; fallback == nil && i < len(h.Movements); i++ {
+		if h.Movements[i].Quantity > 0 {
+			fallback = h.Movements[i].UnitCost
+		}
+	}
+	if fallback == nil {
+		pv.Uncosted = true
+		return pv
+	}
+
+	if method == models.ValuationFIFO {
+		pv.Value = fifoValue(h, *fallback)
+	} else {
+		pv.Value = weightedAverageValue(h, *fallback)
+	}
+
+	pv.UnitCost = mulDivRound(pv.Value, 1, int64(pv.Units))
+	return pv
+}
+
+// weightedAverageValue keeps a perpetual average: receipts blend into it and
+// issues leave it unchanged.
+func weightedAverageValue(h *models.ProductCostHistory, fallback int64) int64 {
+
+	var onHand, value int64
+	for _, m := range h.Movements {
+		qty := int64(m.Quantity)
+		if qty > 0 {
+			cost := fallback
+			if m.UnitCost != nil {
+				cost = *m.UnitCost
+			}
+			onHand += qty
+			value += qty * cost
+			continue
+		}
+
+		out := min(-qty, onHand)
+		if out > 0 {
+			value -= mulDivRound(value, out, onHand)
+			onHand -= out
+		}
+	}
+
+	if onHand <= 0 {
+		return int64(h.Stock) * fallback
+	}
+	return mulDivRound(value, int64(h.Stock), onHand)
+}
+
+// fifoValue issues stock from the oldest receipts first and values what is
+// left at the cost of the newest ones. Stock the ledger does not explain is
+// valued at the fallback cost.
+func fifoValue(h *models.ProductCostHistory, fallback int64) int64 {
+
+	type layer struct {
+		qty  int64
+		cost int64
+	}
+
+	var layers []layer
+	for _, m := range h.Movements {
+		qty := int64(m.Quantity)
+		if qty > 0 {
+			cost := fallback
+			if m.UnitCost != nil {
+				cost = *m.UnitCost
+			}
+			layers = append(layers, layer{qty: qty, cost: cost})
+			continue
+		}
+
+		out := -qty
+		for out > 0 && len(layers) > 0 {
+			take := min(out, layers[0].qty)
+			layers[0].qty -= take
+			out -= take
+			if layers[0].qty == 0 {
+				layers = layers[1:]
+			}
+		}
+	}
+
+	var value int64
+	remaining := int64(h.Stock)
+	for i := len(layers) - 1; i >= 0 && remaining > 0; i-- {
+		take := min(remaining, layers[i].qty)
+		value += take * layers[i].cost
+		remaining -= take
+	}
+
+	return value + remaining*fallback
+}
+
+// mulDivRound returns a*b/c rounded half away from zero, without overflowing
+// on the intermediate product. c must be positive.
+func mulDivRound(a, b, c int64) int64 {
+
+	n := new(big.Int).Mul(big.NewInt(a), big.NewInt(b))
+	q, r := new(big.Int).QuoRem(n, big.NewInt(c), new(big.Int))
+	if r.Abs(r).Lsh(r, 1).Cmp(big.NewInt(c)) >= 0 {
+		q.Add(q, big.NewInt(int64(n.Sign())))
+	}
+	return q.Int64()
+}
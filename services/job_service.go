@@ -0,0 +1,480 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/anggakrnwn/product-catalog-api/repositories"
+	"github.com/google/uuid"
+)
+
+const (
+	jobPollInterval   = 2 * time.Second
+	jobStaleAfter     = 5 * time.Minute
+	jobHeartbeatEvery = time.Minute
+	jobMaxAttempts    = 3
+	jobProgressEvery  = 50
+	jobMaxErrorsKept  = 100
+	productCSVColumns = "id,name,price,stock,category_id,sku,barcode,slug"
+)
+
+type JobService interface {
+	Create(req *models.CreateJobRequest, input io.Reader) (*models.Job, error)
+	GetByID(id string) (*models.Job, error)
+	GetArtifact(id string) (*models.Job, error)
+	Start(ctx context.Context, workers int)
+}
+
+type jobService struct {
+	repo           repositories.JobRepository
+	productService ProductService
+	storageDir     string
+}
+
+func NewJobService(repo repositories.JobRepository, productService ProductService, storageDir string) JobService {
+	return &jobService{
+		repo:           repo,
+		productService: productService,
+		storageDir:     storageDir,
+	}
+}
+
+func (s *jobService) Create(req *models.CreateJobRequest, input io.Reader) (*models.Job, error) {
+
+	req.Type = strings.ToLower(strings.TrimSpace(req.Type))
+	req.Format = strings.ToLower(strings.TrimSpace(req.Format))
+
+	if req.Type != models.JobTypeImport && req.Type != models.JobTypeExport {
+		return nil, errors.New("type is required and must be import or export")
+	}
+
+	if req.Format == "" {
+		req.Format = models.JobFormatCSV
+	}
+	if req.Format != models.JobFormatCSV && req.Format != models.JobFormatJSON {
+		return nil, errors.New("format must be csv or json")
+	}
+
+	job := &models.Job{
+		Type:   req.Type,
+		Format: req.Format,
+	}
+
+	if req.Type == models.JobTypeImport {
+		if input == nil {
+			return nil, errors.New("import file is required")
+		}
+
+		path, err := s.saveInput(input, req.Format)
+		if err != nil {
+			return nil, err
+		}
+		job.InputPath = path
+	}
+
+	if err := s.repo.Create(job); err != nil {
+		if job.InputPath != "" {
+			os.Remove(job.InputPath)
+		}
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (s *jobService) GetByID(id string) (*models.Job, error) {
+
+	jobID, err := uuid.Parse(strings.TrimSpace(id))
+	if err != nil {
+		return nil, errors.New("invalid job ID format")
+	}
+
+	return s.repo.GetByID(jobID)
+}
+
+func (s *jobService) GetArtifact(id string) (*models.Job, error) {
+
+	job, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.Status != models.JobStatusCompleted || job.ArtifactPath == "" {
+		return nil, errors.New("artifact not found")
+	}
+
+	if _, err := os.Stat(job.ArtifactPath); err != nil {
+		return nil, errors.New("artifact not found")
+	}
+
+	return job, nil
+}
+
+// Start launches the worker pool. Workers stop once ctx is cancelled; a job
+// that was interrupted is picked up again after jobStaleAfter.
+func (s *jobService) Start(ctx context.Context, workers int) {
+
+	if workers < 1 {
+		workers = 1
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.work(ctx)
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		log.Println("Job workers stopped")
+	}()
+}
+
+func (s *jobService) work(ctx context.Context) {
+
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		// drain the queue before going back to sleep
+		for ctx.Err() == nil {
+			if err := s.repo.FailExhausted(jobStaleAfter, jobMaxAttempts); err != nil {
+				log.Println("Failed to expire abandoned jobs:", err)
+			}
+
+			job, err := s.repo.ClaimNext(jobStaleAfter, jobMaxAttempts)
+			if err != nil {
+				log.Println("Failed to claim job:", err)
+				break
+			}
+			if job == nil {
+				break
+			}
+
+			s.run(job)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *jobService) run(job *models.Job) {
+
+	// keep the claim fresh while a row or the export takes its time
+	done := make(chan struct{})
+	defer close(done)
+	go s.heartbeat(job.ID, job.Attempts, done)
+
+	var err error
+	switch job.Type {
+	case models.JobTypeImport:
+		err = s.runImport(job)
+	case models.JobTypeExport:
+		err = s.runExport(job)
+	default:
+		err = fmt.Errorf("unknown job type %q", job.Type)
+	}
+
+	if err != nil {
+		job.Errors = appendJobError(job.Errors, models.JobError{Message: err.Error()})
+		if err := s.repo.Fail(job); err != nil {
+			log.Printf("Failed to mark job %s as failed: %v", job.ID, err)
+		}
+		return
+	}
+
+	if err := s.repo.Complete(job); err != nil {
+		log.Printf("Failed to mark job %s as completed: %v", job.ID, err)
+	}
+}
+
+// heartbeat refreshes the job's claim until done is closed or the claim is
+// lost, in which case the run's next write fails as well.
+func (s *jobService) heartbeat(id uuid.UUID, attempts int, done <-chan struct{}) {
+
+	ticker := time.NewTicker(jobHeartbeatEvery)
+	defer ticker.Stop()
+
+	claim := &models.Job{ID: id, Attempts: attempts}
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := s.repo.Heartbeat(claim); err != nil {
+				log.Printf("Failed to refresh job %s: %v", id, err)
+				if strings.Contains(err.Error(), "claimed by another worker") {
+					return
+				}
+			}
+		}
+	}
+}
+
+// runImport resumes from job.Processed, so rows handled before a restart are
+// not created twice (apart from those after the last progress flush).
+func (s *jobService) runImport(job *models.Job) error {
+
+	file, err := os.Open(job.InputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	records, err := decodeProductRecords(file, job.Format)
+	if err != nil {
+		return err
+	}
+
+	job.Total = len(records)
+
+	for i := job.Processed; i < len(records); i++ {
+		rec := records[i]
+
+		categoryID, err := uuid.Parse(strings.TrimSpace(rec.CategoryID))
+		if err != nil {
+			err = errors.New("invalid category ID format")
+		} else {
+			_, err = s.productService.Create(&models.CreateProductRequest{
+				Name:       rec.Name,
+				SKU:        &rec.SKU,
+				Barcode:    &rec.Barcode,
+				Slug:       rec.Slug,
+				Price:      rec.Price,
+				Stock:      rec.Stock,
+				CategoryID: categoryID,
+			})
+		}
+
+		job.Processed++
+		if err != nil {
+			job.Failed++
+			job.Errors = appendJobError(job.Errors, models.JobError{Row: i + 1, Message: err.Error()})
+		} else {
+			job.Succeeded++
+		}
+
+		if job.Processed%jobProgressEvery == 0 {
+			if err := s.repo.UpdateProgress(job); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *jobService) runExport(job *models.Job) error {
+
+	products, err := s.productService.GetAll()
+	if err != nil {
+		return err
+	}
+
+	job.Total = len(products)
+	if err := s.repo.UpdateProgress(job); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.jobsDir(), 0o755); err != nil {
+		return err
+	}
+
+	path := filepath.Join(s.jobsDir(), job.ID.String()+"-export."+job.Format)
+	tmp, err := os.CreateTemp(s.jobsDir(), "export-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	records := make([]models.ProductRecord, 0, len(products))
+	for _, p := range products {
+		records = append(records, models.ProductRecord{
+			ID:         p.ID.String(),
+			Name:       p.Name,
+			Price:      p.ListPrice,
+			Stock:      p.Stock,
+			CategoryID: p.CategoryID.String(),
+			SKU:        stringValue(p.SKU),
+			Barcode:    stringValue(p.Barcode),
+			Slug:       p.Slug,
+		})
+	}
+
+	if err := encodeProductRecords(tmp, job.Format, records); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	job.Processed = len(records)
+	job.Succeeded = len(records)
+	job.ArtifactPath = path
+	return nil
+}
+
+func (s *jobService) jobsDir() string {
+	return filepath.Join(s.storageDir, "jobs")
+}
+
+func (s *jobService) saveInput(input io.Reader, format string) (string, error) {
+
+	if err := os.MkdirAll(s.jobsDir(), 0o755); err != nil {
+		return "", err
+	}
+
+	file, err := os.CreateTemp(s.jobsDir(), "import-*."+format)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(file, input); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+
+	return file.Name(), nil
+}
+
+func appendJobError(jobErrors []models.JobError, e models.JobError) []models.JobError {
+	if len(jobErrors) >= jobMaxErrorsKept {
+		return jobErrors
+	}
+	return append(jobErrors, e)
+}
+
+func decodeProductRecords(r io.Reader, format string) ([]models.ProductRecord, error) {
+
+	if format == models.JobFormatJSON {
+		var records []models.ProductRecord
+		if err := json.NewDecoder(r).Decode(&records); err != nil {
+			return nil, errors.New("invalid JSON import file")
+		}
+		return records, nil
+	}
+
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("invalid CSV import file")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"name", "price", "category_id"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("CSV import file is missing column %s", required)
+		}
+	}
+
+	field := func(row []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	var records []models.ProductRecord
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// bad numbers are left at -1 so product validation rejects the row
+		price, err := strconv.ParseInt(field(row, "price"), 10, 64)
+		if err != nil {
+			price = -1
+		}
+		stock := 0
+		if v := field(row, "stock"); v != "" {
+			if stock, err = strconv.Atoi(v); err != nil {
+				stock = -1
+			}
+		}
+
+		records = append(records, models.ProductRecord{
+			Name:       field(row, "name"),
+			Price:      price,
+			Stock:      stock,
+			CategoryID: field(row, "category_id"),
+			SKU:        field(row, "sku"),
+			Barcode:    field(row, "barcode"),
+			Slug:       field(row, "slug"),
+		})
+	}
+
+	return records, nil
+}
+
+func encodeProductRecords(w io.Writer, format string, records []models.ProductRecord) error {
+
+	if format == models.JobFormatJSON {
+		return json.NewEncoder(w).Encode(records)
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(strings.Split(productCSVColumns, ",")); err != nil {
+		return err
+	}
+
+	for _, rec := range records {
+		err := writer.Write([]string{
+			rec.ID,
+			rec.Name,
+			strconv.FormatInt(rec.Price, 10),
+			strconv.Itoa(rec.Stock),
+			rec.CategoryID,
+			rec.SKU,
+			rec.Barcode,
+			rec.Slug,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,351 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/anggakrnwn/product-catalog-api/repositories"
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/ean"
+	"github.com/google/uuid"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/skip2/go-qrcode"
+)
+
+// barcodeQuietZone is the blank margin, in modules, kept on each side of the
+// bars so scanners can find where the code starts.
+const barcodeQuietZone = 10
+
+type LabelService interface {
+	BarcodePNG(productID uuid.UUID, moduleWidth, height int) (*models.BarcodeImage, error)
+	BarcodeSVG(productID uuid.UUID, moduleWidth, height int) (*models.BarcodeImage, error)
+	QRCode(productID uuid.UUID, size int) ([]byte, error)
+	Sheet(req *models.LabelSheetRequest) ([]byte, error)
+}
+
+type labelService struct {
+	productRepo repositories.ProductRepository
+	pricing     PricingService
+	publicURL   string
+}
+
+func NewLabelService(productRepo repositories.ProductRepository, pricing PricingService, publicURL string) LabelService {
+	return &labelService{
+		productRepo: productRepo,
+		pricing:     pricing,
+		publicURL:   strings.TrimRight(publicURL, "/"),
+	}
+}
+
+// BarcodePNG renders the product's barcode, EAN when it has one and Code128
+// of its SKU otherwise. moduleWidth is the width of the narrowest bar in
+// pixels and height the height of the bars.
+func (s *labelService) BarcodePNG(productID uuid.UUID, moduleWidth, height int) (*models.BarcodeImage, error) {
+
+	if err := validateBarcodeSize(moduleWidth, height); err != nil {
+		return nil, err
+	}
+
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	bc, symbology, err := encodeProductBarcode(product)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := barcodePNG(bc, moduleWidth, height)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.BarcodeImage{Symbology: symbology, Content: bc.Content(), Data: data}, nil
+}
+
+func (s *labelService) BarcodeSVG(productID uuid.UUID, moduleWidth, height int) (*models.BarcodeImage, error) {
+
+	if err := validateBarcodeSize(moduleWidth, height); err != nil {
+		return nil, err
+	}
+
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	bc, symbology, err := encodeProductBarcode(product)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.BarcodeImage{Symbology: symbology, Content: bc.Content(), Data: barcodeSVG(bc, moduleWidth, height)}, nil
+}
+
+// QRCode renders a size x size PNG QR code of the product's public URL.
+func (s *labelService) QRCode(productID uuid.UUID, size int) ([]byte, error) {
+
+	if size < 64 || size > 1024 {
+		return nil, errors.New("size must be between 64 and 1024")
+	}
+
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	return qrcode.Encode(s.productURL(product), qrcode.Medium, size)
+}
+
+// Sheet lays out labels with name, price and barcode on A4 pages, filling
+// each row left to right. Products without a barcode or SKU get a label
+// without bars.
+func (s *labelService) Sheet(req *models.LabelSheetRequest) ([]byte, error) {
+
+	if len(req.ProductIDs) == 0 {
+		return nil, errors.New("product_ids is required")
+	}
+
+	copies := req.Copies
+	if copies == 0 {
+		copies = 1
+	}
+	if copies < 1 || copies > models.MaxLabelCopies {
+		return nil, fmt.Errorf("copies must be between 1 and %d", models.MaxLabelCopies)
+	}
+	if len(req.ProductIDs)*copies > models.MaxLabelsPerJob {
+		return nil, fmt.Errorf("cannot print more than %d labels at once", models.MaxLabelsPerJob)
+	}
+
+	ctx := models.PriceContext{
+		PriceList: strings.ToUpper(strings.TrimSpace(req.PriceList)),
+		Tax:       req.Tax,
+	}
+	if req.Currency != "" {
+		currency, err := models.NormalizeCurrency(req.Currency)
+		if err != nil {
+			return nil, err
+		}
+		ctx.Currency = currency
+	}
+	if ctx.Tax != "" && ctx.Tax != models.TaxInclusive && ctx.Tax != models.TaxExclusive {
+		return nil, errors.New("tax must be incl or excl")
+	}
+
+	products, err := s.productRepo.List(models.ProductFilter{IDs: uniqueIDs(req.ProductIDs)})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.pricing.Apply(products, ctx); err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uuid.UUID]*models.Product, len(products))
+	for i := range products {
+		byID[products[i].ID] = &products[i]
+	}
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(0, 0, 0)
+	pdf.SetAutoPageBreak(false, 0)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	const (
+		labelWidth  = 63.5
+		labelHeight = 38.1
+		marginLeft  = 7.2
+		marginTop   = 15.1
+		columnGap   = 2.5
+		padding     = 3.0
+	)
+
+	barcodes := make(map[uuid.UUID]string)
+	slot := 0
+	for _, id := range req.ProductIDs {
+		product, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("product %s not found", id)
+		}
+
+		imageName, hasBars := barcodes[id]
+		if !hasBars {
+			if bc, _, err := encodeProductBarcode(product); err == nil {
+				data, err := barcodePNG(bc, 2, 60)
+				if err != nil {
+					return nil, err
+				}
+				imageName = "barcode-" + id.String()
+				pdf.RegisterImageOptionsReader(imageName, gofpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(data))
+				if err := pdf.Error(); err != nil {
+					return nil, err
+				}
+			}
+			barcodes[id] = imageName
+		}
+
+		for c := 0; c < copies; c++ {
+			if slot%(models.LabelColumns*models.LabelRows) == 0 {
+				pdf.AddPage()
+			}
+			col := slot % models.LabelColumns
+			row := slot / models.LabelColumns % models.LabelRows
+			x := marginLeft + float64(col)*(labelWidth+columnGap) + padding
+			y := marginTop + float64(row)*labelHeight + padding
+			width := labelWidth - 2*padding
+
+			pdf.SetFont("Helvetica", "B", 9)
+			pdf.SetXY(x, y)
+			pdf.CellFormat(width, 4.5, fitText(pdf, tr(product.Name), width), "", 0, "L", false, 0, "")
+
+			pdf.SetFont("Helvetica", "B", 13)
+			pdf.SetXY(x, y+5)
+			price := models.Money{Amount: product.Price, Currency: product.Currency}
+			pdf.CellFormat(width, 6, price.String(), "", 0, "L", false, 0, "")
+
+			if imageName != "" {
+				pdf.ImageOptions(imageName, x, y+12.5, width, 14, false, gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+				pdf.SetFont("Helvetica", "", 7)
+				pdf.SetXY(x, y+27)
+				pdf.CellFormat(width, 3, tr(barcodeText(product)), "", 0, "C", false, 0, "")
+			}
+			slot++
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *labelService) productURL(product *models.Product) string {
+	return s.publicURL + "/api/products/by-slug/" + product.Slug
+}
+
+func validateBarcodeSize(moduleWidth, height int) error {
+	if moduleWidth < 1 || moduleWidth > 10 {
+		return errors.New("module width must be between 1 and 10")
+	}
+	if height < 20 || height > 600 {
+		return errors.New("height must be between 20 and 600")
+	}
+	return nil
+}
+
+// encodeProductBarcode encodes the product's barcode as EAN-13 or EAN-8; a
+// UPC-A code is an EAN-13 with a leading zero. Without one it falls back to
+// Code128 of the SKU.
+func encodeProductBarcode(product *models.Product) (barcode.Barcode, string, error) {
+
+	if product.Barcode != nil {
+		code := *product.Barcode
+		symbology := "ean13"
+		switch len(code) {
+		case 8:
+			symbology = "ean8"
+		case 12:
+			code = "0" + code
+		}
+		bc, err := ean.Encode(code)
+		if err != nil {
+			return nil, "", err
+		}
+		return bc, symbology, nil
+	}
+
+	if product.SKU != nil {
+		bc, err := code128.Encode(*product.SKU)
+		if err != nil {
+			return nil, "", fmt.Errorf("sku cannot be encoded as code128: %w", err)
+		}
+		return bc, "code128", nil
+	}
+
+	return nil, "", errors.New("product must have a barcode or sku to render a barcode")
+}
+
+// barcodeText is the human-readable line printed under the bars.
+func barcodeText(product *models.Product) string {
+	if product.Barcode != nil {
+		return *product.Barcode
+	}
+	if product.SKU != nil {
+		return *product.SKU
+	}
+	return ""
+}
+
+func barcodePNG(bc barcode.Barcode, moduleWidth, height int) ([]byte, error) {
+
+	modules := bc.Bounds().Dx()
+	scaled, err := barcode.Scale(bc, modules*moduleWidth, height)
+	if err != nil {
+		return nil, err
+	}
+
+	quiet := barcodeQuietZone * moduleWidth
+	img := image.NewGray(image.Rect(0, 0, modules*moduleWidth+2*quiet, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(img, scaled.Bounds().Add(image.Pt(quiet, 0)), scaled, image.Point{}, draw.Src)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// barcodeSVG draws each run of dark modules as one rect, in module units so
+// the image scales without blurring.
+func barcodeSVG(bc barcode.Barcode, moduleWidth, height int) []byte {
+
+	modules := bc.Bounds().Dx()
+	total := modules + 2*barcodeQuietZone
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" preserveAspectRatio="none" shape-rendering="crispEdges">`,
+		total*moduleWidth, height, total, height)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#fff"/>`, total, height)
+
+	for x := 0; x < modules; {
+		if !isDark(bc.At(x, 0)) {
+			x++
+			continue
+		}
+		start := x
+		for x < modules && isDark(bc.At(x, 0)) {
+			x++
+		}
+		fmt.Fprintf(&buf, `<rect x="%d" width="%d" height="%d"/>`, start+barcodeQuietZone, x-start, height)
+	}
+
+	buf.WriteString("</svg>")
+	return buf.Bytes()
+}
+
+func isDark(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return r+g+b < 3*0x8000
+}
+
+// fitText shortens s with an ellipsis until it fits in width at the current
+// font. s is already translated to the single-byte core font encoding.
+func fitText(pdf *gofpdf.Fpdf, s string, width float64) string {
+	if pdf.GetStringWidth(s) <= width {
+		return s
+	}
+	for len(s) > 0 && pdf.GetStringWidth(s+"...") > width {
+		s = s[:len(s)-1]
+	}
+	return s + "..."
+}
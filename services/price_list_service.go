@@ -0,0 +1,200 @@
+package services
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/anggakrnwn/product-catalog-api/repositories"
+	"github.com/google/uuid"
+)
+
+type PriceListService interface {
+	GetAll() ([]models.PriceList, error)
+	GetByID(id uuid.UUID) (*models.PriceList, error)
+	Create(req *models.CreatePriceListRequest) (*models.PriceList, error)
+	Update(id uuid.UUID, req *models.UpdatePriceListRequest) (*models.PriceList, error)
+	Delete(id uuid.UUID) error
+	GetItems(listID uuid.UUID) ([]models.PriceListItem, error)
+	SetItems(listID uuid.UUID, req *models.SetPriceListItemsRequest) ([]models.PriceListItem, error)
+	DeleteItem(listID, productID uuid.UUID) error
+	GetRates() ([]models.ExchangeRate, error)
+	SetRate(req *models.SetExchangeRateRequest) (*models.ExchangeRate, error)
+	DeleteRate(base, quote string) error
+}
+
+type priceListService struct {
+	repo repositories.PriceListRepository
+}
+
+func NewPriceListService(repo repositories.PriceListRepository) PriceListService {
+	return &priceListService{repo: repo}
+}
+
+func (s *priceListService) GetAll() ([]models.PriceList, error) {
+	return s.repo.GetAll()
+}
+
+func (s *priceListService) GetByID(id uuid.UUID) (*models.PriceList, error) {
+
+	if id == uuid.Nil {
+		return nil, errors.New("price list ID is required")
+	}
+	return s.repo.GetByID(id)
+}
+
+func (s *priceListService) Create(req *models.CreatePriceListRequest) (*models.PriceList, error) {
+
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+	if code == "" {
+		return nil, errors.New("code is required")
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+
+	currency, err := models.NormalizeCurrency(req.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &models.PriceList{
+		Code:     code,
+		Name:     name,
+		Currency: currency,
+	}
+
+	if err := s.repo.Create(list); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (s *priceListService) Update(id uuid.UUID, req *models.UpdatePriceListRequest) (*models.PriceList, error) {
+
+	existing, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, errors.New("name cannot be empty")
+		}
+		existing.Name = name
+	}
+
+	if err := s.repo.Update(id, existing); err != nil {
+		return nil, err
+	}
+
+	return existing, nil
+}
+
+func (s *priceListService) Delete(id uuid.UUID) error {
+
+	if id == uuid.Nil {
+		return errors.New("price list ID is required")
+	}
+	return s.repo.Delete(id)
+}
+
+func (s *priceListService) GetItems(listID uuid.UUID) ([]models.PriceListItem, error) {
+
+	if _, err := s.GetByID(listID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetItems(listID)
+}
+
+func (s *priceListService) SetItems(listID uuid.UUID, req *models.SetPriceListItemsRequest) ([]models.PriceListItem, error) {
+
+	if _, err := s.GetByID(listID); err != nil {
+		return nil, err
+	}
+
+	if len(req.Items) == 0 {
+		return nil, errors.New("at least one item is required")
+	}
+
+	for _, item := range req.Items {
+		if item.ProductID == uuid.Nil {
+			return nil, errors.New("product ID is required on every item")
+		}
+		if item.Price < 0 {
+			return nil, errors.New("price must be positive on every item")
+		}
+	}
+
+	if err := s.repo.SetItems(listID, req.Items); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetItems(listID)
+}
+
+func (s *priceListService) DeleteItem(listID, productID uuid.UUID) error {
+
+	if listID == uuid.Nil || productID == uuid.Nil {
+		return errors.New("price list ID and product ID are required")
+	}
+	return s.repo.DeleteItem(listID, productID)
+}
+
+func (s *priceListService) GetRates() ([]models.ExchangeRate, error) {
+	return s.repo.GetRates()
+}
+
+func (s *priceListService) SetRate(req *models.SetExchangeRateRequest) (*models.ExchangeRate, error) {
+
+	base, err := models.NormalizeCurrency(req.Base)
+	if err != nil {
+		return nil, err
+	}
+
+	quote, err := models.NormalizeCurrency(req.Quote)
+	if err != nil {
+		return nil, err
+	}
+
+	if base == quote {
+		return nil, errors.New("base and quote currency must differ")
+	}
+
+	rate, ok := new(big.Rat).SetString(req.Rate.String())
+	if !ok || rate.Sign() <= 0 {
+		return nil, errors.New("rate must be a positive number")
+	}
+
+	exchangeRate := &models.ExchangeRate{
+		Base:  base,
+		Quote: quote,
+		Rate:  req.Rate.String(),
+	}
+
+	if err := s.repo.SetRate(exchangeRate); err != nil {
+		return nil, err
+	}
+
+	return exchangeRate, nil
+}
+
+func (s *priceListService) DeleteRate(base, quote string) error {
+
+	base, err := models.NormalizeCurrency(base)
+	if err != nil {
+		return err
+	}
+
+	quote, err = models.NormalizeCurrency(quote)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.DeleteRate(base, quote)
+}
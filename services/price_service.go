@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/anggakrnwn/product-catalog-api/repositories"
+	"github.com/google/uuid"
+)
+
+const (
+	maxPriceHistoryLimit  = 200
+	priceScheduleInterval = time.Minute
+)
+
+type PriceService interface {
+	GetHistory(productID uuid.UUID, limit, offset int) ([]models.PriceChange, int, error)
+	GetSchedules(productID uuid.UUID) ([]models.PriceSchedule, error)
+	CreateSchedule(productID uuid.UUID, req *models.CreatePriceScheduleRequest) (*models.PriceSchedule, error)
+	DeleteSchedule(productID, id uuid.UUID) error
+	Start(ctx context.Context)
+}
+
+type priceService struct {
+	repo        repositories.PriceRepository
+	productRepo repositories.ProductRepository
+}
+
+func NewPriceService(repo repositories.PriceRepository, productRepo repositories.ProductRepository) PriceService {
+	return &priceService{
+		repo:        repo,
+		productRepo: productRepo,
+	}
+}
+
+func (s *priceService) GetHistory(productID uuid.UUID, limit, offset int) ([]models.PriceChange, int, error) {
+
+	if productID == uuid.Nil {
+		return nil, 0, errors.New("product ID is required")
+	}
+
+	if _, err := s.productRepo.GetByID(productID); err != nil {
+		return nil, 0, err
+	}
+
+	if limit <= 0 || limit > maxPriceHistoryLimit {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	return s.repo.GetHistory(productID, limit, offset)
+}
+
+func (s *priceService) GetSchedules(productID uuid.UUID) ([]models.PriceSchedule, error) {
+
+	if productID == uuid.Nil {
+		return nil, errors.New("product ID is required")
+	}
+
+	if _, err := s.productRepo.GetByID(productID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetSchedules(productID)
+}
+
+func (s *priceService) CreateSchedule(productID uuid.UUID, req *models.CreatePriceScheduleRequest) (*models.PriceSchedule, error) {
+
+	if productID == uuid.Nil {
+		return nil, errors.New("product ID is required")
+	}
+
+	if req.Price < 0 {
+		return nil, errors.New("price must be positive")
+	}
+
+	if req.StartsAt.IsZero() {
+		return nil, errors.New("starts_at is required")
+	}
+
+	if req.EndsAt != nil && !req.EndsAt.After(req.StartsAt) {
+		return nil, errors.New("ends_at must be after starts_at")
+	}
+
+	schedule := &models.PriceSchedule{
+		ProductID: productID,
+		Price:     req.Price,
+		StartsAt:  req.StartsAt,
+		EndsAt:    req.EndsAt,
+		Priority:  req.Priority,
+		Label:     strings.TrimSpace(req.Label),
+	}
+
+	if err := s.repo.CreateSchedule(schedule); err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+func (s *priceService) DeleteSchedule(productID, id uuid.UUID) error {
+
+	if productID == uuid.Nil || id == uuid.Nil {
+		return errors.New("product ID and schedule ID are required")
+	}
+
+	return s.repo.DeleteSchedule(productID, id)
+}
+
+// Start runs the scheduler that records scheduled price transitions in the
+// price history until ctx is cancelled. Reads resolve the effective price on
+// their own, so this only keeps the history complete.
+func (s *priceService) Start(ctx context.Context) {
+
+	go func() {
+		ticker := time.NewTicker(priceScheduleInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				recorded, err := s.repo.MaterializeScheduled()
+				if err != nil {
+					log.Println("Failed to record scheduled prices:", err)
+				} else if recorded > 0 {
+					log.Printf("Recorded %d scheduled price changes", recorded)
+				}
+			}
+		}
+	}()
+}
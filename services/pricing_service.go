@@ -0,0 +1,340 @@
+package services
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/anggakrnwn/product-catalog-api/repositories"
+	"github.com/google/uuid"
+)
+
+// PricingService presents stored prices, which are in the base currency's
+// minor unit, in the currency, price list and tax mode a caller asked for.
+type PricingService interface {
+	BaseCurrency() string
+	Apply(products []models.Product, ctx models.PriceContext) error
+	ApplyDetail(product *models.ProductWithCategory, ctx models.PriceContext) error
+	ApplyRelated(relations map[string][]models.RelatedProduct, ctx models.PriceContext) error
+}
+
+type pricingService struct {
+	priceListRepo    repositories.PriceListRepository
+	taxRepo          repositories.TaxRepository
+	baseCurrency     string
+	pricesIncludeTax bool
+}
+
+func NewPricingService(priceListRepo repositories.PriceListRepository, taxRepo repositories.TaxRepository, baseCurrency string, pricesIncludeTax bool) PricingService {
+	return &pricingService{
+		priceListRepo:    priceListRepo,
+		taxRepo:          taxRepo,
+		baseCurrency:     baseCurrency,
+		pricesIncludeTax: pricesIncludeTax,
+	}
+}
+
+func (s *pricingService) BaseCurrency() string {
+	return s.baseCurrency
+}
+
+// Apply rewrites the prices of products in place. A price list replaces the
+// price of every product it covers, keeping its compare-at and lowest prices
+// in the list's currency; the rest, and any ?currency= request, are
+// converted through the exchange-rate table. Tax is worked out last, on
+// the final currency's minor unit.
+func (s *pricingService) Apply(products []models.Product, ctx models.PriceContext) error {
+
+	rates := make(map[string]*big.Rat)
+
+	var list *models.PriceList
+	var listPrices map[uuid.UUID]int64
+	if ctx.PriceList != "" && len(products) > 0 {
+		var err error
+		list, err = s.resolvePriceList(ctx.PriceList)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]uuid.UUID, len(products))
+		for i := range products {
+			ids[i] = products[i].ID
+		}
+		listPrices, err = s.priceListRepo.GetItemPrices(list.ID, ids)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := range products {
+		p := &products[i]
+		p.Currency = s.baseCurrency
+
+		if list != nil {
+			if price, ok := listPrices[p.ID]; ok {
+				// the reference prices stay what the product really sold
+				// at, converted when there is a rate and left out otherwise
+				if err := s.convertProduct(p, list.Currency, rates); err != nil {
+					p.CompareAtPrice = nil
+					p.LowestPrice30d = nil
+				}
+				p.Price = price
+				p.ListPrice = price
+				if p.CompareAtPrice != nil && *p.CompareAtPrice <= price {
+					p.CompareAtPrice = nil
+				}
+				p.Currency = list.Currency
+			} else if err := s.convertProduct(p, list.Currency, rates); err != nil {
+				return err
+			}
+		}
+
+		if ctx.Currency != "" {
+			if err := s.convertProduct(p, ctx.Currency, rates); err != nil {
+				return err
+			}
+		}
+	}
+
+	if ctx.Tax != "" && len(products) > 0 {
+		return s.applyTax(products, ctx.Tax)
+	}
+
+	return nil
+}
+
+// applyTax presents every price inclusive or exclusive of tax and attaches
+// the net/tax/gross split of Price. Products without a tax class are taxed
+// at zero.
+func (s *pricingService) applyTax(products []models.Product, mode string) error {
+
+	ids := make([]uuid.UUID, len(products))
+	for i := range products {
+		ids[i] = products[i].ID
+	}
+
+	taxes, err := s.taxRepo.GetProductTaxes(ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range products {
+		p := &products[i]
+		tax := taxes[p.ID]
+
+		net, taxAmount, gross := models.SplitTax(p.Price, tax.RateBP, s.pricesIncludeTax)
+		p.Tax = &models.TaxBreakdown{
+			Mode:     mode,
+			TaxClass: tax.TaxClassCode,
+			RateBP:   tax.RateBP,
+			Net:      net,
+			Tax:      taxAmount,
+			Gross:    gross,
+		}
+
+		p.Price = s.presentTax(p.Price, tax.RateBP, mode)
+		p.ListPrice = s.presentTax(p.ListPrice, tax.RateBP, mode)
+		if p.LowestPrice30d != nil {
+			lowest := s.presentTax(*p.LowestPrice30d, tax.RateBP, mode)
+			p.LowestPrice30d = &lowest
+		}
+		if p.CompareAtPrice != nil {
+			compareAt := s.presentTax(*p.CompareAtPrice, tax.RateBP, mode)
+			p.CompareAtPrice = &compareAt
+		}
+	}
+
+	return nil
+}
+
+// presentTax turns a stored amount into its gross or net form.
+func (s *pricingService) presentTax(amount int64, rateBP int, mode string) int64 {
+	net, _, gross := models.SplitTax(amount, rateBP, s.pricesIncludeTax)
+	if mode == models.TaxInclusive {
+		return gross
+	}
+	return net
+}
+
+// ApplyRelated applies ctx to the prices of related products, the same way
+// Apply does for the product they are listed on.
+func (s *pricingService) ApplyRelated(relations map[string][]models.RelatedProduct, ctx models.PriceContext) error {
+
+	// keep a pointer per entry; map order differs between passes
+	var entries []*models.RelatedProduct
+	var products []models.Product
+	for _, related := range relations {
+		for j := range related {
+			entries = append(entries, &related[j])
+			products = append(products, models.Product{ID: related[j].ID, Price: related[j].Price, ListPrice: related[j].Price})
+		}
+	}
+
+	if err := s.Apply(products, ctx); err != nil {
+		return err
+	}
+
+	for i, rp := range entries {
+		rp.Price = products[i].Price
+		rp.Currency = products[i].Currency
+	}
+	return nil
+}
+
+// ApplyDetail applies ctx to a product detail. Variants without an override
+// sell at the product's price, so they take whatever price the product ends
+// up with; only overrides are converted from the base currency. When no
+// rate reaches the product's currency, as with a price list in a currency
+// the rate table does not cover, overridden variants fall back to the
+// product's price rather than failing the read.
+func (s *pricingService) ApplyDetail(product *models.ProductWithCategory, ctx models.PriceContext) error {
+
+	products := []models.Product{product.Product}
+	if err := s.Apply(products, ctx); err != nil {
+		return err
+	}
+	product.Product = products[0]
+
+	var rate *big.Rat
+	converts := product.Currency == s.baseCurrency
+	if !converts {
+		for _, v := range product.Variants {
+			if v.PriceOverride != nil {
+				var err error
+				rate, err = s.rate(s.baseCurrency, product.Currency, make(map[string]*big.Rat))
+				converts = err == nil
+				break
+			}
+		}
+	}
+
+	for i := range product.Variants {
+		v := &product.Variants[i]
+		if v.PriceOverride == nil || !converts {
+			v.Price = product.Price
+			v.PriceOverride = nil
+			continue
+		}
+
+		override := *v.PriceOverride
+		if rate != nil {
+			var err error
+			if override, err = s.convertAmount(override, product.Currency, rate); err != nil {
+				return err
+			}
+		}
+		if product.Tax != nil {
+			override = s.presentTax(override, product.Tax.RateBP, product.Tax.Mode)
+		}
+		v.Price = override
+		v.PriceOverride = &override
+	}
+
+	return nil
+}
+
+func (s *pricingService) resolvePriceList(ref string) (*models.PriceList, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		return s.priceListRepo.GetByID(id)
+	}
+	return s.priceListRepo.GetByCode(ref)
+}
+
+func (s *pricingService) convertProduct(p *models.Product, to string, rates map[string]*big.Rat) error {
+
+	if p.Currency == to {
+		return nil
+	}
+
+	rate, err := s.rate(p.Currency, to, rates)
+	if err != nil {
+		return err
+	}
+
+	from := p.Currency
+	convert := func(amount int64) (int64, error) {
+		m, err := models.Money{Amount: amount, Currency: from}.Convert(to, rate)
+		return m.Amount, err
+	}
+
+	if p.Price, err = convert(p.Price); err != nil {
+		return err
+	}
+	if p.ListPrice, err = convert(p.ListPrice); err != nil {
+		return err
+	}
+	if p.LowestPrice30d != nil {
+		lowest, err := convert(*p.LowestPrice30d)
+		if err != nil {
+			return err
+		}
+		p.LowestPrice30d = &lowest
+	}
+	if p.CompareAtPrice != nil {
+		compareAt, err := convert(*p.CompareAtPrice)
+		if err != nil {
+			return err
+		}
+		p.CompareAtPrice = &compareAt
+	}
+
+	p.Currency = to
+	return nil
+}
+
+func (s *pricingService) convertAmount(amount int64, to string, rate *big.Rat) (int64, error) {
+	m, err := models.Money{Amount: amount, Currency: s.baseCurrency}.Convert(to, rate)
+	return m.Amount, err
+}
+
+// rate finds the from→to rate: stored directly, as the inverse of to→from, or
+// crossed through the base currency.
+func (s *pricingService) rate(from, to string, cache map[string]*big.Rat) (*big.Rat, error) {
+
+	key := from + "/" + to
+	if rate, ok := cache[key]; ok {
+		return rate, nil
+	}
+
+	rate, err := s.pairRate(from, to)
+	if err != nil && from != s.baseCurrency && to != s.baseCurrency {
+		var toBase, fromBase *big.Rat
+		if toBase, err = s.pairRate(from, s.baseCurrency); err == nil {
+			if fromBase, err = s.pairRate(s.baseCurrency, to); err == nil {
+				rate = new(big.Rat).Mul(toBase, fromBase)
+			}
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot convert %s to %s: no exchange rate", from, to)
+	}
+
+	cache[key] = rate
+	return rate, nil
+}
+
+func (s *pricingService) pairRate(from, to string) (*big.Rat, error) {
+
+	if value, err := s.priceListRepo.GetRate(from, to); err == nil {
+		return parseRate(value)
+	}
+
+	value, err := s.priceListRepo.GetRate(to, from)
+	if err != nil {
+		return nil, err
+	}
+
+	rate, err := parseRate(value)
+	if err != nil {
+		return nil, err
+	}
+	return rate.Inv(rate), nil
+}
+
+func parseRate(value string) (*big.Rat, error) {
+	rate, ok := new(big.Rat).SetString(value)
+	if !ok || rate.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid exchange rate %q", value)
+	}
+	return rate, nil
+}
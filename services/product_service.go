@@ -2,8 +2,12 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"math"
 	"strings"
+	"unicode/utf8"
 
+	"github.com/anggakrnwn/product-catalog-api/events"
 	"github.com/anggakrnwn/product-catalog-api/models"
 	"github.com/anggakrnwn/product-catalog-api/repositories"
 	"github.com/google/uuid"
@@ -12,22 +16,32 @@ import (
 type ProductService interface {
 	GetAll() ([]models.Product, error)
 	GetByID(id uuid.UUID) (*models.Product, error)
-	GetWithCategory(id uuid.UUID) (*models.ProductWithCategory, error)
+	GetWithCategory(id uuid.UUID, includeCost bool) (*models.ProductWithCategory, error)
 	Create(req *models.CreateProductRequest) (*models.Product, error)
 	Update(id uuid.UUID, req *models.UpdateProductRequest) (*models.Product, error)
 	Delete(id uuid.UUID) error
 	GetByCategoryID(categoryID uuid.UUID) ([]models.Product, error)
+	GetByCategoryTree(categoryID uuid.UUID) ([]models.Product, error)
+	List(filter models.ProductFilter) ([]models.Product, error)
+	Facets(filter models.ProductFilter, priceBounds []int64) (*models.ProductFacets, error)
+	FindBySKU(sku string) (*models.ProductLookup, error)
+	FindByBarcode(code string) (*models.ProductLookup, error)
+	FindBySlug(slug string) (*models.ProductLookup, error)
 }
 
 type productService struct {
 	repo         repositories.ProductRepository
 	categoryRepo repositories.CategoryRepository
+	variantRepo  repositories.VariantRepository
+	bus          *events.Bus
 }
 
-func NewProductService(repo repositories.ProductRepository, categoryRepo repositories.CategoryRepository) ProductService {
+func NewProductService(repo repositories.ProductRepository, categoryRepo repositories.CategoryRepository, variantRepo repositories.VariantRepository, bus *events.Bus) ProductService {
 	return &productService{
 		repo:         repo,
 		categoryRepo: categoryRepo,
+		variantRepo:  variantRepo,
+		bus:          bus,
 	}
 }
 
@@ -43,12 +57,26 @@ func (s *productService) GetByID(id uuid.UUID) (*models.Product, error) {
 	return s.repo.GetByID(id)
 }
 
-func (s *productService) GetWithCategory(id uuid.UUID) (*models.ProductWithCategory, error) {
+func (s *productService) GetWithCategory(id uuid.UUID, includeCost bool) (*models.ProductWithCategory, error) {
 
 	if id == uuid.Nil {
 		return nil, errors.New("product ID is required")
 	}
-	return s.repo.GetWithCategory(id)
+
+	product, err := s.repo.GetWithCategory(id, includeCost)
+	if err != nil {
+		return nil, err
+	}
+
+	if product.Options, err = s.variantRepo.GetOptions(id); err != nil {
+		return nil, err
+	}
+
+	if product.Variants, err = s.variantRepo.GetByProductID(id); err != nil {
+		return nil, err
+	}
+
+	return product, nil
 }
 
 func (s *productService) Create(req *models.CreateProductRequest) (*models.Product, error) {
@@ -75,16 +103,74 @@ func (s *productService) Create(req *models.CreateProductRequest) (*models.Produ
 		return nil, errors.New("category ID is required")
 	}
 
+	if req.LowStockThreshold != nil && *req.LowStockThreshold < 0 {
+		return nil, errors.New("low stock threshold cannot be negative")
+	}
+
+	if req.ReorderQty < 0 {
+		return nil, errors.New("reorder quantity cannot be negative")
+	}
+
+	if req.CostPrice != nil && *req.CostPrice < 0 {
+		return nil, errors.New("cost price cannot be negative")
+	}
+
+	req.SKU = trimOptional(req.SKU)
+	req.Barcode = trimOptional(req.Barcode)
+	if req.Barcode != nil {
+		if err := models.ValidateBarcode(*req.Barcode); err != nil {
+			return nil, err
+		}
+	}
+
+	req.ShortDescription = strings.TrimSpace(req.ShortDescription)
+	req.Description = strings.TrimSpace(req.Description)
+	if err := validateDescriptions(req.ShortDescription, req.Description); err != nil {
+		return nil, err
+	}
+
+	slug := strings.TrimSpace(req.Slug)
+	if slug != "" {
+		if err := models.ValidateSlug(slug); err != nil {
+			return nil, err
+		}
+	} else {
+		generated, err := uniqueSlug(models.Slugify(req.Name), "product", s.repo.SlugTaken)
+		if err != nil {
+			return nil, err
+		}
+		slug = generated
+	}
+
 	_, err := s.categoryRepo.GetByID(req.CategoryID)
 	if err != nil {
 		return nil, errors.New("category not found")
 	}
 
+	attributes, err := s.validateAttributes(req.CategoryID, req.Attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.TaxClassID != nil && *req.TaxClassID == uuid.Nil {
+		req.TaxClassID = nil
+	}
+
 	product := &models.Product{
-		Name:       req.Name,
-		Price:      req.Price,
-		Stock:      req.Stock,
-		CategoryID: req.CategoryID,
+		Name:              req.Name,
+		SKU:               req.SKU,
+		Barcode:           req.Barcode,
+		Slug:              slug,
+		ShortDescription:  req.ShortDescription,
+		Description:       req.Description,
+		Price:             req.Price,
+		Stock:             req.Stock,
+		CategoryID:        req.CategoryID,
+		LowStockThreshold: req.LowStockThreshold,
+		ReorderQty:        req.ReorderQty,
+		TaxClassID:        req.TaxClassID,
+		CostPrice:         req.CostPrice,
+		Attributes:        attributes,
 	}
 
 	err = s.repo.Create(product)
@@ -118,12 +204,43 @@ func (s *productService) Update(id uuid.UUID, req *models.UpdateProductRequest)
 		existing.Name = name
 	}
 
+	if req.SKU != nil {
+		existing.SKU = trimOptional(req.SKU)
+	}
+
+	if req.Barcode != nil {
+		existing.Barcode = trimOptional(req.Barcode)
+		if existing.Barcode != nil {
+			if err := models.ValidateBarcode(*existing.Barcode); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if req.Slug != nil {
+		slug := strings.TrimSpace(*req.Slug)
+		if err := models.ValidateSlug(slug); err != nil {
+			return nil, err
+		}
+		existing.Slug = slug
+	}
+
+	if req.ShortDescription != nil {
+		existing.ShortDescription = strings.TrimSpace(*req.ShortDescription)
+	}
+	if req.Description != nil {
+		existing.Description = strings.TrimSpace(*req.Description)
+	}
+	if err := validateDescriptions(existing.ShortDescription, existing.Description); err != nil {
+		return nil, err
+	}
+
 	// untuk price
 	if req.Price != nil {
 		if *req.Price < 0 {
 			return nil, errors.New("price must be positive")
 		}
-		existing.Price = *req.Price
+		existing.ListPrice = *req.Price
 	}
 
 	// untuk stock
@@ -131,7 +248,14 @@ func (s *productService) Update(id uuid.UUID, req *models.UpdateProductRequest)
 		if *req.Stock < 0 {
 			return nil, errors.New("stock cannot be negative")
 		}
-		existing.Stock = *req.Stock
+
+		variants, err := s.variantRepo.GetByProductID(id)
+		if err != nil {
+			return nil, err
+		}
+		if len(variants) > 0 {
+			return nil, errors.New("stock cannot be set on a product with variants, update the variants instead")
+		}
 	}
 
 	// untuk category
@@ -147,11 +271,100 @@ func (s *productService) Update(id uuid.UUID, req *models.UpdateProductRequest)
 		existing.CategoryID = *req.CategoryID
 	}
 
-	if err := s.repo.Update(id, existing); err != nil {
+	// moving to another category checks the kept values against its schema
+	if req.Attributes != nil || req.CategoryID != nil {
+		values := existing.Attributes
+		if req.Attributes != nil {
+			values = req.Attributes
+		}
+		attributes, err := s.validateAttributes(existing.CategoryID, values)
+		if err != nil {
+			return nil, err
+		}
+		existing.Attributes = attributes
+	}
+
+	previousAvailable := existing.Available
+	previousThreshold := existing.LowStockThreshold
+
+	if req.LowStockThreshold != nil {
+		if *req.LowStockThreshold < 0 {
+			existing.LowStockThreshold = nil
+		} else {
+			existing.LowStockThreshold = req.LowStockThreshold
+		}
+	}
+
+	if req.ReorderQty != nil {
+		if *req.ReorderQty < 0 {
+			return nil, errors.New("reorder quantity cannot be negative")
+		}
+		existing.ReorderQty = *req.ReorderQty
+	}
+
+	if req.TaxClassID != nil {
+		if *req.TaxClassID == uuid.Nil {
+			existing.TaxClassID = nil
+		} else {
+			existing.TaxClassID = req.TaxClassID
+		}
+	}
+
+	if req.CostPrice != nil {
+		if *req.CostPrice < 0 {
+			existing.CostPrice = nil
+		} else {
+			existing.CostPrice = req.CostPrice
+		}
+	}
+
+	// stock goes through the ledger so the change is traceable
+	if err := s.repo.Update(id, existing, req.Actor, req.Stock); err != nil {
 		return nil, err
 	}
 
-	return s.repo.GetByID(id)
+	updated, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// only a stock change can cross; moving the threshold alone is not an event
+	if req.Stock != nil {
+		s.publishThresholdCrossing(previousAvailable, previousThreshold, updated)
+	}
+
+	return updated, nil
+}
+
+// publishThresholdCrossing raises stock.low when available stock drops to or
+// below the threshold and stock.restored when it climbs back above it. The
+// previous stock is judged against the threshold that applied to it.
+func (s *productService) publishThresholdCrossing(previousAvailable int, previousThreshold *int, product *models.Product) {
+
+	if s.bus == nil || product.LowStockThreshold == nil {
+		return
+	}
+
+	threshold := *product.LowStockThreshold
+	wasLow := previousThreshold != nil && previousAvailable <= *previousThreshold
+	isLow := product.Available <= threshold
+	if wasLow == isLow {
+		return
+	}
+
+	eventType := events.StockRestored
+	if isLow {
+		eventType = events.StockLow
+	}
+
+	s.bus.Publish(eventType, models.StockThresholdEvent{
+		ProductID:         product.ID,
+		Name:              product.Name,
+		PreviousAvailable: previousAvailable,
+		Available:         product.Available,
+		LowStockThreshold: threshold,
+		ReorderQty:        product.ReorderQty,
+	})
 }
 
 func (s *productService) Delete(id uuid.UUID) error {
@@ -160,7 +373,14 @@ func (s *productService) Delete(id uuid.UUID) error {
 		return errors.New("product ID is required")
 	}
 
-	return s.repo.Delete(id)
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+
+	if s.bus != nil {
+		s.bus.Publish(events.ProductDeleted, models.ProductDeletedEvent{ProductID: id})
+	}
+	return nil
 }
 
 func (s *productService) GetByCategoryID(categoryID uuid.UUID) ([]models.Product, error) {
@@ -170,3 +390,190 @@ func (s *productService) GetByCategoryID(categoryID uuid.UUID) ([]models.Product
 
 	return s.repo.GetByCategoryID(categoryID)
 }
+
+func (s *productService) GetByCategoryTree(categoryID uuid.UUID) ([]models.Product, error) {
+	if categoryID == uuid.Nil {
+		return nil, errors.New("category ID is required")
+	}
+
+	return s.repo.GetByCategoryTree(categoryID)
+}
+
+func (s *productService) List(filter models.ProductFilter) ([]models.Product, error) {
+	return s.repo.List(filter)
+}
+
+// Facets counts the filtered products per facet value. priceBounds are the
+// lower bounds of the price buckets; without them the buckets are fitted to
+// the prices the price facet covers.
+func (s *productService) Facets(filter models.ProductFilter, priceBounds []int64) (*models.ProductFacets, error) {
+
+	if len(priceBounds) == 0 {
+		byPrice := filter
+		byPrice.MinPrice, byPrice.MaxPrice = nil, nil
+		min, max, err := s.repo.PriceRange(byPrice)
+		if err != nil {
+			return nil, err
+		}
+		if min != nil && max != nil {
+			priceBounds = models.PriceBucketBounds(*min, *max, models.DefaultPriceBuckets)
+		}
+	} else if priceBounds[0] > 0 {
+		// prices below the first given bound still need a bucket
+		priceBounds = append([]int64{0}, priceBounds...)
+	}
+
+	return s.repo.Facets(filter, priceBounds)
+}
+
+func (s *productService) FindBySKU(sku string) (*models.ProductLookup, error) {
+
+	sku = strings.TrimSpace(sku)
+	if sku == "" {
+		return nil, errors.New("sku is required")
+	}
+	return s.repo.FindBySKU(sku)
+}
+
+func (s *productService) FindByBarcode(code string) (*models.ProductLookup, error) {
+
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return nil, errors.New("barcode is required")
+	}
+	return s.repo.FindByBarcode(code)
+}
+
+func (s *productService) FindBySlug(slug string) (*models.ProductLookup, error) {
+
+	slug = strings.ToLower(strings.TrimSpace(slug))
+	if slug == "" {
+		return nil, errors.New("slug is required")
+	}
+	return s.repo.FindBySlug(slug)
+}
+
+// validateAttributes checks values against the category's attribute schema
+// and returns them normalized: strings trimmed, enum values in their defined
+// spelling, and empty or null values dropped.
+func (s *productService) validateAttributes(categoryID uuid.UUID, values map[string]any) (map[string]any, error) {
+
+	schema, err := s.categoryRepo.GetAttributes(categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	defined := make(map[string]models.CategoryAttribute, len(schema))
+	for _, a := range schema {
+		defined[a.Name] = a
+	}
+
+	result := make(map[string]any, len(values))
+	for name, value := range values {
+		def, ok := defined[name]
+		if !ok {
+			return nil, fmt.Errorf("attribute %s cannot be set on products in this category", name)
+		}
+		if value == nil {
+			continue
+		}
+
+		switch def.Type {
+		case models.AttributeString:
+			str, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("attribute %s must be a string", name)
+			}
+			str = strings.TrimSpace(str)
+			if utf8.RuneCountInString(str) > models.MaxAttributeStringLength {
+				return nil, fmt.Errorf("attribute %s cannot be longer than %d characters", name, models.MaxAttributeStringLength)
+			}
+			if str != "" {
+				result[name] = str
+			}
+		case models.AttributeNumber, models.AttributeInteger:
+			number, ok := value.(float64)
+			if !ok {
+				return nil, fmt.Errorf("attribute %s must be a number", name)
+			}
+			if def.Type == models.AttributeInteger && number != math.Trunc(number) {
+				return nil, fmt.Errorf("attribute %s must be a whole number", name)
+			}
+			result[name] = number
+		case models.AttributeBoolean:
+			flag, ok := value.(bool)
+			if !ok {
+				return nil, fmt.Errorf("attribute %s must be true or false", name)
+			}
+			result[name] = flag
+		case models.AttributeEnum:
+			str, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("attribute %s must be one of %s", name, strings.Join(def.Values, ", "))
+			}
+			str = strings.TrimSpace(str)
+			if str == "" {
+				continue
+			}
+			matched := ""
+			for _, v := range def.Values {
+				if strings.EqualFold(v, str) {
+					matched = v
+					break
+				}
+			}
+			if matched == "" {
+				return nil, fmt.Errorf("attribute %s must be one of %s", name, strings.Join(def.Values, ", "))
+			}
+			result[name] = matched
+		}
+	}
+
+	for _, def := range schema {
+		if _, ok := result[def.Name]; def.Required && !ok {
+			return nil, fmt.Errorf("attribute %s is required", def.Name)
+		}
+	}
+
+	return result, nil
+}
+
+func validateDescriptions(short, long string) error {
+	if utf8.RuneCountInString(short) > models.MaxShortDescriptionLength {
+		return fmt.Errorf("short_description cannot be longer than %d characters", models.MaxShortDescriptionLength)
+	}
+	if utf8.RuneCountInString(long) > models.MaxDescriptionLength {
+		return fmt.Errorf("description cannot be longer than %d characters", models.MaxDescriptionLength)
+	}
+	return nil
+}
+
+// uniqueSlug returns base, or base with the lowest numeric suffix that is not
+// taken. An empty base falls back to fallback.
+func uniqueSlug(base, fallback string, taken func(string) (bool, error)) (string, error) {
+
+	if base == "" {
+		base = fallback
+	}
+
+	for n := 1; n <= 100; n++ {
+		slug := base
+		if n > 1 {
+			suffix := fmt.Sprintf("-%d", n)
+			if len(slug)+len(suffix) > models.MaxSlugLength {
+				slug = strings.TrimRight(slug[:models.MaxSlugLength-len(suffix)], "-")
+			}
+			slug += suffix
+		}
+
+		inUse, err := taken(slug)
+		if err != nil {
+			return "", err
+		}
+		if !inUse {
+			return slug, nil
+		}
+	}
+
+	return "", errors.New("cannot find a free slug for " + base + ", give one explicitly")
+}
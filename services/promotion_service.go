@@ -0,0 +1,674 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/anggakrnwn/product-catalog-api/repositories"
+	"github.com/google/uuid"
+)
+
+const maxCartItems = 200
+
+type PromotionService interface {
+	GetAll(runningOnly bool) ([]models.Promotion, error)
+	GetByID(id uuid.UUID) (*models.Promotion, error)
+	Create(req *models.CreatePromotionRequest) (*models.Promotion, error)
+	Update(id uuid.UUID, req *models.UpdatePromotionRequest) (*models.Promotion, error)
+	Delete(id uuid.UUID) error
+	Evaluate(req *models.EvaluateCartRequest) (*models.CartEvaluation, error)
+}
+
+type promotionService struct {
+	repo         repositories.PromotionRepository
+	productRepo  repositories.ProductRepository
+	baseCurrency string
+}
+
+func NewPromotionService(repo repositories.PromotionRepository, productRepo repositories.ProductRepository, baseCurrency string) PromotionService {
+	return &promotionService{
+		repo:         repo,
+		productRepo:  productRepo,
+		baseCurrency: baseCurrency,
+	}
+}
+
+func (s *promotionService) GetAll(runningOnly bool) ([]models.Promotion, error) {
+
+	if runningOnly {
+		now := time.Now()
+		return s.repo.GetAll(&now)
+	}
+	return s.repo.GetAll(nil)
+}
+
+func (s *promotionService) GetByID(id uuid.UUID) (*models.Promotion, error) {
+
+	if id == uuid.Nil {
+		return nil, errors.New("promotion ID is required")
+	}
+	return s.repo.GetByID(id)
+}
+
+func (s *promotionService) Create(req *models.CreatePromotionRequest) (*models.Promotion, error) {
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+
+	promotion := &models.Promotion{
+		Name:        name,
+		Type:        strings.ToLower(strings.TrimSpace(req.Type)),
+		DiscountBP:  req.DiscountBP,
+		Amount:      req.Amount,
+		BuyQty:      req.BuyQty,
+		GetQty:      req.GetQty,
+		BundleQty:   req.BundleQty,
+		BundlePrice: req.BundlePrice,
+		ProductIDs:  uniqueIDs(req.ProductIDs),
+		CategoryIDs: uniqueIDs(req.CategoryIDs),
+		StartsAt:    req.StartsAt,
+		EndsAt:      req.EndsAt,
+		UsageLimit:  req.UsageLimit,
+		Priority:    req.Priority,
+		Stackable:   true,
+		Active:      true,
+	}
+
+	if code := normalizeCouponCode(req.Code); code != "" {
+		promotion.Code = &code
+	}
+
+	// without a scope, targets make a targeted promotion
+	promotion.Scope = strings.ToLower(strings.TrimSpace(req.Scope))
+	if promotion.Scope == "" {
+		promotion.Scope = models.PromotionScopeCatalog
+		if len(promotion.ProductIDs) > 0 || len(promotion.CategoryIDs) > 0 {
+			promotion.Scope = models.PromotionScopeTargets
+		}
+	}
+	if req.Stackable != nil {
+		promotion.Stackable = *req.Stackable
+	}
+	if req.Active != nil {
+		promotion.Active = *req.Active
+	}
+
+	if err := validatePromotion(promotion); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(promotion); err != nil {
+		return nil, err
+	}
+
+	return promotion, nil
+}
+
+func (s *promotionService) Update(id uuid.UUID, req *models.UpdatePromotionRequest) (*models.Promotion, error) {
+
+	existing, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, errors.New("name cannot be empty")
+		}
+		existing.Name = name
+	}
+
+	if req.Code != nil {
+		existing.Code = nil
+		if code := normalizeCouponCode(*req.Code); code != "" {
+			existing.Code = &code
+		}
+	}
+
+	if req.DiscountBP != nil {
+		existing.DiscountBP = req.DiscountBP
+	}
+	if req.Amount != nil {
+		existing.Amount = req.Amount
+	}
+	if req.BuyQty != nil {
+		existing.BuyQty = req.BuyQty
+	}
+	if req.GetQty != nil {
+		existing.GetQty = req.GetQty
+	}
+	if req.BundleQty != nil {
+		existing.BundleQty = req.BundleQty
+	}
+	if req.BundlePrice != nil {
+		existing.BundlePrice = req.BundlePrice
+	}
+	if req.ProductIDs != nil {
+		existing.ProductIDs = uniqueIDs(*req.ProductIDs)
+	}
+	if req.CategoryIDs != nil {
+		existing.CategoryIDs = uniqueIDs(*req.CategoryIDs)
+	}
+	if req.Scope != nil {
+		existing.Scope = strings.ToLower(strings.TrimSpace(*req.Scope))
+	} else if len(existing.ProductIDs) > 0 || len(existing.CategoryIDs) > 0 {
+		existing.Scope = models.PromotionScopeTargets
+	}
+	if req.StartsAt != nil {
+		existing.StartsAt = req.StartsAt
+	}
+	if req.EndsAt != nil {
+		existing.EndsAt = req.EndsAt
+	}
+	if req.UsageLimit != nil {
+		existing.UsageLimit = req.UsageLimit
+		if *req.UsageLimit == 0 {
+			existing.UsageLimit = nil
+		}
+	}
+	if req.Priority != nil {
+		existing.Priority = *req.Priority
+	}
+	if req.Stackable != nil {
+		existing.Stackable = *req.Stackable
+	}
+	if req.Active != nil {
+		existing.Active = *req.Active
+	}
+
+	if err := validatePromotion(existing); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(id, existing); err != nil {
+		return nil, err
+	}
+
+	return existing, nil
+}
+
+func (s *promotionService) Delete(id uuid.UUID) error {
+
+	if id == uuid.Nil {
+		return errors.New("promotion ID is required")
+	}
+	return s.repo.Delete(id)
+}
+
+// cartLineState tracks a line through evaluation. A locked line has been
+// discounted by a non-stackable promotion and takes no further discounts.
+type cartLineState struct {
+	line       models.CartLine
+	categories map[uuid.UUID]bool
+	locked     bool
+}
+
+// Evaluate prices the cart from each product's current price and applies the
+// running promotions and presented coupons in priority order. Coupons that do
+// not apply are reported rather than failing the request.
+func (s *promotionService) Evaluate(req *models.EvaluateCartRequest) (*models.CartEvaluation, error) {
+
+	if len(req.Items) == 0 {
+		return nil, errors.New("at least one item is required")
+	}
+	if len(req.Items) > maxCartItems {
+		return nil, fmt.Errorf("a cart cannot have more than %d items", maxCartItems)
+	}
+
+	products := make(map[uuid.UUID]*models.Product)
+	var ids []uuid.UUID
+	for _, item := range req.Items {
+		if item.ProductID == uuid.Nil {
+			return nil, errors.New("product ID is required on every item")
+		}
+		if item.Quantity <= 0 {
+			return nil, errors.New("quantity must be positive on every item")
+		}
+		if _, seen := products[item.ProductID]; seen {
+			continue
+		}
+
+		product, err := s.productRepo.GetByID(item.ProductID)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				return nil, fmt.Errorf("product %s not found", item.ProductID)
+			}
+			return nil, err
+		}
+		products[item.ProductID] = product
+		ids = append(ids, item.ProductID)
+	}
+
+	var codes []string
+	seenCodes := make(map[string]bool)
+	for _, raw := range req.CouponCodes {
+		code := normalizeCouponCode(raw)
+		if code == "" || seenCodes[code] {
+			continue
+		}
+		seenCodes[code] = true
+		codes = append(codes, code)
+	}
+
+	now := time.Now()
+	promotions, err := s.repo.GetForCart(now, codes)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := s.repo.GetProductCategories(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	evaluation := &models.CartEvaluation{
+		Currency:        s.baseCurrency,
+		Applied:         []models.AppliedDiscount{},
+		RejectedCoupons: []models.RejectedCoupon{},
+	}
+
+	lines := make([]cartLineState, len(req.Items))
+	for i, item := range req.Items {
+		product := products[item.ProductID]
+		subtotal := product.Price * int64(item.Quantity)
+		lines[i] = cartLineState{
+			line: models.CartLine{
+				ProductID: product.ID,
+				Name:      product.Name,
+				Quantity:  item.Quantity,
+				UnitPrice: product.Price,
+				Subtotal:  subtotal,
+				Discounts: []models.AppliedDiscount{},
+				Total:     subtotal,
+			},
+			categories: make(map[uuid.UUID]bool),
+		}
+		for _, categoryID := range categories[product.ID] {
+			lines[i].categories[categoryID] = true
+		}
+	}
+
+	var candidates []*models.Promotion
+	byCode := make(map[string]*models.Promotion)
+	for i := range promotions {
+		p := &promotions[i]
+		if p.Code != nil {
+			byCode[*p.Code] = p
+			continue
+		}
+		if !p.Exhausted() {
+			candidates = append(candidates, p)
+		}
+	}
+
+	reject := func(code, reason string) {
+		evaluation.RejectedCoupons = append(evaluation.RejectedCoupons, models.RejectedCoupon{Code: code, Reason: reason})
+	}
+
+	for _, code := range codes {
+		p, ok := byCode[code]
+		if !ok {
+			reject(code, "coupon not found")
+			continue
+		}
+		if reason := couponUnavailable(p, now); reason != "" {
+			reject(code, reason)
+			continue
+		}
+		candidates = append(candidates, p)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Priority != candidates[j].Priority {
+			return candidates[i].Priority > candidates[j].Priority
+		}
+		return candidates[i].CreatedAt.Before(candidates[j].CreatedAt)
+	})
+
+	var appliedIDs []uuid.UUID
+	for _, p := range candidates {
+		var eligible []int
+		for i := range lines {
+			l := &lines[i]
+			if l.locked || l.line.Total == 0 || !promotionTargets(p, l) {
+				continue
+			}
+			if !p.Stackable && len(l.line.Discounts) > 0 {
+				continue
+			}
+			eligible = append(eligible, i)
+		}
+
+		var total int64
+		for k, amount := range promotionDiscounts(p, lines, eligible) {
+			if amount <= 0 {
+				continue
+			}
+			l := &lines[eligible[k]]
+			l.line.Discounts = append(l.line.Discounts, appliedDiscount(p, amount))
+			l.line.DiscountTotal += amount
+			l.line.Total -= amount
+			if !p.Stackable {
+				l.locked = true
+			}
+			total += amount
+		}
+
+		if total == 0 {
+			if p.Code != nil {
+				reject(*p.Code, "coupon does not apply to this cart")
+			}
+			continue
+		}
+
+		evaluation.Applied = append(evaluation.Applied, appliedDiscount(p, total))
+		appliedIDs = append(appliedIDs, p.ID)
+	}
+
+	for _, l := range lines {
+		evaluation.Lines = append(evaluation.Lines, l.line)
+		evaluation.Subtotal += l.line.Subtotal
+		evaluation.DiscountTotal += l.line.DiscountTotal
+		evaluation.Total += l.line.Total
+	}
+
+	if req.Redeem && len(appliedIDs) > 0 {
+		if err := s.repo.Redeem(appliedIDs); err != nil {
+			return nil, err
+		}
+		evaluation.Redeemed = true
+	}
+
+	return evaluation, nil
+}
+
+// unitRun is count units of one eligible line, each worth value.
+type unitRun struct {
+	slot  int
+	value int64
+	count int
+}
+
+// promotionDiscounts works out what p takes off each of the eligible lines,
+// returned in the same order as eligible.
+func promotionDiscounts(p *models.Promotion, lines []cartLineState, eligible []int) []int64 {
+
+	out := make([]int64, len(eligible))
+	if len(eligible) == 0 {
+		return out
+	}
+
+	runs := make([]unitRun, len(eligible))
+	units := 0
+	for k, i := range eligible {
+		l := lines[i].line
+		runs[k] = unitRun{slot: k, value: l.Total / int64(l.Quantity), count: l.Quantity}
+		units += l.Quantity
+	}
+
+	switch p.Type {
+	case models.PromotionPercent:
+		for k, i := range eligible {
+			out[k] = models.BasisPointsOf(lines[i].line.Total, *p.DiscountBP)
+		}
+
+	case models.PromotionFixed:
+		for k, i := range eligible {
+			l := lines[i].line
+			out[k] = min(*p.Amount*int64(l.Quantity), l.Total)
+		}
+
+	case models.PromotionBuyXGetY:
+		discounted := units / (*p.BuyQty + *p.GetQty) * *p.GetQty
+		sort.SliceStable(runs, func(i, j int) bool { return runs[i].value < runs[j].value })
+		for _, run := range runs {
+			if discounted == 0 {
+				break
+			}
+			take := min(run.count, discounted)
+			out[run.slot] += int64(take) * models.BasisPointsOf(run.value, *p.DiscountBP)
+			discounted -= take
+		}
+
+	case models.PromotionBundle:
+		sort.SliceStable(runs, func(i, j int) bool { return runs[i].value > runs[j].value })
+		total, weights := bundleDiscount(runs, len(eligible), *p.BundleQty, *p.BundlePrice)
+		out = allocate(total, weights)
+	}
+
+	return out
+}
+
+// bundleDiscount groups the units, most expensive first, into bundles of size
+// and totals what each complete bundle saves over its price. weights holds
+// the value each slot contributes to bundles that save something.
+func bundleDiscount(runs []unitRun, slots, size int, price int64) (int64, []int64) {
+
+	type part struct {
+		slot  int
+		value int64
+	}
+
+	weights := make([]int64, slots)
+	var total, groupValue int64
+	var parts []part
+	filled := 0
+
+	for _, run := range runs {
+		remaining := run.count
+		for remaining > 0 {
+			if filled == 0 && remaining >= size {
+				bundles := remaining / size
+				if value := run.value * int64(size); value > price {
+					total += int64(bundles) * (value - price)
+					weights[run.slot] += int64(bundles) * value
+				}
+				remaining -= bundles * size
+				continue
+			}
+
+			take := min(remaining, size-filled)
+			parts = append(parts, part{slot: run.slot, value: run.value * int64(take)})
+			groupValue += run.value * int64(take)
+			filled += take
+			remaining -= take
+
+			if filled == size {
+				if groupValue > price {
+					total += groupValue - price
+					for _, pt := range parts {
+						weights[pt.slot] += pt.value
+					}
+				}
+				parts, groupValue, filled = parts[:0], 0, 0
+			}
+		}
+	}
+
+	return total, weights
+}
+
+// allocate splits total across weights proportionally without giving any
+// slot more than its weight. total must not exceed the sum of weights.
+func allocate(total int64, weights []int64) []int64 {
+
+	out := make([]int64, len(weights))
+
+	var sum int64
+	for _, w := range weights {
+		sum += w
+	}
+	if total <= 0 || sum == 0 {
+		return out
+	}
+
+	var given int64
+	t, s := big.NewInt(total), big.NewInt(sum)
+	for i, w := range weights {
+		share := new(big.Int).Mul(t, big.NewInt(w))
+		out[i] = share.Quo(share, s).Int64()
+		given += out[i]
+	}
+
+	for i := 0; given < total; i = (i + 1) % len(out) {
+		if out[i] < weights[i] {
+			out[i]++
+			given++
+		}
+	}
+
+	return out
+}
+
+func promotionTargets(p *models.Promotion, l *cartLineState) bool {
+
+	if p.Scope == models.PromotionScopeCatalog {
+		return true
+	}
+	for _, id := range p.ProductIDs {
+		if id == l.line.ProductID {
+			return true
+		}
+	}
+	for _, id := range p.CategoryIDs {
+		if l.categories[id] {
+			return true
+		}
+	}
+	return false
+}
+
+func couponUnavailable(p *models.Promotion, now time.Time) string {
+	switch {
+	case !p.Active:
+		return "coupon is not active"
+	case p.StartsAt != nil && now.Before(*p.StartsAt):
+		return "coupon is not valid yet"
+	case p.EndsAt != nil && !now.Before(*p.EndsAt):
+		return "coupon has expired"
+	case p.Exhausted():
+		return "coupon usage limit reached"
+	}
+	return ""
+}
+
+func appliedDiscount(p *models.Promotion, amount int64) models.AppliedDiscount {
+	return models.AppliedDiscount{
+		PromotionID: p.ID,
+		Name:        p.Name,
+		Code:        p.Code,
+		Type:        p.Type,
+		Amount:      amount,
+	}
+}
+
+// validatePromotion checks the fields p.Type needs and clears the ones it
+// does not use.
+func validatePromotion(p *models.Promotion) error {
+
+	switch p.Type {
+	case models.PromotionPercent:
+		if p.DiscountBP == nil {
+			return errors.New("discount_bp is required for percent promotions")
+		}
+		p.Amount, p.BuyQty, p.GetQty, p.BundleQty, p.BundlePrice = nil, nil, nil, nil, nil
+
+	case models.PromotionFixed:
+		if p.Amount == nil || *p.Amount <= 0 {
+			return errors.New("amount must be positive for fixed promotions")
+		}
+		p.DiscountBP, p.BuyQty, p.GetQty, p.BundleQty, p.BundlePrice = nil, nil, nil, nil, nil
+
+	case models.PromotionBuyXGetY:
+		if p.BuyQty == nil || *p.BuyQty < 1 {
+			return errors.New("buy_qty must be at least 1 for buy_x_get_y promotions")
+		}
+		if p.GetQty == nil || *p.GetQty < 1 {
+			return errors.New("get_qty must be at least 1 for buy_x_get_y promotions")
+		}
+		if p.DiscountBP == nil {
+			free := 10000
+			p.DiscountBP = &free
+		}
+		p.Amount, p.BundleQty, p.BundlePrice = nil, nil, nil
+
+	case models.PromotionBundle:
+		if p.BundleQty == nil || *p.BundleQty < 2 {
+			return errors.New("bundle_qty must be at least 2 for bundle promotions")
+		}
+		if p.BundlePrice == nil {
+			return errors.New("bundle_price is required for bundle promotions")
+		}
+		if *p.BundlePrice < 0 {
+			return errors.New("bundle_price cannot be negative")
+		}
+		p.DiscountBP, p.Amount, p.BuyQty, p.GetQty = nil, nil, nil, nil
+
+	default:
+		return errors.New("type must be percent, fixed, buy_x_get_y or bundle")
+	}
+
+	if p.DiscountBP != nil && (*p.DiscountBP <= 0 || *p.DiscountBP > 10000) {
+		return errors.New("discount_bp must be between 1 and 10000")
+	}
+
+	if p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+
+	if p.UsageLimit != nil && *p.UsageLimit < 1 {
+		return errors.New("usage_limit must be at least 1")
+	}
+
+	switch p.Scope {
+	case models.PromotionScopeCatalog:
+		if len(p.ProductIDs) > 0 || len(p.CategoryIDs) > 0 {
+			return errors.New("a catalog promotion cannot have product_ids or category_ids")
+		}
+	case models.PromotionScopeTargets:
+		if len(p.ProductIDs) == 0 && len(p.CategoryIDs) == 0 {
+			return errors.New("a targets promotion must have product_ids or category_ids")
+		}
+	default:
+		return errors.New("scope must be catalog or targets")
+	}
+
+	for _, id := range p.ProductIDs {
+		if id == uuid.Nil {
+			return errors.New("product_ids cannot contain an empty ID")
+		}
+	}
+	for _, id := range p.CategoryIDs {
+		if id == uuid.Nil {
+			return errors.New("category_ids cannot contain an empty ID")
+		}
+	}
+
+	return nil
+}
+
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func uniqueIDs(ids []uuid.UUID) []uuid.UUID {
+
+	out := []uuid.UUID{}
+	seen := make(map[uuid.UUID]bool)
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		out = append(out, id)
+	}
+	return out
+}
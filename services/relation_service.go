@@ -0,0 +1,100 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/anggakrnwn/product-catalog-api/repositories"
+	"github.com/google/uuid"
+)
+
+type RelationService interface {
+	GetAll(productID uuid.UUID) (map[string][]models.RelatedProduct, error)
+	Set(productID uuid.UUID, relationType string, req *models.SetRelationsRequest) ([]models.RelatedProduct, error)
+	Remove(productID uuid.UUID, relationType string, relatedID uuid.UUID) error
+}
+
+type relationService struct {
+	repo        repositories.RelationRepository
+	productRepo repositories.ProductRepository
+}
+
+func NewRelationService(repo repositories.RelationRepository, productRepo repositories.ProductRepository) RelationService {
+	return &relationService{
+		repo:        repo,
+		productRepo: productRepo,
+	}
+}
+
+// GetAll returns the product's relations keyed by type, with every type
+// present even when empty.
+func (s *relationService) GetAll(productID uuid.UUID) (map[string][]models.RelatedProduct, error) {
+
+	if _, err := s.productRepo.GetByID(productID); err != nil {
+		return nil, err
+	}
+
+	relations, err := s.repo.GetByProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range models.RelationTypes {
+		if relations[t] == nil {
+			relations[t] = []models.RelatedProduct{}
+		}
+	}
+	return relations, nil
+}
+
+func (s *relationService) Set(productID uuid.UUID, relationType string, req *models.SetRelationsRequest) ([]models.RelatedProduct, error) {
+
+	if err := validateRelationType(relationType); err != nil {
+		return nil, err
+	}
+
+	if len(req.ProductIDs) > models.MaxRelationsPerType {
+		return nil, fmt.Errorf("product cannot have more than %d %s products", models.MaxRelationsPerType, relationType)
+	}
+
+	seen := make(map[uuid.UUID]bool)
+	for _, id := range req.ProductIDs {
+		if id == uuid.Nil {
+			return nil, errors.New("product_ids cannot contain the nil UUID")
+		}
+		if id == productID {
+			return nil, errors.New("product cannot be related to itself")
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("product_ids must list %s only once", id)
+		}
+		seen[id] = true
+	}
+
+	if err := s.repo.Set(productID, relationType, req.ProductIDs); err != nil {
+		return nil, err
+	}
+
+	relations, err := s.repo.GetByProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+	return relations[relationType], nil
+}
+
+// Remove drops one relation; for symmetric types the way back goes too.
+func (s *relationService) Remove(productID uuid.UUID, relationType string, relatedID uuid.UUID) error {
+
+	if err := validateRelationType(relationType); err != nil {
+		return err
+	}
+	return s.repo.Remove(productID, relationType, relatedID)
+}
+
+func validateRelationType(relationType string) error {
+	if !models.ValidRelationType(relationType) {
+		return errors.New("relation type must be related, cross_sell, upsell, accessory or replacement")
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/anggakrnwn/product-catalog-api/repositories"
+	"github.com/google/uuid"
+)
+
+const reservationSweepInterval = 30 * time.Second
+
+type ReservationService interface {
+	Create(req *models.CreateReservationRequest) (*models.Reservation, error)
+	GetByID(id uuid.UUID) (*models.Reservation, error)
+	Confirm(id uuid.UUID, req *models.ConfirmReservationRequest) (*models.Reservation, error)
+	Release(id uuid.UUID) (*models.Reservation, error)
+	Start(ctx context.Context)
+}
+
+type reservationService struct {
+	repo repositories.ReservationRepository
+}
+
+func NewReservationService(repo repositories.ReservationRepository) ReservationService {
+	return &reservationService{repo: repo}
+}
+
+func (s *reservationService) Create(req *models.CreateReservationRequest) (*models.Reservation, error) {
+
+	if len(req.Lines) == 0 {
+		return nil, errors.New("at least one line is required")
+	}
+
+	// merge duplicate lines so each row is locked and checked once
+	var lines []models.ReservationLine
+	index := make(map[[2]uuid.UUID]int)
+	for _, line := range req.Lines {
+		if line.ProductID == uuid.Nil {
+			return nil, errors.New("product ID is required on every line")
+		}
+		if line.Quantity <= 0 {
+			return nil, errors.New("quantity must be positive on every line")
+		}
+
+		key := [2]uuid.UUID{line.ProductID}
+		if line.VariantID != nil {
+			key[1] = *line.VariantID
+		}
+		if i, ok := index[key]; ok {
+			lines[i].Quantity += line.Quantity
+			continue
+		}
+		index[key] = len(lines)
+		lines = append(lines, models.ReservationLine{ProductID: line.ProductID, VariantID: line.VariantID, Quantity: line.Quantity})
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].ProductID != lines[j].ProductID {
+			return lines[i].ProductID.String() < lines[j].ProductID.String()
+		}
+		return variantKey(lines[i].VariantID) < variantKey(lines[j].VariantID)
+	})
+
+	ttl := models.DefaultReservationTTL
+	if req.TTLSeconds > 0 {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+	}
+	if ttl > models.MaxReservationTTL {
+		return nil, fmt.Errorf("ttl_seconds must not exceed %d", int(models.MaxReservationTTL.Seconds()))
+	}
+
+	reservation := &models.Reservation{
+		Reference: strings.TrimSpace(req.Reference),
+		Lines:     lines,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+
+	if err := s.repo.Create(reservation); err != nil {
+		return nil, err
+	}
+
+	return reservation, nil
+}
+
+func (s *reservationService) GetByID(id uuid.UUID) (*models.Reservation, error) {
+
+	if id == uuid.Nil {
+		return nil, errors.New("reservation ID is required")
+	}
+
+	return s.repo.GetByID(id)
+}
+
+func (s *reservationService) Confirm(id uuid.UUID, req *models.ConfirmReservationRequest) (*models.Reservation, error) {
+
+	if id == uuid.Nil {
+		return nil, errors.New("reservation ID is required")
+	}
+
+	if err := s.repo.Confirm(id, strings.TrimSpace(req.Actor)); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByID(id)
+}
+
+func (s *reservationService) Release(id uuid.UUID) (*models.Reservation, error) {
+
+	if id == uuid.Nil {
+		return nil, errors.New("reservation ID is required")
+	}
+
+	if err := s.repo.Release(id); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByID(id)
+}
+
+// variantKey orders lines without a variant first.
+func variantKey(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
+
+// Start runs the sweeper that expires stale holds until ctx is cancelled.
+func (s *reservationService) Start(ctx context.Context) {
+
+	go func() {
+		ticker := time.NewTicker(reservationSweepInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				expired, err := s.repo.ExpireStale()
+				if err != nil {
+					log.Println("Failed to expire reservations:", err)
+				} else if expired > 0 {
+					log.Printf("Expired %d reservations", expired)
+				}
+			}
+		}
+	}()
+}
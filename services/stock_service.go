@@ -0,0 +1,132 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/anggakrnwn/product-catalog-api/repositories"
+	"github.com/google/uuid"
+)
+
+const maxStockHistoryLimit = 200
+
+type StockService interface {
+	Adjust(productID uuid.UUID, req *models.AdjustStockRequest) (*models.StockMovement, error)
+	GetHistory(productID uuid.UUID, limit, offset int) ([]models.StockMovement, int, error)
+	GetLevels(productID uuid.UUID) ([]models.StockLevel, error)
+}
+
+type stockService struct {
+	repo        repositories.StockRepository
+	productRepo repositories.ProductRepository
+	variantRepo repositories.VariantRepository
+}
+
+func NewStockService(repo repositories.StockRepository, productRepo repositories.ProductRepository, variantRepo repositories.VariantRepository) StockService {
+	return &stockService{
+		repo:        repo,
+		productRepo: productRepo,
+		variantRepo: variantRepo,
+	}
+}
+
+func (s *stockService) Adjust(productID uuid.UUID, req *models.AdjustStockRequest) (*models.StockMovement, error) {
+
+	if productID == uuid.Nil {
+		return nil, errors.New("product ID is required")
+	}
+
+	req.Type = strings.ToLower(strings.TrimSpace(req.Type))
+	if req.Type == "" {
+		req.Type = models.MovementAdjustment
+	}
+
+	if req.Quantity == 0 {
+		return nil, errors.New("quantity is required and cannot be zero")
+	}
+
+	switch req.Type {
+	case models.MovementReceipt, models.MovementReturn:
+		if req.Quantity < 0 {
+			return nil, errors.New("quantity must be positive for " + req.Type)
+		}
+	case models.MovementSale:
+		if req.Quantity > 0 {
+			return nil, errors.New("quantity must be negative for sale")
+		}
+	case models.MovementAdjustment:
+	case models.MovementTransfer:
+		// a transfer is two paired entries, written by the warehouse transfer
+		return nil, errors.New("transfer cannot be adjusted directly, use the warehouse transfer instead")
+	default:
+		return nil, errors.New("type must be receipt, sale, adjustment or return")
+	}
+
+	if req.UnitCost != nil {
+		if *req.UnitCost < 0 {
+			return nil, errors.New("unit cost cannot be negative")
+		}
+		if req.Quantity < 0 {
+			return nil, errors.New("unit cost can only be set on incoming stock")
+		}
+	}
+
+	variants, err := s.variantRepo.GetByProductID(productID)
+	if err != nil {
+		return nil, err
+	}
+	if len(variants) > 0 {
+		return nil, errors.New("stock cannot be adjusted on a product with variants, update the variants instead")
+	}
+
+	movement := &models.StockMovement{
+		ProductID:   productID,
+		WarehouseID: req.WarehouseID,
+		Type:        req.Type,
+		Quantity:    req.Quantity,
+		UnitCost:    req.UnitCost,
+		Reason:      strings.TrimSpace(req.Reason),
+		Reference:   strings.TrimSpace(req.Reference),
+		Actor:       strings.TrimSpace(req.Actor),
+	}
+
+	if err := s.repo.Adjust(movement); err != nil {
+		return nil, err
+	}
+
+	return movement, nil
+}
+
+func (s *stockService) GetHistory(productID uuid.UUID, limit, offset int) ([]models.StockMovement, int, error) {
+
+	if productID == uuid.Nil {
+		return nil, 0, errors.New("product ID is required")
+	}
+
+	if _, err := s.productRepo.GetByID(productID); err != nil {
+		return nil, 0, err
+	}
+
+	if limit <= 0 || limit > maxStockHistoryLimit {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	return s.repo.GetHistory(productID, limit, offset)
+}
+
+func (s *stockService) GetLevels(productID uuid.UUID) ([]models.StockLevel, error) {
+
+	if productID == uuid.Nil {
+		return nil, errors.New("product ID is required")
+	}
+
+	if _, err := s.productRepo.GetByID(productID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetLevels(productID)
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/anggakrnwn/product-catalog-api/repositories"
+	"github.com/google/uuid"
+)
+
+type TagService interface {
+	GetAll() ([]models.Tag, error)
+	GetByID(id uuid.UUID) (*models.Tag, error)
+	Create(req *models.CreateTagRequest) (*models.Tag, error)
+	Update(id uuid.UUID, req *models.UpdateTagRequest) (*models.Tag, error)
+	Delete(id uuid.UUID) error
+	Merge(id uuid.UUID, req *models.MergeTagRequest) (*models.MergeTagResult, error)
+	GetProductTags(productID uuid.UUID) ([]models.Tag, error)
+	AddProductTags(productID uuid.UUID, req *models.ProductTagsRequest) ([]models.Tag, error)
+	RemoveProductTag(productID uuid.UUID, tag string) error
+	Attach(products []models.Product) error
+}
+
+type tagService struct {
+	repo        repositories.TagRepository
+	productRepo repositories.ProductRepository
+}
+
+func NewTagService(repo repositories.TagRepository, productRepo repositories.ProductRepository) TagService {
+	return &tagService{
+		repo:        repo,
+		productRepo: productRepo,
+	}
+}
+
+func (s *tagService) GetAll() ([]models.Tag, error) {
+	return s.repo.GetAll()
+}
+
+func (s *tagService) GetByID(id uuid.UUID) (*models.Tag, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *tagService) Create(req *models.CreateTagRequest) (*models.Tag, error) {
+
+	name, err := normalizeTag(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	tag := &models.Tag{Name: name}
+	if err := s.repo.Create(tag); err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+func (s *tagService) Update(id uuid.UUID, req *models.UpdateTagRequest) (*models.Tag, error) {
+
+	name, err := normalizeTag(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Rename(id, name); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
+}
+
+func (s *tagService) Delete(id uuid.UUID) error {
+	return s.repo.Delete(id)
+}
+
+func (s *tagService) Merge(id uuid.UUID, req *models.MergeTagRequest) (*models.MergeTagResult, error) {
+
+	if req.TargetID == uuid.Nil {
+		return nil, errors.New("target_id is required")
+	}
+	if req.TargetID == id {
+		return nil, errors.New("tag cannot be merged into itself")
+	}
+
+	return s.repo.Merge(id, req.TargetID)
+}
+
+func (s *tagService) GetProductTags(productID uuid.UUID) ([]models.Tag, error) {
+
+	if _, err := s.productRepo.GetByID(productID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByProduct(productID)
+}
+
+// AddProductTags adds tags to the product and returns all of its tags.
+func (s *tagService) AddProductTags(productID uuid.UUID, req *models.ProductTagsRequest) ([]models.Tag, error) {
+
+	if len(req.Tags) == 0 {
+		return nil, errors.New("tags are required")
+	}
+
+	seen := make(map[string]bool)
+	var names []string
+	for _, raw := range req.Tags {
+		name, err := normalizeTag(raw)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	if err := s.repo.AddToProduct(productID, names); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByProduct(productID)
+}
+
+// RemoveProductTag takes the tag's ID or its name.
+func (s *tagService) RemoveProductTag(productID uuid.UUID, tag string) error {
+
+	tagID, err := uuid.Parse(tag)
+	if err != nil {
+		name, err := normalizeTag(tag)
+		if err != nil {
+			return err
+		}
+		found, err := s.repo.FindByName(name)
+		if err != nil {
+			return err
+		}
+		tagID = found.ID
+	}
+
+	return s.repo.RemoveFromProduct(productID, tagID)
+}
+
+// Attach loads the tag names of products for product reads.
+func (s *tagService) Attach(products []models.Product) error {
+
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(products))
+	for i := range products {
+		ids[i] = products[i].ID
+	}
+
+	byProduct, err := s.repo.GetForProducts(ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range products {
+		products[i].Tags = byProduct[products[i].ID]
+	}
+	return nil
+}
+
+func normalizeTag(name string) (string, error) {
+	name = models.NormalizeTag(name)
+	if name == "" {
+		return "", errors.New("tag name is required")
+	}
+	if utf8.RuneCountInString(name) > models.MaxTagLength {
+		return "", fmt.Errorf("tag name cannot be longer than %d characters", models.MaxTagLength)
+	}
+	return name, nil
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/anggakrnwn/product-catalog-api/repositories"
+	"github.com/google/uuid"
+)
+
+type TaxService interface {
+	GetAll() ([]models.TaxClass, error)
+	GetByID(id uuid.UUID) (*models.TaxClass, error)
+	Create(req *models.CreateTaxClassRequest) (*models.TaxClass, error)
+	Update(id uuid.UUID, req *models.UpdateTaxClassRequest) (*models.TaxClass, error)
+	Delete(id uuid.UUID) error
+	CreateRate(classID uuid.UUID, req *models.CreateTaxRateRequest) (*models.TaxRate, error)
+}
+
+type taxService struct {
+	repo repositories.TaxRepository
+}
+
+func NewTaxService(repo repositories.TaxRepository) TaxService {
+	return &taxService{repo: repo}
+}
+
+func (s *taxService) GetAll() ([]models.TaxClass, error) {
+	return s.repo.GetAll()
+}
+
+// GetByID returns the tax class with its full rate history.
+func (s *taxService) GetByID(id uuid.UUID) (*models.TaxClass, error) {
+
+	if id == uuid.Nil {
+		return nil, errors.New("tax class ID is required")
+	}
+
+	class, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	class.Rates, err = s.repo.GetRates(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return class, nil
+}
+
+func (s *taxService) Create(req *models.CreateTaxClassRequest) (*models.TaxClass, error) {
+
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+	if code == "" {
+		return nil, errors.New("code is required")
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+
+	var openingRate *models.TaxRate
+	if req.RateBP != nil {
+		if err := validateRateBP(*req.RateBP); err != nil {
+			return nil, err
+		}
+		openingRate = &models.TaxRate{
+			RateBP:        *req.RateBP,
+			EffectiveFrom: time.Now(),
+		}
+	}
+
+	class := &models.TaxClass{
+		Code: code,
+		Name: name,
+	}
+
+	if err := s.repo.Create(class, openingRate); err != nil {
+		return nil, err
+	}
+
+	return class, nil
+}
+
+func (s *taxService) Update(id uuid.UUID, req *models.UpdateTaxClassRequest) (*models.TaxClass, error) {
+
+	class, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, errors.New("name cannot be empty")
+		}
+		class.Name = name
+	}
+
+	if err := s.repo.Update(id, class); err != nil {
+		return nil, err
+	}
+
+	return class, nil
+}
+
+func (s *taxService) Delete(id uuid.UUID) error {
+
+	if id == uuid.Nil {
+		return errors.New("tax class ID is required")
+	}
+	return s.repo.Delete(id)
+}
+
+// CreateRate schedules a new rate for the class; without effective_from it
+// applies immediately.
+func (s *taxService) CreateRate(classID uuid.UUID, req *models.CreateTaxRateRequest) (*models.TaxRate, error) {
+
+	if classID == uuid.Nil {
+		return nil, errors.New("tax class ID is required")
+	}
+
+	if err := validateRateBP(req.RateBP); err != nil {
+		return nil, err
+	}
+
+	rate := &models.TaxRate{
+		TaxClassID:    classID,
+		RateBP:        req.RateBP,
+		EffectiveFrom: time.Now(),
+	}
+	if req.EffectiveFrom != nil {
+		rate.EffectiveFrom = *req.EffectiveFrom
+	}
+
+	if err := s.repo.CreateRate(rate); err != nil {
+		return nil, err
+	}
+
+	return rate, nil
+}
+
+func validateRateBP(rateBP int) error {
+	if rateBP < 0 || rateBP > 100000 {
+		return errors.New("rate_bp must be between 0 and 100000")
+	}
+	return nil
+}
@@ -0,0 +1,395 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/anggakrnwn/product-catalog-api/repositories"
+	"github.com/google/uuid"
+)
+
+type VariantService interface {
+	GetOptions(productID uuid.UUID) ([]models.ProductOption, error)
+	SetOptions(productID uuid.UUID, req *models.SetOptionsRequest) ([]models.ProductOption, error)
+	GetByProductID(productID uuid.UUID) ([]models.ProductVariant, error)
+	GetByID(productID, id uuid.UUID) (*models.ProductVariant, error)
+	Create(productID uuid.UUID, req *models.CreateVariantRequest) (*models.ProductVariant, error)
+	Update(productID, id uuid.UUID, req *models.UpdateVariantRequest) (*models.ProductVariant, error)
+	Delete(productID, id uuid.UUID) error
+	Generate(productID uuid.UUID, req *models.GenerateVariantsRequest) ([]models.ProductVariant, error)
+}
+
+type variantService struct {
+	repo        repositories.VariantRepository
+	productRepo repositories.ProductRepository
+}
+
+func NewVariantService(repo repositories.VariantRepository, productRepo repositories.ProductRepository) VariantService {
+	return &variantService{
+		repo:        repo,
+		productRepo: productRepo,
+	}
+}
+
+func (s *variantService) GetOptions(productID uuid.UUID) ([]models.ProductOption, error) {
+
+	if err := s.checkProduct(productID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetOptions(productID)
+}
+
+func (s *variantService) SetOptions(productID uuid.UUID, req *models.SetOptionsRequest) ([]models.ProductOption, error) {
+
+	if err := s.checkProduct(productID); err != nil {
+		return nil, err
+	}
+
+	seenNames := make(map[string]bool)
+	combinations := 1
+	options := make([]models.ProductOption, 0, len(req.Options))
+
+	for i, input := range req.Options {
+		name := strings.TrimSpace(input.Name)
+		if name == "" {
+			return nil, errors.New("option name is required")
+		}
+		if seenNames[strings.ToLower(name)] {
+			return nil, fmt.Errorf("option %s is defined twice", name)
+		}
+		seenNames[strings.ToLower(name)] = true
+
+		seenValues := make(map[string]bool)
+		var values []string
+		for _, v := range input.Values {
+			v = strings.TrimSpace(v)
+			if v == "" || seenValues[strings.ToLower(v)] {
+				continue
+			}
+			seenValues[strings.ToLower(v)] = true
+			values = append(values, v)
+		}
+		if len(values) == 0 {
+			return nil, fmt.Errorf("option %s must have at least one value", name)
+		}
+
+		combinations *= len(values)
+		if combinations > models.MaxVariantCombinations {
+			return nil, fmt.Errorf("options must not produce more than %d variants", models.MaxVariantCombinations)
+		}
+
+		options = append(options, models.ProductOption{
+			Name:     name,
+			Values:   values,
+			Position: i,
+		})
+	}
+
+	if err := s.repo.SetOptions(productID, options); err != nil {
+		return nil, err
+	}
+
+	return options, nil
+}
+
+func (s *variantService) GetByProductID(productID uuid.UUID) ([]models.ProductVariant, error) {
+
+	if err := s.checkProduct(productID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByProductID(productID)
+}
+
+func (s *variantService) GetByID(productID, id uuid.UUID) (*models.ProductVariant, error) {
+
+	variant, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if variant.ProductID != productID {
+		return nil, errors.New("variant not found")
+	}
+
+	return variant, nil
+}
+
+func (s *variantService) Create(productID uuid.UUID, req *models.CreateVariantRequest) (*models.ProductVariant, error) {
+
+	if err := s.checkProduct(productID); err != nil {
+		return nil, err
+	}
+
+	if req.Stock < 0 {
+		return nil, errors.New("stock cannot be negative")
+	}
+
+	if req.PriceOverride != nil && *req.PriceOverride < 0 {
+		return nil, errors.New("price must be positive")
+	}
+
+	options, err := s.repo.GetOptions(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	selected, err := matchOptions(options, req.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	barcode := trimOptional(req.Barcode)
+	if barcode != nil {
+		if err := models.ValidateBarcode(*barcode); err != nil {
+			return nil, err
+		}
+	}
+
+	variant := models.ProductVariant{
+		SKU:           trimOptional(req.SKU),
+		Barcode:       barcode,
+		PriceOverride: req.PriceOverride,
+		Stock:         req.Stock,
+		Options:       selected,
+	}
+
+	variants := []models.ProductVariant{variant}
+	if err := s.repo.Create(productID, variants); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByID(variants[0].ID)
+}
+
+func (s *variantService) Update(productID, id uuid.UUID, req *models.UpdateVariantRequest) (*models.ProductVariant, error) {
+
+	existing, err := s.GetByID(productID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.SKU != nil {
+		existing.SKU = trimOptional(req.SKU)
+	}
+
+	if req.Barcode != nil {
+		existing.Barcode = trimOptional(req.Barcode)
+		if existing.Barcode != nil {
+			if err := models.ValidateBarcode(*existing.Barcode); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if req.ClearPrice {
+		existing.PriceOverride = nil
+	} else if req.PriceOverride != nil {
+		if *req.PriceOverride < 0 {
+			return nil, errors.New("price must be positive")
+		}
+		existing.PriceOverride = req.PriceOverride
+	}
+
+	if req.Stock != nil {
+		if *req.Stock < 0 {
+			return nil, errors.New("stock cannot be negative")
+		}
+		existing.Stock = *req.Stock
+	}
+
+	if err := s.repo.Update(id, existing); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByID(id)
+}
+
+func (s *variantService) Delete(productID, id uuid.UUID) error {
+
+	if _, err := s.GetByID(productID, id); err != nil {
+		return err
+	}
+
+	return s.repo.Delete(id)
+}
+
+// Generate creates a variant for every option combination that does not
+// exist yet and returns only the new ones.
+func (s *variantService) Generate(productID uuid.UUID, req *models.GenerateVariantsRequest) ([]models.ProductVariant, error) {
+
+	if err := s.checkProduct(productID); err != nil {
+		return nil, err
+	}
+
+	if req.Stock < 0 {
+		return nil, errors.New("stock cannot be negative")
+	}
+
+	if req.PriceOverride != nil && *req.PriceOverride < 0 {
+		return nil, errors.New("price must be positive")
+	}
+
+	options, err := s.repo.GetOptions(productID)
+	if err != nil {
+		return nil, err
+	}
+	if len(options) == 0 {
+		return nil, errors.New("product options are required before generating variants")
+	}
+
+	existing, err := s.repo.GetByProductID(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	existingKeys := make(map[string]bool)
+	for _, v := range existing {
+		existingKeys[models.OptionKey(v.Options)] = true
+	}
+
+	prefix := strings.TrimSpace(req.SKUPrefix)
+
+	var created []models.ProductVariant
+	for _, combo := range optionMatrix(options) {
+		if existingKeys[models.OptionKey(combo)] {
+			continue
+		}
+
+		variant := models.ProductVariant{
+			PriceOverride: req.PriceOverride,
+			Stock:         req.Stock,
+			Options:       combo,
+		}
+
+		if prefix != "" {
+			parts := []string{prefix}
+			for _, o := range options {
+				parts = append(parts, combo[o.Name])
+			}
+			sku := strings.ToUpper(strings.ReplaceAll(strings.Join(parts, "-"), " ", ""))
+			variant.SKU = &sku
+		}
+
+		created = append(created, variant)
+	}
+
+	if len(created) == 0 {
+		return []models.ProductVariant{}, nil
+	}
+
+	if err := s.repo.Create(productID, created); err != nil {
+		return nil, err
+	}
+
+	// reload so the response carries the resolved price
+	createdIDs := make(map[uuid.UUID]bool, len(created))
+	for _, v := range created {
+		createdIDs[v.ID] = true
+	}
+
+	all, err := s.repo.GetByProductID(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.ProductVariant, 0, len(created))
+	for _, v := range all {
+		if createdIDs[v.ID] {
+			result = append(result, v)
+		}
+	}
+
+	return result, nil
+}
+
+func (s *variantService) checkProduct(productID uuid.UUID) error {
+
+	if productID == uuid.Nil {
+		return errors.New("product ID is required")
+	}
+
+	if _, err := s.productRepo.GetByID(productID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// matchOptions validates a requested combination against the product's option
+// definitions and returns it with canonical names and values.
+func matchOptions(options []models.ProductOption, requested map[string]string) (map[string]string, error) {
+
+	if len(options) == 0 {
+		if len(requested) > 0 {
+			return nil, errors.New("product has no options defined")
+		}
+		return map[string]string{}, nil
+	}
+
+	lookup := make(map[string]string, len(requested))
+	for name, value := range requested {
+		lookup[strings.ToLower(strings.TrimSpace(name))] = strings.TrimSpace(value)
+	}
+	if len(lookup) != len(options) {
+		return nil, errors.New("options must set a value for every product option")
+	}
+
+	selected := make(map[string]string, len(options))
+	for _, o := range options {
+		value, ok := lookup[strings.ToLower(o.Name)]
+		if !ok {
+			return nil, fmt.Errorf("option %s is required", o.Name)
+		}
+
+		found := false
+		for _, allowed := range o.Values {
+			if strings.EqualFold(allowed, value) {
+				selected[o.Name] = allowed
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%s is not a valid value for option %s", value, o.Name)
+		}
+	}
+
+	return selected, nil
+}
+
+// optionMatrix expands option definitions into every value combination.
+func optionMatrix(options []models.ProductOption) []map[string]string {
+
+	combos := []map[string]string{{}}
+	for _, o := range options {
+		var next []map[string]string
+		for _, combo := range combos {
+			for _, value := range o.Values {
+				c := make(map[string]string, len(combo)+1)
+				for k, v := range combo {
+					c[k] = v
+				}
+				c[o.Name] = value
+				next = append(next, c)
+			}
+		}
+		combos = next
+	}
+
+	return combos
+}
+
+func trimOptional(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*value)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
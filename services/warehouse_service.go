@@ -0,0 +1,176 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/anggakrnwn/product-catalog-api/models"
+	"github.com/anggakrnwn/product-catalog-api/repositories"
+	"github.com/google/uuid"
+)
+
+type WarehouseService interface {
+	GetAll() ([]models.Warehouse, error)
+	GetByID(id uuid.UUID) (*models.Warehouse, error)
+	Create(req *models.CreateWarehouseRequest) (*models.Warehouse, error)
+	Update(id uuid.UUID, req *models.UpdateWarehouseRequest) (*models.Warehouse, error)
+	Delete(id uuid.UUID) error
+	GetStockLevels(id uuid.UUID) ([]models.StockLevel, error)
+	Transfer(req *models.TransferStockRequest) (*models.Transfer, error)
+}
+
+type warehouseService struct {
+	repo        repositories.WarehouseRepository
+	stockRepo   repositories.StockRepository
+	variantRepo repositories.VariantRepository
+}
+
+func NewWarehouseService(repo repositories.WarehouseRepository, stockRepo repositories.StockRepository, variantRepo repositories.VariantRepository) WarehouseService {
+	return &warehouseService{
+		repo:        repo,
+		stockRepo:   stockRepo,
+		variantRepo: variantRepo,
+	}
+}
+
+func (s *warehouseService) GetAll() ([]models.Warehouse, error) {
+	return s.repo.GetAll()
+}
+
+func (s *warehouseService) GetByID(id uuid.UUID) (*models.Warehouse, error) {
+
+	if id == uuid.Nil {
+		return nil, errors.New("warehouse ID is required")
+	}
+	return s.repo.GetByID(id)
+}
+
+func (s *warehouseService) Create(req *models.CreateWarehouseRequest) (*models.Warehouse, error) {
+
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+	name := strings.TrimSpace(req.Name)
+
+	if code == "" {
+		return nil, errors.New("code is required")
+	}
+
+	if len(code) > 20 {
+		return nil, errors.New("code must not exceed 20 characters")
+	}
+
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+
+	warehouse := &models.Warehouse{
+		Code:      code,
+		Name:      name,
+		Address:   strings.TrimSpace(req.Address),
+		IsDefault: req.IsDefault,
+	}
+
+	if err := s.repo.Create(warehouse); err != nil {
+		return nil, err
+	}
+
+	return warehouse, nil
+}
+
+func (s *warehouseService) Update(id uuid.UUID, req *models.UpdateWarehouseRequest) (*models.Warehouse, error) {
+
+	existing, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, errors.New("name cannot be empty")
+		}
+		existing.Name = name
+	}
+
+	if req.Address != nil {
+		existing.Address = strings.TrimSpace(*req.Address)
+	}
+
+	if req.IsDefault != nil {
+		// there is always exactly one default, so it can only be moved, not cleared
+		if !*req.IsDefault && existing.IsDefault {
+			return nil, errors.New("default warehouse cannot be unset, mark another warehouse as default instead")
+		}
+		existing.IsDefault = *req.IsDefault
+	}
+
+	if err := s.repo.Update(id, existing); err != nil {
+		return nil, err
+	}
+
+	return existing, nil
+}
+
+func (s *warehouseService) Delete(id uuid.UUID) error {
+
+	if id == uuid.Nil {
+		return errors.New("warehouse ID is required")
+	}
+	return s.repo.Delete(id)
+}
+
+func (s *warehouseService) GetStockLevels(id uuid.UUID) ([]models.StockLevel, error) {
+
+	if _, err := s.GetByID(id); err != nil {
+		return nil, err
+	}
+	return s.repo.GetStockLevels(id)
+}
+
+func (s *warehouseService) Transfer(req *models.TransferStockRequest) (*models.Transfer, error) {
+
+	if req.ProductID == uuid.Nil {
+		return nil, errors.New("product_id is required")
+	}
+
+	if req.FromWarehouseID == uuid.Nil || req.ToWarehouseID == uuid.Nil {
+		return nil, errors.New("from_warehouse_id and to_warehouse_id are required")
+	}
+
+	if req.FromWarehouseID == req.ToWarehouseID {
+		return nil, errors.New("source and destination warehouse cannot be the same")
+	}
+
+	if req.Quantity <= 0 {
+		return nil, errors.New("quantity must be positive")
+	}
+
+	if _, err := s.repo.GetByID(req.FromWarehouseID); err != nil {
+		return nil, errors.New("source warehouse not found")
+	}
+	if _, err := s.repo.GetByID(req.ToWarehouseID); err != nil {
+		return nil, errors.New("destination warehouse not found")
+	}
+
+	variants, err := s.variantRepo.GetByProductID(req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	if len(variants) > 0 {
+		return nil, errors.New("stock cannot be transferred for a product with variants")
+	}
+
+	req.Reason = strings.TrimSpace(req.Reason)
+	req.Reference = strings.TrimSpace(req.Reference)
+	req.Actor = strings.TrimSpace(req.Actor)
+
+	transfer := &models.Transfer{}
+	if err := s.repo.Transfer(transfer, req); err != nil {
+		return nil, err
+	}
+
+	if transfer.Levels, err = s.stockRepo.GetLevels(req.ProductID); err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}